@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,25 +17,43 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/Abhaythakor/SigMap/internal/database"
+	"github.com/Abhaythakor/SigMap/internal/dns"
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
 	"github.com/Abhaythakor/SigMap/internal/handlers"
 	"github.com/Abhaythakor/SigMap/internal/integrations/chaos"
+	"github.com/Abhaythakor/SigMap/internal/integrations/ctlog"
 	"github.com/Abhaythakor/SigMap/internal/integrations/ipinfo"
+	"github.com/Abhaythakor/SigMap/internal/integrations/oidc"
 	"github.com/Abhaythakor/SigMap/internal/integrations/runner"
 	"github.com/Abhaythakor/SigMap/internal/jobs"
 	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/Abhaythakor/SigMap/internal/tlsgrab"
 	"github.com/Abhaythakor/SigMap/internal/vulnintel"
 	"github.com/Abhaythakor/SigMap/internal/vulnintel/sources"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
 	// Flags
 	syncFlag := flag.Bool("sync", false, "Sync technology metadata from Wappalyzer")
+	syncFromFlag := flag.String("sync-from", "", "Mirror URL or local directory for -sync (default SIGNATURE_SOURCE, then the wappalyzergo repository)")
 	ingestFlag := flag.Bool("ingest", false, "Ingest mock sample data for domains")
 	vulnFlag := flag.Bool("vuln", false, "Refresh vulnerability profiles")
 	alertFlag := flag.Bool("alert", false, "Run alert worker once")
+	assetsFlag := flag.Bool("assets", false, "Link existing domains into root domains and the host tree")
+	tlsFlag := flag.Bool("tls", false, "Grab TLS certificates from every known host")
+	ctImportFlag := flag.String("ct-import", "", "Import subdomains from an offline Certificate Transparency dump into -workspace")
+	pdnsImportFlag := flag.String("pdns-import", "", "Import historical resolutions from a passive DNS export (JSONL or CSV) into -workspace")
+	cloudImportFlag := flag.String("cloud-import", "", "Import an AWS, GCP or Azure inventory export (JSON) into -workspace")
+	cloudAccountFlag := flag.String("cloud-account", "", "AWS account ID or GCP project for -cloud-import of DNS exports, which do not name it")
+	eolFlag := flag.Bool("eol", false, "Import end-of-life release cycles and judge detected versions")
+	eolFromFlag := flag.String("eol-from", "", "URL, directory or file for -eol (default EOL_SOURCE, then endoflife.date)")
+	detectFlag := flag.String("detect", "", "Detect technologies on a URL, or in a saved HTTP response file, with the local fingerprint engine")
+	workspaceFlag := flag.String("workspace", "", "Workspace ID or slug for -ingest, -ct-import, -pdns-import and -cloud-import (default workspace if empty)")
 	flag.Parse()
 
 	// Load environment variables
@@ -53,33 +75,120 @@ func main() {
 		sources.NewGithubConnector(),
 	}
 	vulnService := vulnintel.NewService(db.Pool, vulnConnectors)
-	alertService := services.NewAlertService(db.Pool)
+	auditRepo := repositories.NewAuditRepository(db.Pool)
+	auditService := services.NewAuditService(auditRepo)
+	alertService := services.NewAlertService(db.Pool, auditService)
 	
-	chaosClient := chaos.NewClient(os.Getenv("CHAOS_API_KEY"))
-	chaosService := services.NewChaosService(repositories.NewDomainRepository(db.Pool), chaosClient)
+	subdomainSources := []services.SubdomainSource{chaos.NewClient(os.Getenv("CHAOS_API_KEY"))}
+	if ctURL := os.Getenv("CT_SOURCE_URL"); ctURL != "off" {
+		subdomainSources = append(subdomainSources, ctlog.NewClient(ctURL))
+	}
+	discoveryService := services.NewDiscoveryService(repositories.NewDomainRepository(db.Pool), subdomainSources...)
 	
 	ipInfoClient := ipinfo.NewClient(os.Getenv("IPINFO_TOKEN"))
-	ingestionService := services.NewIngestionService(repositories.NewDomainRepository(db.Pool), ipInfoClient)
+	assetRepo := repositories.NewAssetRepository(db.Pool)
+	dnsService := services.NewDNSService(repositories.NewDomainRepository(db.Pool), assetRepo, dns.NewClient(dns.ServersFromEnv()))
+	ingestionService := services.NewIngestionService(repositories.NewDomainRepository(db.Pool), assetRepo, dnsService, ipInfoClient)
+	passiveDNSService := services.NewPassiveDNSService(repositories.NewDomainRepository(db.Pool), assetRepo)
+	cloudService := services.NewCloudService(repositories.NewCloudRepository(db.Pool))
+	savedQueryService := services.NewSavedQueryService(repositories.NewSavedQueryRepository(db.Pool), repositories.NewDomainRepository(db.Pool), alertService)
+
+	certRepo := repositories.NewCertificateRepository(db.Pool)
+	tlsService := services.NewTLSService(repositories.NewDomainRepository(db.Pool), certRepo, tlsgrab.NewGrabber(), tlsgrab.PortsFromEnv())
 
 	cliRunner := runner.NewRunner()
-	httpxService := services.NewHTTPXService(repositories.NewDomainRepository(db.Pool), cliRunner)
+	fingerprintService := services.NewFingerprintService(repositories.NewFingerprintRepository(db.Pool), repositories.NewDomainRepository(db.Pool))
+	httpxService := services.NewHTTPXService(repositories.NewDomainRepository(db.Pool), assetRepo, cliRunner, fingerprintService)
+	httpxService.PreferLocal = os.Getenv("TECH_DETECT") == "local"
 	nucleiService := services.NewNucleiService(repositories.NewDomainRepository(db.Pool), cliRunner)
 
+	authService := services.NewAuthService(repositories.NewUserRepository(db.Pool), oidc.NewClient(oidc.LoadConfig()))
+	tokenService := services.NewTokenService(repositories.NewTokenRepository(db.Pool), repositories.NewUserRepository(db.Pool))
+	workspaceService := services.NewWorkspaceService(repositories.NewWorkspaceRepository(db.Pool))
+	scheduleService := services.NewScheduleService(repositories.NewScheduleRepository(db.Pool))
+	scanService := services.NewScanService(repositories.NewDomainRepository(db.Pool), ingestionService, discoveryService, tlsService, httpxService, nucleiService)
+
+	syncService := services.NewSyncService(db.Pool, repositories.NewSignatureRepository(db.Pool), os.Getenv("SIGNATURE_SOURCE"))
+	if *syncFromFlag != "" {
+		syncService.Source = *syncFromFlag
+	}
+	riskService := services.NewRiskService(repositories.NewRiskRepository(db.Pool))
+	eolService := services.NewEOLService(repositories.NewEOLRepository(db.Pool), os.Getenv("EOL_SOURCE"))
+	if *eolFromFlag != "" {
+		eolService.Source = *eolFromFlag
+	}
+
 	// Handle Flags
 	if *syncFlag {
-		if err := services.NewSyncService(db.Pool).Sync(context.Background()); err != nil {
+		if err := syncService.Sync(context.Background()); err != nil {
 			log.Fatalf("Sync failed: %v", err)
 		}
 		return
 	}
 
+	if *eolFlag {
+		res, err := eolService.Import(context.Background(), "")
+		if err != nil {
+			log.Fatalf("EOL import failed: %v", err)
+		}
+		for _, p := range res.Missing {
+			log.Printf("%s: not found at %s", p, res.Source)
+		}
+		return
+	}
+
+	if *detectFlag != "" {
+		var detections []fingerprint.Detection
+		if f, err := os.Open(*detectFlag); err == nil {
+			detections, err = fingerprintService.DetectSaved(context.Background(), f, "")
+			f.Close()
+			if err != nil {
+				log.Fatalf("Detection failed: %v", err)
+			}
+		} else {
+			var resp *fingerprint.Response
+			resp, detections, err = fingerprintService.Detect(context.Background(), *detectFlag)
+			if err != nil {
+				log.Fatalf("Detection failed: %v", err)
+			}
+			log.Printf("%s answered %d", resp.URL, resp.StatusCode)
+		}
+		for _, d := range detections {
+			fmt.Printf("%-30s %-15s %3d%%  %s\n", d.Name, d.Version, d.Confidence, strings.Join(d.Evidence, ", "))
+		}
+		return
+	}
+
+	cliCtx := context.Background()
+	if *workspaceFlag != "" {
+		ws, err := workspaceService.Lookup(cliCtx, *workspaceFlag)
+		if err != nil {
+			log.Fatalf("Unknown workspace %q: %v", *workspaceFlag, err)
+		}
+		cliCtx = workspace.WithID(cliCtx, ws.ID)
+	}
+
 	if *ingestFlag {
-		if err := ingestionService.IngestFromDirectory(context.Background(), "testDir"); err != nil {
+		if err := ingestionService.IngestFromDirectory(cliCtx, "testDir"); err != nil {
 			log.Fatalf("Ingestion failed: %v", err)
 		}
 		return
 	}
 
+	if *ctImportFlag != "" {
+		f, err := os.Open(*ctImportFlag)
+		if err != nil {
+			log.Fatalf("CT import failed: %v", err)
+		}
+		res, err := discoveryService.ImportCTDump(cliCtx, f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("CT import failed after %d names: %v", res.Imported, err)
+		}
+		log.Printf("Imported %d subdomains from CT dump (%d outside tracked root domains skipped)", res.Imported, res.Skipped)
+		return
+	}
+
 	if *vulnFlag {
 		if err := jobs.NewVulnRefreshJob(db.Pool, vulnService).Run(context.Background()); err != nil {
 			log.Fatalf("Vuln refresh failed: %v", err)
@@ -87,6 +196,52 @@ func main() {
 		return
 	}
 
+	if *assetsFlag {
+		n, err := repositories.NewDomainRepository(db.Pool).LinkUnlinkedDomains(context.Background())
+		if err != nil {
+			log.Fatalf("Asset linking failed after %d domains: %v", n, err)
+		}
+		log.Printf("Linked %d domains into the host tree", n)
+		return
+	}
+
+	if *pdnsImportFlag != "" {
+		f, err := os.Open(*pdnsImportFlag)
+		if err != nil {
+			log.Fatalf("Passive DNS import failed: %v", err)
+		}
+		res, err := passiveDNSService.Import(cliCtx, f, filepath.Base(*pdnsImportFlag))
+		f.Close()
+		if err != nil {
+			log.Fatalf("Passive DNS import failed after %d resolutions: %v", res.Stored, err)
+		}
+		log.Printf("Imported %d resolutions (%d outside tracked root domains, %d unsupported types, %d invalid)", res.Stored, res.OutOfScope, res.Unsupported, res.Invalid)
+		return
+	}
+
+	if *cloudImportFlag != "" {
+		f, err := os.Open(*cloudImportFlag)
+		if err != nil {
+			log.Fatalf("Cloud import failed: %v", err)
+		}
+		res, err := cloudService.Import(cliCtx, f, *cloudAccountFlag, filepath.Base(*cloudImportFlag))
+		f.Close()
+		if err != nil {
+			log.Fatalf("Cloud import failed: %v", err)
+		}
+		log.Printf("Imported %d %s resources (%d unsupported records skipped); %d hosts linked to cloud resources", res.Resources, res.Format, res.Skipped, res.Linked)
+		return
+	}
+
+	if *tlsFlag {
+		n, err := tlsService.ScanAll(context.Background())
+		if err != nil {
+			log.Fatalf("TLS scan failed after %d hosts: %v", n, err)
+		}
+		log.Printf("Grabbed TLS certificates from %d hosts", n)
+		return
+	}
+
 	if *alertFlag {
 		if err := jobs.NewAlertWorker(db.Pool, alertService).Run(context.Background()); err != nil {
 			log.Fatalf("Alert worker failed: %v", err)
@@ -95,19 +250,42 @@ func main() {
 	}
 
 	// Background Workers
-	go startBackgroundJobs(db.Pool, vulnService, alertService)
+	dashboardService := services.NewDashboardService(repositories.NewDashboardRepository(db.Pool))
+	scheduler := jobs.NewScheduler(scheduleService.Repo, scheduleService, scanService, auditService)
+	if n, err := strconv.Atoi(os.Getenv("SCHEDULER_MAX_RUNS")); err == nil && n > 0 {
+		scheduler.MaxRuns = n
+	}
+	scheduler.Every("dns", time.Hour, dnsService.RefreshStale)
+	scheduler.Every("cloud", time.Hour, cloudService.ReconcileAll)
+	scheduler.Every("saved-views", 15*time.Minute, savedQueryService.EvaluateAll)
+	scheduler.Every("eol", time.Hour, eolService.Evaluate)
+	scheduler.Every("risk", time.Minute, riskService.Recompute)
+	scheduler.Every("risk-refresh", time.Hour, riskService.RefreshStale)
+	scheduler.Every("dashboard", 15*time.Minute, dashboardService.RefreshAll)
+	scheduler.Every("dashboard-ingest", time.Minute, dashboardService.RefreshDirty)
+	go startBackgroundJobs(scheduler, db.Pool, alertService, authService)
 
 	// Repositories
 	dashboardRepo := repositories.NewDashboardRepository(db.Pool)
 	domainRepo := repositories.NewDomainRepository(db.Pool)
+	go func() {
+		// Version keys of detections stored before they existed
+		if n, err := domainRepo.BackfillVersionKeys(context.Background()); err != nil {
+			log.Printf("Version key backfill failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Backfilled version keys on %d detections", n)
+		}
+	}()
 	techRepo := repositories.NewTechRepository(db.Pool)
 	categoryRepo := repositories.NewCategoryRepository(db.Pool)
 	trendRepo := repositories.NewTrendRepo(db.Pool)
+	savedQueryRepo := repositories.NewSavedQueryRepository(db.Pool)
 
 	// Handlers
-	dashboardHandler := handlers.NewDashboardHandler(dashboardRepo)
-	domainHandler := handlers.NewDomainHandler(domainRepo)
-	techHandler := handlers.NewTechHandler(techRepo)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardRepo, dashboardService)
+	domainHandler := handlers.NewDomainHandler(domainRepo, savedQueryRepo)
+	techHandler := handlers.NewTechHandler(techRepo, savedQueryRepo, vulnService)
+	searchHandler := handlers.NewSearchHandler(domainRepo, techRepo, savedQueryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryRepo)
 	bookmarkHandler := handlers.NewBookmarkHandler(domainRepo)
 	noteHandler := handlers.NewNoteHandler(domainRepo)
@@ -115,9 +293,21 @@ func main() {
 	deltaHandler := handlers.NewDeltaHandler(domainRepo)
 	exportHandler := handlers.NewExportHandler(domainRepo)
 	
-	scanHandler := handlers.NewScanHandler(domainRepo, ingestionService, vulnService, chaosService, httpxService, nucleiService)
+	scanHandler := handlers.NewScanHandler(domainRepo, ingestionService, vulnService, discoveryService, tlsService, httpxService, nucleiService)
 	settingsHandler := handlers.NewSettingsHandler(domainRepo)
 	vulnHandler := handlers.NewVulnHandler(vulnService)
+	authHandler := handlers.NewAuthHandler(authService)
+	tokenHandler := handlers.NewTokenHandler(tokenService)
+	ingestHandler := handlers.NewIngestHandler(ingestionService)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	assetHandler := handlers.NewAssetHandler(assetRepo)
+	certificateHandler := handlers.NewCertificateHandler(certRepo)
+	cloudHandler := handlers.NewCloudHandler(cloudService)
+	fingerprintHandler := handlers.NewFingerprintHandler(fingerprintService)
+	signatureHandler := handlers.NewSignatureHandler(syncService)
+	eolHandler := handlers.NewEOLHandler(eolService)
 
 	// Router
 	r := chi.NewRouter()
@@ -127,35 +317,160 @@ func main() {
 	r.Use(middleware.Throttle(100))
 	r.Use(middleware.Timeout(60 * time.Second))
 
+	// Authentication (OIDC SSO, enabled when OIDC_ISSUER is configured)
+	auth := &customMiddleware.Auth{Enabled: authService.Enabled(), ResolveSession: authService.ResolveSession, ResolveToken: tokenService.Resolve}
+	if auth.Enabled {
+		log.Printf("SSO enabled via %s", authService.OIDC.Config.IssuerURL)
+	}
+	r.Use(auth.Authenticate)
+
+	// Workspaces (selected per browser, fixed per API token)
+	workspaces := &customMiddleware.Workspaces{Resolve: workspaceService.Resolve}
+	r.Use(workspaces.Scope)
+
+	// Audit trail of every mutating request
+	auditLog := &customMiddleware.Audit{Persist: auditService.Persist}
+	r.Use(auditLog.Record)
+	viewer := auth.RequireRole(models.RoleViewer)
+	analyst := auth.RequireRole(models.RoleAnalyst)
+	admin := auth.RequireRole(models.RoleAdmin)
+
 	fileServer := http.FileServer(http.Dir("./static"))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
 
 	// Routes
+	r.Get("/auth/login", authHandler.Login)
+	r.Get("/auth/callback", authHandler.Callback)
+	r.Post("/auth/logout", authHandler.Logout)
+	r.Get("/auth/logged-out", authHandler.LoggedOut)
+	r.Get("/me", authHandler.Badge)
+	r.Get("/workspaces/switcher", workspaceHandler.Switcher)
+	r.With(viewer).Post("/workspaces/switch", workspaceHandler.Switch)
+
 	r.Get("/", dashboardHandler.ServeHTTP)
-	r.Get("/internal/vuln/{technology}", vulnHandler.GetProfile)
+	r.With(admin).Post("/dashboard/refresh", dashboardHandler.Refresh)
+	r.With(auth.RequireScope(models.ScopeRead, models.RoleViewer)).Get("/internal/vuln/{technology}", vulnHandler.GetProfile)
 	
 	r.Get("/domains", domainHandler.List)
 	r.Get("/domains/redirect", domainHandler.RedirectByName)
 	r.Get("/domains/{id}", domainHandler.Detail)
+	r.With(analyst).Post("/domains/bulk", domainHandler.BulkEdit)
+	r.With(analyst).Post("/domains/{id}/edit", domainHandler.Edit)
+	r.Get("/ips/redirect", assetHandler.RedirectByAddress)
+	r.Get("/ips/{id}", assetHandler.IPDetail)
+	r.Get("/certificates", certificateHandler.List)
+	r.Get("/certificates/{id}", certificateHandler.Detail)
+	r.Get("/cloud", cloudHandler.View)
 	r.Get("/technologies", techHandler.List)
+	r.Get("/technologies/{id}", techHandler.Detail)
+	r.Get("/search/suggest", searchHandler.Suggest)
+	r.Get("/queries", searchHandler.Views)
+	r.Get("/queries/pinned", searchHandler.Pinned)
+	r.With(analyst).Post("/queries", searchHandler.Save)
+	r.With(analyst).Delete("/queries/{id}", searchHandler.Delete)
+	r.With(analyst).Post("/queries/{id}/pin", searchHandler.Pin)
+	r.With(analyst).Post("/queries/{id}/subscriptions", searchHandler.Subscribe)
 	r.Get("/categories", categoryHandler.List)
+	r.Get("/categories/{id}", categoryHandler.Detail)
 	r.Get("/bookmarks", bookmarkHandler.List)
-	r.Post("/bookmarks/toggle", bookmarkHandler.Toggle)
+	r.With(analyst).Post("/bookmarks/toggle", bookmarkHandler.Toggle)
 	r.Route("/notes", func(r chi.Router) {
 		r.Get("/", noteHandler.List)
-		r.Get("/new", noteHandler.New)
-		r.Post("/", noteHandler.Create)
-		r.Get("/{id}/edit", noteHandler.Edit)
-		r.Post("/{id}", noteHandler.Update)
-		r.Delete("/{id}", noteHandler.Delete)
+		r.With(analyst).Get("/new", noteHandler.New)
+		r.With(analyst).Post("/", noteHandler.Create)
+		r.With(analyst).Get("/{id}/edit", noteHandler.Edit)
+		r.With(analyst).Post("/{id}", noteHandler.Update)
+		r.With(analyst).Delete("/{id}", noteHandler.Delete)
 	})
 	r.Get("/trends", trendHandler.List)
 	r.Get("/delta", deltaHandler.List)
-	r.Get("/export/domains", exportHandler.Domains)
-	r.Post("/scan", scanHandler.Trigger)
-	r.Get("/settings/alerts", settingsHandler.AlertsView)
-	r.Post("/settings/alerts", settingsHandler.AddChannel)
-	r.Delete("/settings/alerts/{id}", settingsHandler.DeleteChannel)
+	r.With(auth.RequireScope(models.ScopeRead, models.RoleViewer)).Get("/export/domains", exportHandler.Domains)
+	r.With(auth.RequireScope(models.ScopeScan, models.RoleAnalyst)).Post("/scan", scanHandler.Trigger)
+	r.Route("/schedules", func(r chi.Router) {
+		r.With(viewer).Get("/", scheduleHandler.List)
+		r.With(analyst).Post("/", scheduleHandler.Create)
+		r.With(analyst).Post("/{id}/toggle", scheduleHandler.Toggle)
+		r.With(analyst).Delete("/{id}", scheduleHandler.Delete)
+		r.With(analyst).Post("/{id}/targets", scheduleHandler.AddTargets)
+		r.With(analyst).Delete("/{id}/targets/{targetID}", scheduleHandler.RemoveTarget)
+		r.With(analyst).Post("/{id}/targets/{targetID}/run", scheduleHandler.RunNow)
+	})
+	r.Route("/settings", func(r chi.Router) {
+		r.With(admin).Get("/alerts", settingsHandler.AlertsView)
+		r.With(admin).Post("/alerts", settingsHandler.AddChannel)
+		r.With(admin).Delete("/alerts/{id}", settingsHandler.DeleteChannel)
+		r.With(viewer).Get("/tags", settingsHandler.TagsView)
+		r.With(analyst).Post("/tags", settingsHandler.AddTagRule)
+		r.With(analyst).Delete("/tags/{id}", settingsHandler.DeleteTagRule)
+		r.With(viewer).Get("/tokens", tokenHandler.View)
+		r.With(viewer).Post("/tokens", tokenHandler.Create)
+		r.With(viewer).Delete("/tokens/{id}", tokenHandler.Revoke)
+		r.With(viewer).Get("/workspaces", workspaceHandler.View)
+		r.With(viewer).Post("/workspaces", workspaceHandler.Create)
+		r.With(admin).Post("/workspaces/members", workspaceHandler.SetMember)
+		r.With(admin).Delete("/workspaces/members/{userID}", workspaceHandler.RemoveMember)
+		r.With(admin).Get("/signatures", signatureHandler.View)
+		r.With(admin).Post("/signatures/sync", signatureHandler.Sync)
+		r.With(admin).Post("/signatures/{id}/apply", signatureHandler.Apply)
+		r.With(admin).Post("/signatures/{id}/pin", signatureHandler.Pin)
+		r.With(admin).Delete("/signatures/{id}/pin", signatureHandler.Unpin)
+		r.With(viewer).Get("/fingerprints", fingerprintHandler.View)
+		r.With(admin).Post("/fingerprints", fingerprintHandler.Save)
+		r.With(viewer).Post("/fingerprints/test", fingerprintHandler.Test)
+		r.With(admin).Delete("/fingerprints/{id}", fingerprintHandler.Delete)
+		r.With(viewer).Get("/eol", eolHandler.View)
+		r.With(admin).Post("/eol/import", eolHandler.Import)
+		r.With(admin).Post("/eol/mappings", eolHandler.SetMapping)
+		r.With(admin).Delete("/eol/mappings", eolHandler.DeleteMapping)
+		r.With(admin).Get("/audit", auditHandler.View)
+		r.With(admin).Get("/audit/export", auditHandler.Export)
+	})
+
+	// Automation API (bearer tokens)
+	r.Route("/api", func(r chi.Router) {
+		r.With(auth.RequireScope(models.ScopeIngest, models.RoleAnalyst)).Post("/ingest", ingestHandler.Ingest)
+		r.With(auth.RequireScope(models.ScopeIngest, models.RoleAnalyst)).Post("/cloud/import", cloudHandler.ImportJSON)
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(models.ScopeRead, models.RoleViewer))
+			r.Get("/search", searchHandler.SearchJSON)
+			r.Get("/search/fields", searchHandler.FieldsJSON)
+			r.Get("/search/suggest", searchHandler.SuggestJSON)
+			r.Get("/queries", searchHandler.ListJSON)
+			r.Get("/queries/{id}/results", searchHandler.ResultsJSON)
+			r.Get("/queries/{id}/changes", searchHandler.ChangesJSON)
+			r.Get("/fingerprints", fingerprintHandler.ListJSON)
+			r.Get("/fingerprints/{id}/revisions", fingerprintHandler.RevisionsJSON)
+			r.Post("/fingerprints/test", fingerprintHandler.TestJSON)
+			r.Get("/eol/products", eolHandler.ProductsJSON)
+			r.Get("/eol/mappings", eolHandler.MappingsJSON)
+			r.Get("/dashboard/refreshes", dashboardHandler.RefreshesJSON)
+			r.Get("/trends", trendHandler.ListJSON)
+		})
+		r.With(auth.RequireScope(models.ScopeScan, models.RoleAnalyst)).Post("/detect", fingerprintHandler.DetectJSON)
+		r.With(auth.RequireScope(models.ScopeQueries, models.RoleAnalyst)).Post("/queries", searchHandler.CreateJSON)
+		r.With(auth.RequireScope(models.ScopeQueries, models.RoleAnalyst)).Delete("/queries/{id}", searchHandler.DeleteJSON)
+		r.With(auth.RequireScope(models.ScopeQueries, models.RoleAnalyst)).Put("/queries/{id}/subscriptions", searchHandler.SubscriptionsJSON)
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(models.ScopeAdmin, models.RoleAdmin))
+			r.Get("/tokens", tokenHandler.ListJSON)
+			r.Post("/tokens", tokenHandler.CreateJSON)
+			r.Delete("/tokens/{id}", tokenHandler.RevokeJSON)
+			r.Get("/audit", auditHandler.Export)
+			r.Get("/signatures", signatureHandler.ListJSON)
+			r.Get("/signatures/diff", signatureHandler.DiffJSON)
+			r.Post("/signatures/sync", signatureHandler.SyncJSON)
+			r.Post("/signatures/{id}/apply", signatureHandler.ApplyJSON)
+			r.Post("/signatures/{id}/pin", signatureHandler.PinJSON)
+			r.Delete("/signatures/{id}/pin", signatureHandler.UnpinJSON)
+			r.Post("/fingerprints", fingerprintHandler.SaveJSON)
+			r.Put("/fingerprints/{id}", fingerprintHandler.SaveJSON)
+			r.Delete("/fingerprints/{id}", fingerprintHandler.DeleteJSON)
+			r.Post("/eol/import", eolHandler.ImportJSON)
+			r.Put("/eol/mappings", eolHandler.SetMappingJSON)
+			r.Delete("/eol/mappings", eolHandler.DeleteMappingJSON)
+			r.Post("/dashboard/refresh", dashboardHandler.RefreshJSON)
+		})
+	})
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK); w.Write([]byte("OK")) })
 
@@ -165,14 +480,17 @@ func main() {
 	http.ListenAndServe(":"+port, r)
 }
 
-func startBackgroundJobs(pool *pgxpool.Pool, vulnSvc *vulnintel.Service, alertSvc *services.AlertService) {
+func startBackgroundJobs(scheduler *jobs.Scheduler, pool *pgxpool.Pool, alertSvc *services.AlertService, authSvc *services.AuthService) {
 	alertWorker := jobs.NewAlertWorker(pool, alertSvc)
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
+	scheduler.Every("alerts", 5*time.Minute, alertWorker.Run)
+	if authSvc.Enabled() {
+		scheduler.Every("sessions", 5*time.Minute, func(ctx context.Context) error {
+			_, err := authSvc.Users.PurgeExpiredSessions(ctx)
+			return err
+		})
+	}
 
-	for range ticker.C {
-		if err := alertWorker.Run(context.Background()); err != nil {
-			log.Printf("Background alert worker error: %v", err)
-		}
+	if err := scheduler.Run(context.Background()); err != nil {
+		log.Printf("Scheduler stopped: %v", err)
 	}
 }
@@ -0,0 +1,145 @@
+// Package audit lets handlers and services describe the action a request
+// performs. The audit middleware opens an event per request and persists it
+// once the handler returns.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+type contextKey struct{}
+
+// Recorder holds the in-flight event of one request.
+type Recorder struct {
+	mu     sync.Mutex
+	event  *models.AuditEvent
+	closed bool
+}
+
+// Begin attaches an in-flight event to ctx.
+func Begin(ctx context.Context, e *models.AuditEvent) (context.Context, *Recorder) {
+	rec := &Recorder{event: e}
+	return context.WithValue(ctx, contextKey{}, rec), rec
+}
+
+// Close ends the request; later descriptions from background work that
+// outlived the request no longer touch the event.
+func (r *Recorder) Close() *models.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.event
+}
+
+// Describe names the action and target of the current request and stores the
+// fields that differ between before and after. Either side may be nil for
+// creations and deletions. It reports false when ctx has no open event.
+func Describe(ctx context.Context, action, targetType string, targetID int, targetName string, before, after interface{}) bool {
+	rec, _ := ctx.Value(contextKey{}).(*Recorder)
+	if rec == nil {
+		return false
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.closed {
+		return false
+	}
+	Fill(rec.event, action, targetType, targetID, targetName, before, after)
+	return true
+}
+
+// SetActor overrides the actor of the open event, e.g. right after login.
+func SetActor(ctx context.Context, actorType string, actorID int, actorName string) {
+	rec, _ := ctx.Value(contextKey{}).(*Recorder)
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !rec.closed {
+		rec.event.ActorType = actorType
+		rec.event.ActorID = strconv.Itoa(actorID)
+		rec.event.ActorName = actorName
+	}
+}
+
+// Origin returns a new event attributed to whoever started the request ctx
+// descends from, or to the system when there was none.
+func Origin(ctx context.Context) *models.AuditEvent {
+	rec, _ := ctx.Value(contextKey{}).(*Recorder)
+	if rec == nil {
+		return &models.AuditEvent{ActorType: models.ActorSystem, ActorName: "sigmap"}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &models.AuditEvent{
+		ActorType: rec.event.ActorType,
+		ActorID:   rec.event.ActorID,
+		ActorName: rec.event.ActorName,
+		IP:        rec.event.IP,
+	}
+}
+
+// Fill sets the action, target and diff of an event.
+func Fill(e *models.AuditEvent, action, targetType string, targetID int, targetName string, before, after interface{}) {
+	e.Action = action
+	e.TargetType = targetType
+	if targetID != 0 {
+		e.TargetID = strconv.Itoa(targetID)
+	}
+	e.TargetName = targetName
+	e.Before, e.After = Diff(before, after)
+}
+
+// Diff returns the JSON of the fields that changed between two snapshots.
+// Snapshots are anything that marshals to a JSON object.
+func Diff(before, after interface{}) (json.RawMessage, json.RawMessage) {
+	b := toMap(before)
+	a := toMap(after)
+	if b == nil || a == nil {
+		return marshal(b), marshal(a)
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for k, v := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(v, av) {
+			changedBefore[k] = v
+		}
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || !reflect.DeepEqual(v, bv) {
+			changedAfter[k] = v
+		}
+	}
+	return marshal(changedBefore), marshal(changedAfter)
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return map[string]interface{}{"value": v}
+	}
+	return m
+}
+
+func marshal(m map[string]interface{}) json.RawMessage {
+	if len(m) == 0 {
+		return nil
+	}
+	raw, _ := json.Marshal(m)
+	return raw
+}
@@ -0,0 +1,205 @@
+package cloudinv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// parseRoute53 reads `aws route53 list-resource-record-sets` output. Alias
+// records target the AWS resource they alias, without the "dualstack."
+// prefix so they match the resource's own DNS name.
+func parseRoute53(data []byte, account string) (Inventory, error) {
+	inv := Inventory{Format: FormatRoute53}
+	if account == "" {
+		return inv, fmt.Errorf("route53 exports do not name their account; pass the account ID")
+	}
+	var export struct {
+		ResourceRecordSets []struct {
+			Name            string
+			Type            string
+			SetIdentifier   string
+			Region          string
+			ResourceRecords []struct{ Value string }
+			AliasTarget     *struct{ DNSName string }
+		}
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return inv, err
+	}
+
+	for _, rr := range export.ResourceRecordSets {
+		res := models.CloudResource{
+			Provider:   models.CloudAWS,
+			Account:    account,
+			Region:     rr.Region,
+			Name:       rr.Name,
+			RecordType: rr.Type,
+			ResourceID: hostname(rr.Name) + "/" + strings.ToUpper(rr.Type),
+		}
+		if rr.SetIdentifier != "" {
+			res.ResourceID += "/" + rr.SetIdentifier
+		}
+		if rr.AliasTarget != nil {
+			// Alias values are names regardless of the record type.
+			res.RecordType = "CNAME"
+			inv.addRecord(res, []string{strings.TrimPrefix(hostname(rr.AliasTarget.DNSName), "dualstack.")})
+			continue
+		}
+		values := make([]string, 0, len(rr.ResourceRecords))
+		for _, v := range rr.ResourceRecords {
+			values = append(values, v.Value)
+		}
+		inv.addRecord(res, values)
+	}
+	return inv, nil
+}
+
+// parseEC2 reads `aws ec2 describe-instances` output. Every public IPv4
+// and IPv6 address of an instance is owned by the reservation's account.
+func parseEC2(data []byte) (Inventory, error) {
+	inv := Inventory{Format: FormatEC2}
+	var export struct {
+		Reservations []struct {
+			OwnerId   string
+			Instances []struct {
+				InstanceId        string
+				PublicIpAddress   string
+				PublicDnsName     string
+				Ipv6Address       string
+				Placement         struct{ AvailabilityZone string }
+				State             struct{ Name string }
+				Tags              []struct{ Key, Value string }
+				NetworkInterfaces []struct {
+					Association *struct {
+						PublicIp      string
+						PublicDnsName string
+					}
+					Ipv6Addresses []struct{ Ipv6Address string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return inv, err
+	}
+
+	for _, rsv := range export.Reservations {
+		for _, in := range rsv.Instances {
+			if in.State.Name == "terminated" {
+				continue
+			}
+			ips := []string{in.PublicIpAddress, in.Ipv6Address}
+			names := []string{in.PublicDnsName}
+			for _, ni := range in.NetworkInterfaces {
+				if ni.Association != nil {
+					ips = append(ips, ni.Association.PublicIp)
+					names = append(names, ni.Association.PublicDnsName)
+				}
+				for _, v6 := range ni.Ipv6Addresses {
+					ips = append(ips, v6.Ipv6Address)
+				}
+			}
+			name := in.InstanceId
+			for _, t := range in.Tags {
+				if t.Key == "Name" && t.Value != "" {
+					name = t.Value
+				}
+			}
+			inv.Resources = append(inv.Resources, models.CloudResource{
+				Provider:   models.CloudAWS,
+				Account:    rsv.OwnerId,
+				Region:     zoneRegion(in.Placement.AvailabilityZone),
+				Type:       models.CloudInstance,
+				ResourceID: in.InstanceId,
+				Name:       name,
+				Hostnames:  hostnames(names...),
+				Addresses:  addresses(ips...),
+			})
+		}
+	}
+	return inv, nil
+}
+
+// parseELB reads `aws elbv2 describe-load-balancers` or classic `aws elb
+// describe-load-balancers` output. Classic load balancers do not name their
+// account, so account is required for them.
+func parseELB(data []byte, account string) (Inventory, error) {
+	inv := Inventory{Format: FormatELB}
+	var export struct {
+		LoadBalancers []struct {
+			LoadBalancerArn   string
+			LoadBalancerName  string
+			DNSName           string
+			AvailabilityZones []struct {
+				LoadBalancerAddresses []struct {
+					IpAddress    string
+					IPv6Address  string
+					AllocationId string
+				}
+			}
+		}
+		LoadBalancerDescriptions []struct {
+			LoadBalancerName  string
+			DNSName           string
+			AvailabilityZones []string
+		}
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return inv, err
+	}
+
+	for _, lb := range export.LoadBalancers {
+		// arn:aws:elasticloadbalancing:<region>:<account>:loadbalancer/...
+		arn := strings.SplitN(lb.LoadBalancerArn, ":", 6)
+		if len(arn) < 6 {
+			return inv, fmt.Errorf("load balancer %s: malformed ARN %q", lb.LoadBalancerName, lb.LoadBalancerArn)
+		}
+		var ips []string
+		for _, az := range lb.AvailabilityZones {
+			for _, a := range az.LoadBalancerAddresses {
+				ips = append(ips, a.IpAddress, a.IPv6Address)
+			}
+		}
+		inv.Resources = append(inv.Resources, models.CloudResource{
+			Provider:   models.CloudAWS,
+			Account:    arn[4],
+			Region:     arn[3],
+			Type:       models.CloudLoadBalancer,
+			ResourceID: lb.LoadBalancerArn,
+			Name:       lb.LoadBalancerName,
+			Hostnames:  hostnames(lb.DNSName),
+			Addresses:  addresses(ips...),
+		})
+	}
+
+	if len(export.LoadBalancerDescriptions) > 0 && account == "" {
+		return inv, fmt.Errorf("classic load balancer exports do not name their account; pass the account ID")
+	}
+	for _, lb := range export.LoadBalancerDescriptions {
+		region := ""
+		if len(lb.AvailabilityZones) > 0 {
+			region = zoneRegion(lb.AvailabilityZones[0])
+		}
+		inv.Resources = append(inv.Resources, models.CloudResource{
+			Provider:   models.CloudAWS,
+			Account:    account,
+			Region:     region,
+			Type:       models.CloudLoadBalancer,
+			ResourceID: region + "/" + lb.LoadBalancerName,
+			Name:       lb.LoadBalancerName,
+			Hostnames:  hostnames(lb.DNSName),
+		})
+	}
+	return inv, nil
+}
+
+// zoneRegion returns the region of an availability zone ("us-east-1a").
+func zoneRegion(zone string) string {
+	if n := len(zone); n > 0 && zone[n-1] >= 'a' && zone[n-1] <= 'z' {
+		return zone[:n-1]
+	}
+	return zone
+}
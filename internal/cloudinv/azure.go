@@ -0,0 +1,71 @@
+package cloudinv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+type azureRecordProps struct {
+	Fqdn        string                         `json:"fqdn"`
+	ARecords    []struct{ IPv4Address string } `json:"aRecords"`
+	AAAARecords []struct{ IPv6Address string } `json:"aaaaRecords"`
+	CNAMERecord *struct{ CNAME string }        `json:"cnameRecord"`
+}
+
+// parseAzureDNS reads `az network dns record-set list` output. Records may
+// also be nested under "properties" as the REST API returns them. The
+// subscription is taken from each record's resource ID.
+func parseAzureDNS(data []byte) (Inventory, error) {
+	inv := Inventory{Format: FormatAzureDNS}
+	var sets []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+		azureRecordProps
+		Properties *azureRecordProps `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return inv, err
+	}
+
+	for _, rs := range sets {
+		// /subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Network/dnszones/<zone>/<type>/<name>
+		parts := strings.Split(strings.Trim(rs.ID, "/"), "/")
+		if len(parts) < 10 || !strings.EqualFold(parts[0], "subscriptions") {
+			return inv, fmt.Errorf("record set %s: malformed resource ID %q", rs.Name, rs.ID)
+		}
+		props := rs.azureRecordProps
+		if rs.Properties != nil {
+			props = *rs.Properties
+		}
+		fqdn := props.Fqdn
+		if fqdn == "" {
+			fqdn = parts[7]
+			if rs.Name != "@" {
+				fqdn = rs.Name + "." + fqdn
+			}
+		}
+
+		var values []string
+		for _, a := range props.ARecords {
+			values = append(values, a.IPv4Address)
+		}
+		for _, a := range props.AAAARecords {
+			values = append(values, a.IPv6Address)
+		}
+		if props.CNAMERecord != nil {
+			values = append(values, props.CNAMERecord.CNAME)
+		}
+		inv.addRecord(models.CloudResource{
+			Provider:   models.CloudAzure,
+			Account:    parts[1],
+			Name:       fqdn,
+			RecordType: rs.Type[strings.LastIndex(rs.Type, "/")+1:],
+			ResourceID: rs.ID,
+		}, values)
+	}
+	return inv, nil
+}
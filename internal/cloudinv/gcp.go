@@ -0,0 +1,35 @@
+package cloudinv
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// parseCloudDNS reads `gcloud dns record-sets list --format=json` output or
+// the rrsets of an API response. project is the GCP project owning the zone.
+func parseCloudDNS(data []byte, project string) (Inventory, error) {
+	inv := Inventory{Format: FormatCloudDNS}
+	if project == "" {
+		return inv, fmt.Errorf("cloud DNS exports do not name their project; pass the project ID")
+	}
+	var sets []struct {
+		Name    string   `json:"name"`
+		Type    string   `json:"type"`
+		RRDatas []string `json:"rrdatas"`
+	}
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return inv, err
+	}
+	for _, rs := range sets {
+		inv.addRecord(models.CloudResource{
+			Provider:   models.CloudGCP,
+			Account:    project,
+			Name:       rs.Name,
+			RecordType: rs.Type,
+			ResourceID: hostname(rs.Name) + "/" + rs.Type,
+		}, rs.RRDatas)
+	}
+	return inv, nil
+}
@@ -0,0 +1,154 @@
+// Package cloudinv reads cloud inventory exports produced by the providers'
+// CLIs: AWS Route53 record sets, EC2 instances and load balancers, GCP
+// Cloud DNS record sets and Azure DNS record sets. Nothing here talks to a
+// provider; exports are taken as files.
+package cloudinv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// Export formats.
+const (
+	FormatRoute53  = "aws-route53"
+	FormatEC2      = "aws-ec2"
+	FormatELB      = "aws-elb"
+	FormatCloudDNS = "gcp-clouddns"
+	FormatAzureDNS = "azure-dns"
+)
+
+// Inventory is the content of one export.
+type Inventory struct {
+	Format    string
+	Resources []models.CloudResource
+	// Skipped counts DNS records of types other than A, AAAA and CNAME.
+	Skipped int
+}
+
+// Parse detects the format of an export and reads its resources. account
+// names the AWS account or GCP project for DNS exports, which do not carry
+// it; the other formats name their own account.
+func Parse(r io.Reader, account string) (Inventory, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Inventory{}, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Inventory{}, fmt.Errorf("export is empty")
+	}
+
+	if data[0] == '[' {
+		var probe []struct {
+			Kind string `json:"kind"`
+			ID   string `json:"id"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return Inventory{}, err
+		}
+		if len(probe) == 0 {
+			return Inventory{}, fmt.Errorf("export is empty")
+		}
+		switch {
+		case probe[0].Kind == "dns#resourceRecordSet":
+			return parseCloudDNS(data, account)
+		case strings.Contains(strings.ToLower(probe[0].ID), "/providers/microsoft.network/dnszones/"):
+			return parseAzureDNS(data)
+		}
+		return Inventory{}, fmt.Errorf("unrecognized export: expected GCP Cloud DNS or Azure DNS record sets")
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return Inventory{}, err
+	}
+	switch {
+	case keys["ResourceRecordSets"] != nil:
+		return parseRoute53(data, account)
+	case keys["Reservations"] != nil:
+		return parseEC2(data)
+	case keys["LoadBalancers"] != nil, keys["LoadBalancerDescriptions"] != nil:
+		return parseELB(data, account)
+	case keys["rrsets"] != nil:
+		var resp struct {
+			RRSets json.RawMessage `json:"rrsets"`
+		}
+		json.Unmarshal(data, &resp)
+		return parseCloudDNS(resp.RRSets, account)
+	}
+	return Inventory{}, fmt.Errorf("unrecognized export: expected Route53, EC2 or ELB output")
+}
+
+// hostname normalizes a DNS name: lower case, no trailing dot, and the
+// octal escape Route53 uses for wildcards undone.
+func hostname(name string) string {
+	name = strings.ReplaceAll(strings.TrimSpace(name), `\052`, "*")
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// recordTarget validates and normalizes one value of an A, AAAA or CNAME
+// record.
+func recordTarget(rrtype, value string) (string, bool) {
+	if rrtype == "CNAME" {
+		value = hostname(value)
+		return value, value != ""
+	}
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil || (rrtype == "A") != (ip.To4() != nil) {
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// addRecord appends a DNS record set to inv, counting unsupported types.
+func (inv *Inventory) addRecord(res models.CloudResource, values []string) {
+	res.RecordType = strings.ToUpper(res.RecordType)
+	if res.RecordType != "A" && res.RecordType != "AAAA" && res.RecordType != "CNAME" {
+		inv.Skipped++
+		return
+	}
+	res.Type = models.CloudDNSRecord
+	res.Name = hostname(res.Name)
+	res.Hostnames = []string{res.Name}
+	for _, v := range values {
+		if v, ok := recordTarget(res.RecordType, v); ok {
+			res.Targets = append(res.Targets, v)
+		}
+	}
+	inv.Resources = append(inv.Resources, res)
+}
+
+// addresses keeps the valid, distinct IPs of a list.
+func addresses(values ...string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		ip := net.ParseIP(strings.TrimSpace(v))
+		if ip == nil || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+// hostnames keeps the non-empty, distinct names of a list.
+func hostnames(values ...string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		if v = hostname(v); v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,308 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultResolvers are used when neither DNS_RESOLVERS nor /etc/resolv.conf
+// name a resolver.
+var DefaultResolvers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+
+// maxChain bounds how many CNAME hops are followed.
+const maxChain = 8
+
+// Client sends queries to a list of recursive resolvers, trying the next one
+// when a resolver fails, times out or answers SERVFAIL/REFUSED.
+type Client struct {
+	Servers  []string // host:port
+	Timeout  time.Duration
+	Attempts int // per resolver
+}
+
+func NewClient(servers []string) *Client {
+	return &Client{Servers: servers, Timeout: 3 * time.Second, Attempts: 2}
+}
+
+// ServersFromEnv returns the resolvers in DNS_RESOLVERS (comma separated,
+// port 53 if omitted), else the nameservers in /etc/resolv.conf, else
+// DefaultResolvers.
+func ServersFromEnv() []string {
+	var servers []string
+	for _, s := range strings.Split(os.Getenv("DNS_RESOLVERS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, withPort(s))
+		}
+	}
+	if len(servers) > 0 {
+		return servers
+	}
+
+	if f, err := os.Open("/etc/resolv.conf"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, withPort(fields[1]))
+			}
+		}
+	}
+	if len(servers) > 0 {
+		return servers
+	}
+	return DefaultResolvers
+}
+
+func withPort(s string) string {
+	if _, _, err := net.SplitHostPort(s); err == nil {
+		return s
+	}
+	return net.JoinHostPort(strings.Trim(s, "[]"), "53")
+}
+
+// Exchange sends one query and returns the response with the resolver that
+// produced it.
+func (c *Client) Exchange(ctx context.Context, name string, qtype uint16) (*Msg, string, error) {
+	if len(c.Servers) == 0 {
+		return nil, "", errors.New("dns: no resolvers configured")
+	}
+	attempts := c.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for _, server := range c.Servers {
+		for i := 0; i < attempts; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, "", err
+			}
+			resp, err := c.exchange(ctx, server, name, qtype)
+			if err == nil && (resp.Rcode == RcodeServFail || resp.Rcode == RcodeRefused) {
+				err = fmt.Errorf("dns: %s answered %s for %s", server, RcodeName(resp.Rcode), name)
+			}
+			if err == nil {
+				return resp, server, nil
+			}
+			lastErr = err
+		}
+	}
+	return nil, "", lastErr
+}
+
+func (c *Client) exchange(ctx context.Context, server, name string, qtype uint16) (*Msg, error) {
+	query := &Msg{ID: uint16(rand.UintN(1 << 16)), Recursion: true, Question: []Question{{Name: name, Type: qtype}}}
+	wire, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.roundTrip(ctx, "udp", server, wire)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		if resp, err = c.roundTrip(ctx, "tcp", server, wire); err != nil {
+			return nil, err
+		}
+	}
+	if resp.ID != query.ID || !resp.Response {
+		return nil, fmt.Errorf("dns: mismatched response from %s", server)
+	}
+	return resp, nil
+}
+
+func (c *Client) roundTrip(ctx context.Context, network, server string, wire []byte) (*Msg, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(wire)))); err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(wire); err != nil {
+			return nil, err
+		}
+		var l [2]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(l[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+		return Unpack(buf)
+	}
+
+	if _, err := conn.Write(wire); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return Unpack(buf[:n])
+}
+
+// Result is everything known about one name after resolution.
+type Result struct {
+	Name     string
+	Resolver string
+	Rcode    int      // of the address lookup
+	Records  []RR     // records owned by Name; A/AAAA are the final addresses
+	Chain    []string // CNAME targets in order
+	Takeover *Takeover
+}
+
+// Addresses returns the A and AAAA values of the result.
+func (r *Result) Addresses() []net.IP {
+	var ips []net.IP
+	for _, rr := range r.Records {
+		if rr.Type == TypeA || rr.Type == TypeAAAA {
+			if ip := net.ParseIP(rr.Data); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// Dangling reports whether the name is a CNAME whose chain ends in NXDOMAIN.
+func (r *Result) Dangling() bool {
+	return len(r.Chain) > 0 && r.Rcode == RcodeNXDomain
+}
+
+// Resolve looks up the A, AAAA, CNAME, MX, NS and TXT records of a name,
+// follows its CNAME chain and checks the chain for takeover signals.
+func (c *Client) Resolve(ctx context.Context, name string) (*Result, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	res := &Result{Name: name}
+
+	type answer struct {
+		qtype  uint16
+		chain  []string
+		final  []RR
+		rcode  int
+		server string
+		err    error
+	}
+	qtypes := []uint16{TypeA, TypeAAAA, TypeMX, TypeNS, TypeTXT}
+	answers := make([]answer, len(qtypes))
+
+	var wg sync.WaitGroup
+	for i, qt := range qtypes {
+		wg.Add(1)
+		go func(i int, qt uint16) {
+			defer wg.Done()
+			a := answer{qtype: qt}
+			a.chain, a.final, a.rcode, a.server, a.err = c.follow(ctx, name, qt)
+			answers[i] = a
+		}(i, qt)
+	}
+	wg.Wait()
+
+	addr := answers[0]
+	if addr.err != nil {
+		return nil, addr.err
+	}
+	res.Resolver = addr.server
+	res.Rcode = addr.rcode
+	res.Chain = addr.chain
+	if len(res.Chain) > 0 {
+		res.Records = append(res.Records, RR{Name: name, Type: TypeCNAME, Data: res.Chain[0]})
+	}
+
+	for _, a := range answers {
+		if a.err != nil {
+			continue
+		}
+		// MX, NS and TXT belong to the name only when it is not an alias.
+		if a.qtype != TypeA && a.qtype != TypeAAAA && len(a.chain) > 0 {
+			continue
+		}
+		for _, rr := range a.final {
+			rr.Name = name
+			res.Records = append(res.Records, rr)
+		}
+	}
+
+	res.Takeover = DetectTakeover(res)
+	return res, nil
+}
+
+// follow queries name for qtype and chases CNAMEs until an answer of that type
+// or a negative response. It returns the CNAME targets, the final records,
+// the rcode of the last lookup and the resolver that answered first.
+func (c *Client) follow(ctx context.Context, name string, qtype uint16) ([]string, []RR, int, string, error) {
+	var chain []string
+	var server string
+	cur := name
+
+	for hop := 0; hop <= maxChain; hop++ {
+		asked := cur
+		resp, srv, err := c.Exchange(ctx, asked, qtype)
+		if err != nil {
+			return chain, nil, 0, server, err
+		}
+		if server == "" {
+			server = srv
+		}
+
+		// Walk the CNAMEs the resolver already included.
+		for len(chain) < maxChain {
+			target := cnameFor(resp, cur)
+			if target == "" {
+				break
+			}
+			chain = append(chain, target)
+			cur = target
+		}
+
+		var final []RR
+		for _, rr := range resp.Answer {
+			if rr.Type == qtype && rr.Name == cur {
+				final = append(final, rr)
+			}
+		}
+		if len(final) > 0 || resp.Rcode != RcodeSuccess || cur == asked {
+			return chain, final, resp.Rcode, server, nil
+		}
+		// The resolver stopped part way along the chain; ask for its tail.
+	}
+	return chain, nil, RcodeSuccess, server, nil
+}
+
+func cnameFor(m *Msg, name string) string {
+	for _, rr := range m.Answer {
+		if rr.Type == TypeCNAME && rr.Name == name {
+			return rr.Data
+		}
+	}
+	return ""
+}
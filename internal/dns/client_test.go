@@ -0,0 +1,126 @@
+package dns_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/dns"
+	"github.com/Abhaythakor/SigMap/internal/dns/dnstest"
+)
+
+func newServer(t *testing.T, records ...dns.RR) (*dnstest.Server, *dns.Client) {
+	t.Helper()
+	srv, err := dnstest.NewServer(records...)
+	if err != nil {
+		t.Fatalf("start server: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	c := dns.NewClient([]string{srv.Addr})
+	c.Timeout = time.Second
+	return srv, c
+}
+
+func TestResolveFollowsCNAMEChain(t *testing.T) {
+	_, c := newServer(t,
+		dns.RR{Name: "www.example.com", Type: dns.TypeCNAME, Data: "edge.example.net"},
+		dns.RR{Name: "edge.example.net", Type: dns.TypeCNAME, Data: "lb.example.org"},
+		dns.RR{Name: "lb.example.org", Type: dns.TypeA, Data: "192.0.2.10"},
+		dns.RR{Name: "lb.example.org", Type: dns.TypeA, Data: "192.0.2.11"},
+	)
+
+	res, err := c.Resolve(context.Background(), "WWW.Example.com.")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if want := []string{"edge.example.net", "lb.example.org"}; !equal(res.Chain, want) {
+		t.Errorf("Chain = %v, want %v", res.Chain, want)
+	}
+	var ips []string
+	for _, ip := range res.Addresses() {
+		ips = append(ips, ip.String())
+	}
+	if want := []string{"192.0.2.10", "192.0.2.11"}; !equal(ips, want) {
+		t.Errorf("Addresses = %v, want %v", ips, want)
+	}
+	if res.Dangling() || res.Takeover != nil {
+		t.Errorf("resolving chain reported dangling: %+v", res.Takeover)
+	}
+}
+
+func TestResolveDanglingCNAME(t *testing.T) {
+	_, c := newServer(t,
+		dns.RR{Name: "shop.example.com", Type: dns.TypeCNAME, Data: "old-shop.herokuapp.com"},
+	)
+
+	res, err := c.Resolve(context.Background(), "shop.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Rcode != dns.RcodeNXDomain || !res.Dangling() {
+		t.Fatalf("Rcode = %s, Dangling = %v; want NXDOMAIN and dangling", dns.RcodeName(res.Rcode), res.Dangling())
+	}
+	if !res.Takeover.Vulnerable() || res.Takeover.Service != "Heroku" || res.Takeover.Target != "old-shop.herokuapp.com" {
+		t.Errorf("Takeover = %+v, want Heroku on old-shop.herokuapp.com", res.Takeover)
+	}
+}
+
+func TestResolveNXDomain(t *testing.T) {
+	_, c := newServer(t)
+
+	res, err := c.Resolve(context.Background(), "missing.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Rcode != dns.RcodeNXDomain || len(res.Records) != 0 || res.Dangling() {
+		t.Errorf("got rcode %s, %d records, dangling %v; want NXDOMAIN without records, not dangling",
+			dns.RcodeName(res.Rcode), len(res.Records), res.Dangling())
+	}
+}
+
+func TestExchangeRetriesTruncatedOverTCP(t *testing.T) {
+	srv, c := newServer(t, dns.RR{Name: "big.example.com", Type: dns.TypeTXT, Data: "v=spf1 -all"})
+	srv.SetTruncate(true)
+
+	resp, _, err := c.Exchange(context.Background(), "big.example.com", dns.TypeTXT)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if resp.Truncated || len(resp.Answer) != 1 || resp.Answer[0].Data != "v=spf1 -all" {
+		t.Errorf("answer = %+v (truncated %v), want the TXT record over TCP", resp.Answer, resp.Truncated)
+	}
+	if n := srv.Queries(); n != 2 {
+		t.Errorf("server answered %d queries, want 2 (UDP then TCP)", n)
+	}
+}
+
+func TestExchangeFailsOverOnServFail(t *testing.T) {
+	bad, _ := newServer(t)
+	bad.SetRcode("www.example.com", dns.RcodeServFail)
+	good, _ := newServer(t, dns.RR{Name: "www.example.com", Type: dns.TypeA, Data: "192.0.2.1"})
+
+	c := dns.NewClient([]string{bad.Addr, good.Addr})
+	c.Timeout = time.Second
+	resp, server, err := c.Exchange(context.Background(), "www.example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if server != good.Addr || len(resp.Answer) != 1 {
+		t.Errorf("answered by %s with %d records, want %s with 1", server, len(resp.Answer), good.Addr)
+	}
+	if n := bad.Queries(); n != c.Attempts {
+		t.Errorf("failing resolver asked %d times, want %d", n, c.Attempts)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+// Package dnstest provides an in-process recursive resolver for exercising
+// the dns client against a fixed zone, in the spirit of net/http/httptest.
+package dnstest
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Abhaythakor/SigMap/internal/dns"
+)
+
+// Server answers queries from Records over UDP and TCP on a loopback port.
+// CNAMEs are chased like a recursive resolver would, and names without any
+// record answer NXDOMAIN.
+type Server struct {
+	Addr string // host:port, set by Start
+
+	mu       sync.RWMutex
+	records  []dns.RR
+	rcodes   map[string]int
+	truncate bool
+	queries  atomic.Int64
+
+	udp net.PacketConn
+	tcp net.Listener
+	wg  sync.WaitGroup
+}
+
+// NewServer starts a server for the given records.
+func NewServer(records ...dns.RR) (*Server, error) {
+	s := &Server{rcodes: map[string]int{}}
+	s.SetRecords(records...)
+	if err := s.start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetRecords replaces the zone.
+func (s *Server) SetRecords(records ...dns.RR) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = make([]dns.RR, len(records))
+	for i, rr := range records {
+		rr.Name = strings.ToLower(strings.TrimSuffix(rr.Name, "."))
+		rr.Data = strings.TrimSuffix(rr.Data, ".")
+		if rr.TTL == 0 {
+			rr.TTL = 300
+		}
+		s.records[i] = rr
+	}
+}
+
+// SetRcode makes queries for name fail with rcode, e.g. dns.RcodeServFail.
+func (s *Server) SetRcode(name string, rcode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcodes[strings.ToLower(name)] = rcode
+}
+
+// SetTruncate makes every UDP answer truncated so clients retry over TCP.
+func (s *Server) SetTruncate(truncate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.truncate = truncate
+}
+
+// Queries returns the number of queries answered.
+func (s *Server) Queries() int {
+	return int(s.queries.Load())
+}
+
+func (s *Server) start() error {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		return err
+	}
+	s.udp, s.tcp, s.Addr = udp, tcp, udp.LocalAddr().String()
+
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+	return nil
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.mu.RLock()
+		truncate := s.truncate
+		s.mu.RUnlock()
+		if resp := s.handle(buf[:n], truncate); resp != nil {
+			s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			var l [2]byte
+			if _, err := io.ReadFull(conn, l[:]); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(l[:]))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			if resp := s.handle(buf, false); resp != nil {
+				conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(resp))))
+				conn.Write(resp)
+			}
+		}(conn)
+	}
+}
+
+func (s *Server) handle(wire []byte, truncate bool) []byte {
+	q, err := dns.Unpack(wire)
+	if err != nil || len(q.Question) != 1 {
+		return nil
+	}
+	s.queries.Add(1)
+
+	resp := &dns.Msg{ID: q.ID, Response: true, Recursion: true, Question: q.Question}
+	if truncate {
+		resp.Truncated = true
+	} else {
+		resp.Answer, resp.Rcode = s.answer(q.Question[0].Name, q.Question[0].Type)
+	}
+	out, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func (s *Server) answer(name string, qtype uint16) ([]dns.RR, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if rcode, ok := s.rcodes[name]; ok {
+		return nil, rcode
+	}
+
+	var answer []dns.RR
+	for hops := 0; hops < 16; hops++ {
+		var owned, cname []dns.RR
+		for _, rr := range s.records {
+			if rr.Name != name {
+				continue
+			}
+			owned = append(owned, rr)
+			if rr.Type == dns.TypeCNAME {
+				cname = append(cname, rr)
+			}
+		}
+		if len(owned) == 0 {
+			return answer, dns.RcodeNXDomain
+		}
+		if len(cname) > 0 && qtype != dns.TypeCNAME {
+			answer = append(answer, cname[0])
+			name = cname[0].Data
+			continue
+		}
+		for _, rr := range owned {
+			if rr.Type == qtype {
+				answer = append(answer, rr)
+			}
+		}
+		return answer, dns.RcodeSuccess
+	}
+	return answer, dns.RcodeServFail
+}
@@ -0,0 +1,353 @@
+// Package dns is a small DNS client for enrichment: it sends queries to a
+// configurable list of resolvers and decodes the record types SigMap stores.
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Record types.
+const (
+	TypeA     uint16 = 1
+	TypeNS    uint16 = 2
+	TypeCNAME uint16 = 5
+	TypeSOA   uint16 = 6
+	TypeMX    uint16 = 15
+	TypeTXT   uint16 = 16
+	TypeAAAA  uint16 = 28
+)
+
+// Response codes.
+const (
+	RcodeSuccess  = 0
+	RcodeServFail = 2
+	RcodeNXDomain = 3
+	RcodeRefused  = 5
+)
+
+var typeNames = map[uint16]string{
+	TypeA: "A", TypeNS: "NS", TypeCNAME: "CNAME", TypeSOA: "SOA",
+	TypeMX: "MX", TypeTXT: "TXT", TypeAAAA: "AAAA",
+}
+
+// TypeName returns the mnemonic of a record type, e.g. "AAAA".
+func TypeName(t uint16) string {
+	if n, ok := typeNames[t]; ok {
+		return n
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// RcodeName returns the mnemonic of a response code.
+func RcodeName(rcode int) string {
+	switch rcode {
+	case RcodeSuccess:
+		return "NOERROR"
+	case 1:
+		return "FORMERR"
+	case RcodeServFail:
+		return "SERVFAIL"
+	case RcodeNXDomain:
+		return "NXDOMAIN"
+	case 4:
+		return "NOTIMP"
+	case RcodeRefused:
+		return "REFUSED"
+	}
+	return "RCODE" + strconv.Itoa(rcode)
+}
+
+// RR is a resource record. Data holds the presentation form of the rdata:
+// an address, a target name, "<preference> <host>" for MX, or the joined
+// character strings of a TXT record.
+type RR struct {
+	Name string
+	Type uint16
+	TTL  uint32
+	Data string
+}
+
+// Msg is a DNS query or response.
+type Msg struct {
+	ID        uint16
+	Response  bool
+	Truncated bool
+	Recursion bool // RD on queries, RA on responses
+	Rcode     int
+	Question  []Question
+	Answer    []RR
+	Authority []RR
+}
+
+// Question is an entry of the question section.
+type Question struct {
+	Name string
+	Type uint16
+}
+
+var errMalformed = errors.New("dns: malformed message")
+
+// Pack encodes the message in wire format without name compression.
+func (m *Msg) Pack() ([]byte, error) {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	var flags uint16
+	if m.Response {
+		flags |= 1 << 15
+	}
+	if m.Truncated {
+		flags |= 1 << 9
+	}
+	if m.Recursion {
+		flags |= 1 << 8
+		if m.Response {
+			flags |= 1 << 7
+		}
+	}
+	flags |= uint16(m.Rcode & 0xF)
+	binary.BigEndian.PutUint16(b[2:], flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Question)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answer)))
+	binary.BigEndian.PutUint16(b[8:], uint16(len(m.Authority)))
+
+	var err error
+	for _, q := range m.Question {
+		if b, err = packName(b, q.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, 1) // IN
+	}
+	for _, rr := range append(append([]RR{}, m.Answer...), m.Authority...) {
+		if b, err = packRR(b, rr); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func packName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, fmt.Errorf("dns: invalid name %q", name)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+func packRR(b []byte, rr RR) ([]byte, error) {
+	var err error
+	if b, err = packName(b, rr.Name); err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, rr.Type)
+	b = binary.BigEndian.AppendUint16(b, 1)
+	b = binary.BigEndian.AppendUint32(b, rr.TTL)
+
+	var rdata []byte
+	switch rr.Type {
+	case TypeA:
+		ip := net.ParseIP(rr.Data).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("dns: invalid A data %q", rr.Data)
+		}
+		rdata = ip
+	case TypeAAAA:
+		ip := net.ParseIP(rr.Data)
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("dns: invalid AAAA data %q", rr.Data)
+		}
+		rdata = ip.To16()
+	case TypeCNAME, TypeNS:
+		if rdata, err = packName(nil, rr.Data); err != nil {
+			return nil, err
+		}
+	case TypeMX:
+		pref, host, ok := strings.Cut(rr.Data, " ")
+		p, perr := strconv.Atoi(pref)
+		if !ok || perr != nil {
+			return nil, fmt.Errorf("dns: invalid MX data %q", rr.Data)
+		}
+		rdata = binary.BigEndian.AppendUint16(nil, uint16(p))
+		if rdata, err = packName(rdata, host); err != nil {
+			return nil, err
+		}
+	case TypeTXT:
+		for s := rr.Data; ; {
+			chunk := s
+			if len(chunk) > 255 {
+				chunk = s[:255]
+			}
+			rdata = append(rdata, byte(len(chunk)))
+			rdata = append(rdata, chunk...)
+			s = s[len(chunk):]
+			if s == "" {
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("dns: cannot pack %s records", TypeName(rr.Type))
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(rdata)))
+	return append(b, rdata...), nil
+}
+
+// Unpack decodes a wire-format message. Records of types it does not know
+// are skipped; additional records are ignored.
+func Unpack(b []byte) (*Msg, error) {
+	if len(b) < 12 {
+		return nil, errMalformed
+	}
+	flags := binary.BigEndian.Uint16(b[2:])
+	m := &Msg{
+		ID:        binary.BigEndian.Uint16(b[0:]),
+		Response:  flags&(1<<15) != 0,
+		Truncated: flags&(1<<9) != 0,
+		Recursion: flags&(1<<8) != 0,
+		Rcode:     int(flags & 0xF),
+	}
+	qd := int(binary.BigEndian.Uint16(b[4:]))
+	an := int(binary.BigEndian.Uint16(b[6:]))
+	ns := int(binary.BigEndian.Uint16(b[8:]))
+
+	off := 12
+	for i := 0; i < qd; i++ {
+		name, n, err := unpackName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+4 > len(b) {
+			return nil, errMalformed
+		}
+		m.Question = append(m.Question, Question{Name: name, Type: binary.BigEndian.Uint16(b[off:])})
+		off += 4
+	}
+	for i := 0; i < an+ns; i++ {
+		rr, n, ok, err := unpackRR(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if !ok {
+			continue
+		}
+		if i < an {
+			m.Answer = append(m.Answer, rr)
+		} else {
+			m.Authority = append(m.Authority, rr)
+		}
+	}
+	return m, nil
+}
+
+func unpackRR(b []byte, off int) (RR, int, bool, error) {
+	var rr RR
+	name, off, err := unpackName(b, off)
+	if err != nil {
+		return rr, 0, false, err
+	}
+	if off+10 > len(b) {
+		return rr, 0, false, errMalformed
+	}
+	rr.Name = name
+	rr.Type = binary.BigEndian.Uint16(b[off:])
+	rr.TTL = binary.BigEndian.Uint32(b[off+4:])
+	rdlen := int(binary.BigEndian.Uint16(b[off+8:]))
+	off += 10
+	end := off + rdlen
+	if end > len(b) {
+		return rr, 0, false, errMalformed
+	}
+	rdata := b[off:end]
+
+	switch rr.Type {
+	case TypeA:
+		if rdlen != 4 {
+			return rr, 0, false, errMalformed
+		}
+		rr.Data = net.IP(rdata).String()
+	case TypeAAAA:
+		if rdlen != 16 {
+			return rr, 0, false, errMalformed
+		}
+		rr.Data = net.IP(rdata).String()
+	case TypeCNAME, TypeNS:
+		if rr.Data, _, err = unpackName(b, off); err != nil {
+			return rr, 0, false, err
+		}
+	case TypeMX:
+		if rdlen < 3 {
+			return rr, 0, false, errMalformed
+		}
+		host, _, err := unpackName(b, off+2)
+		if err != nil {
+			return rr, 0, false, err
+		}
+		rr.Data = strconv.Itoa(int(binary.BigEndian.Uint16(rdata))) + " " + host
+	case TypeTXT:
+		var sb strings.Builder
+		for i := 0; i < len(rdata); {
+			l := int(rdata[i])
+			if i+1+l > len(rdata) {
+				return rr, 0, false, errMalformed
+			}
+			sb.Write(rdata[i+1 : i+1+l])
+			i += 1 + l
+		}
+		rr.Data = sb.String()
+	case TypeSOA:
+		if rr.Data, _, err = unpackName(b, off); err != nil {
+			return rr, 0, false, err
+		}
+	default:
+		return rr, end, false, nil
+	}
+	return rr, end, true, nil
+}
+
+// unpackName reads a possibly compressed name at off and returns it with the
+// offset just past it.
+func unpackName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for hops := 0; ; hops++ {
+		if off >= len(b) || hops > 127 {
+			return "", 0, errMalformed
+		}
+		l := int(b[off])
+		switch {
+		case l == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")), next, nil
+		case l&0xC0 == 0xC0:
+			if off+1 >= len(b) {
+				return "", 0, errMalformed
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+		case l&0xC0 != 0:
+			return "", 0, errMalformed
+		default:
+			if off+1+l > len(b) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(b[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package dns
+
+import "strings"
+
+// Takeover is a dangling CNAME: the name aliases a target that no longer
+// exists. When the target belongs to a hosting service that lets anyone
+// claim the name, the subdomain can be taken over.
+type Takeover struct {
+	Target  string // the CNAME hop that matched, or the last hop
+	Service string // empty when the target is not a known service
+}
+
+// Vulnerable reports whether the dangling target belongs to a known service.
+func (t *Takeover) Vulnerable() bool {
+	return t != nil && t.Service != ""
+}
+
+// TakeoverService describes a hosting service whose deprovisioned resources
+// leave NXDOMAIN behind and can be re-registered by anyone.
+type TakeoverService struct {
+	Name     string
+	Suffixes []string
+}
+
+// TakeoverServices are checked in order against every hop of a dangling chain.
+var TakeoverServices = []TakeoverService{
+	{Name: "AWS S3", Suffixes: []string{".s3.amazonaws.com", ".s3-website.amazonaws.com"}},
+	{Name: "AWS Elastic Beanstalk", Suffixes: []string{".elasticbeanstalk.com"}},
+	{Name: "AWS CloudFront", Suffixes: []string{".cloudfront.net"}},
+	{Name: "Azure App Service", Suffixes: []string{".azurewebsites.net"}},
+	{Name: "Azure Cloud Services", Suffixes: []string{".cloudapp.net", ".cloudapp.azure.com"}},
+	{Name: "Azure Traffic Manager", Suffixes: []string{".trafficmanager.net"}},
+	{Name: "Azure Blob Storage", Suffixes: []string{".blob.core.windows.net"}},
+	{Name: "Azure Static Web Apps", Suffixes: []string{".azurestaticapps.net"}},
+	{Name: "Azure CDN", Suffixes: []string{".azureedge.net"}},
+	{Name: "Heroku", Suffixes: []string{".herokuapp.com", ".herokudns.com"}},
+	{Name: "GitHub Pages", Suffixes: []string{".github.io"}},
+	{Name: "Netlify", Suffixes: []string{".netlify.app", ".netlify.com"}},
+	{Name: "Vercel", Suffixes: []string{".vercel.app", ".now.sh"}},
+	{Name: "Fastly", Suffixes: []string{".fastly.net"}},
+	{Name: "Google Cloud Storage", Suffixes: []string{".storage.googleapis.com"}},
+	{Name: "Google App Engine", Suffixes: []string{".appspot.com"}},
+	{Name: "Firebase", Suffixes: []string{".firebaseapp.com", ".web.app"}},
+	{Name: "Shopify", Suffixes: []string{".myshopify.com"}},
+	{Name: "Pantheon", Suffixes: []string{".pantheonsite.io"}},
+	{Name: "Ghost", Suffixes: []string{".ghost.io"}},
+	{Name: "Surge", Suffixes: []string{".surge.sh"}},
+	{Name: "Bitbucket", Suffixes: []string{".bitbucket.io"}},
+	{Name: "Zendesk", Suffixes: []string{".zendesk.com"}},
+	{Name: "ReadMe", Suffixes: []string{".readme.io"}},
+	{Name: "WP Engine", Suffixes: []string{".wpengine.com"}},
+	{Name: "Fly.io", Suffixes: []string{".fly.dev"}},
+	{Name: "Render", Suffixes: []string{".onrender.com"}},
+}
+
+// DetectTakeover returns a signal for a name whose CNAME chain ends in
+// NXDOMAIN, naming the service when a hop matches TakeoverServices.
+func DetectTakeover(r *Result) *Takeover {
+	if !r.Dangling() {
+		return nil
+	}
+	for _, hop := range r.Chain {
+		for _, svc := range TakeoverServices {
+			for _, suffix := range svc.Suffixes {
+				if strings.HasSuffix(hop, suffix) {
+					return &Takeover{Target: hop, Service: svc.Name}
+				}
+			}
+		}
+	}
+	return &Takeover{Target: r.Chain[len(r.Chain)-1]}
+}
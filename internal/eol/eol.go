@@ -0,0 +1,213 @@
+// Package eol reads release cycles in the endoflife.date format and judges
+// detected versions against them: still supported, past end of life, or
+// supported but releases behind.
+package eol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/version"
+)
+
+// Status is the verdict on a detected version.
+type Status string
+
+const (
+	Supported Status = "supported"
+	Outdated  Status = "outdated"
+	EndOfLife Status = "eol"
+)
+
+// Statuses in increasing severity, as the search fields rank them.
+var Statuses = []string{string(Supported), string(Outdated), string(EndOfLife)}
+
+// A supported version is Outdated once this many newer major, or minor,
+// release cycles exist.
+const (
+	OutdatedMajors = 1
+	OutdatedMinors = 2
+)
+
+// Cycle is one release cycle of a product, e.g. nginx 1.26.
+type Cycle struct {
+	Cycle       string     `json:"cycle"`
+	ReleaseDate *time.Time `json:"release_date,omitempty"`
+	EOLDate     *time.Time `json:"eol_date,omitempty"`
+	// EOL is set when the cycle is end of life without a date.
+	EOL    bool   `json:"eol"`
+	Latest string `json:"latest,omitempty"`
+	LTS    bool   `json:"lts"`
+}
+
+// Ended reports whether the cycle is past end of life at now.
+func (c Cycle) Ended(now time.Time) bool {
+	return c.EOL || c.EOLDate != nil && !now.Before(*c.EOLDate)
+}
+
+// rawCycle is a cycle as endoflife.date writes it: eol and lts are a date
+// or a boolean, cycle and latest are usually strings but not always.
+type rawCycle struct {
+	Cycle       interface{} `json:"cycle"`
+	ReleaseDate string      `json:"releaseDate"`
+	EOL         interface{} `json:"eol"`
+	Latest      interface{} `json:"latest"`
+	LTS         interface{} `json:"lts"`
+}
+
+// Parse reads one product: the array endoflife.date serves at
+// /api/{product}.json.
+func Parse(data []byte) ([]Cycle, error) {
+	var raw []rawCycle
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("not an endoflife.date product: %v", err)
+	}
+	cycles := make([]Cycle, 0, len(raw))
+	for i, r := range raw {
+		c := Cycle{Cycle: scalar(r.Cycle), Latest: scalar(r.Latest)}
+		if c.Cycle == "" {
+			return nil, fmt.Errorf("cycle %d has no name", i+1)
+		}
+		if t, err := time.Parse("2006-01-02", r.ReleaseDate); err == nil {
+			c.ReleaseDate = &t
+		}
+		c.EOLDate, c.EOL = dateOrBool(r.EOL)
+		lts, isLTS := dateOrBool(r.LTS)
+		c.LTS = isLTS || lts != nil
+		cycles = append(cycles, c)
+	}
+	return cycles, nil
+}
+
+// ParseBundle reads several products from one document, an object of
+// product name to cycles, for offline imports.
+func ParseBundle(data []byte) (map[string][]Cycle, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("not an endoflife.date bundle: %v", err)
+	}
+	out := make(map[string][]Cycle, len(raw))
+	for product, doc := range raw {
+		cycles, err := Parse(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", product, err)
+		}
+		out[product] = cycles
+	}
+	return out, nil
+}
+
+func scalar(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func dateOrBool(v interface{}) (*time.Time, bool) {
+	switch v := v.(type) {
+	case bool:
+		return nil, v
+	case string:
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			return &t, false
+		}
+	}
+	return nil, false
+}
+
+// Result is the verdict on one version.
+type Result struct {
+	Status Status
+	// Cycle is the release cycle the version belongs to; empty when it is
+	// older than every known cycle.
+	Cycle        string
+	EOLDate      *time.Time
+	Latest       string
+	MajorsBehind int
+	MinorsBehind int
+}
+
+// Check judges a version against a product's cycles. ok is false when the
+// version fits no cycle and is not older than all of them, which happens
+// with cycles newer than the data.
+func Check(cycles []Cycle, v version.Version, now time.Time) (res Result, ok bool) {
+	type parsed struct {
+		Cycle
+		v version.Version
+	}
+	var all []parsed
+	for _, c := range cycles {
+		cv, err := version.Parse(c.Cycle)
+		if err != nil {
+			continue
+		}
+		all = append(all, parsed{c, cv})
+	}
+	if len(all) == 0 {
+		return res, false
+	}
+
+	// The most specific cycle the version falls in: 1.2.3 is in 1.2
+	// rather than 1.
+	var match *parsed
+	oldest := all[0].v
+	for i := range all {
+		c := &all[i]
+		if version.Compare(c.v, oldest) < 0 {
+			oldest = c.v
+		}
+		if within(v, c.v) && (match == nil || len(c.v.Numbers) > len(match.v.Numbers)) {
+			match = c
+		}
+	}
+	if match == nil {
+		if version.Compare(v, oldest) < 0 {
+			return Result{Status: EndOfLife}, true
+		}
+		return res, false
+	}
+
+	res = Result{Cycle: match.Cycle.Cycle, EOLDate: match.EOLDate, Latest: match.Latest}
+	majors := map[int64]bool{}
+	minors := map[int64]bool{}
+	for _, c := range all {
+		switch {
+		case c.v.Epoch != v.Epoch:
+		case c.v.Segment(0) > v.Segment(0):
+			majors[c.v.Segment(0)] = true
+		case c.v.Segment(0) == v.Segment(0) && len(c.v.Numbers) > 1 && c.v.Segment(1) > v.Segment(1):
+			minors[c.v.Segment(1)] = true
+		}
+	}
+	res.MajorsBehind, res.MinorsBehind = len(majors), len(minors)
+
+	switch {
+	case match.Ended(now):
+		res.Status = EndOfLife
+	case res.MajorsBehind >= OutdatedMajors || res.MinorsBehind >= OutdatedMinors:
+		res.Status = Outdated
+	default:
+		res.Status = Supported
+	}
+	return res, true
+}
+
+// within reports whether v starts with the numbers of cycle c.
+func within(v, c version.Version) bool {
+	if v.Epoch != c.Epoch {
+		return false
+	}
+	for i, n := range c.Numbers {
+		if v.Segment(i) != n {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Change is a technology present in both sets whose entry differs.
+type Change struct {
+	Name string `json:"name"`
+	// Fields are the schema keys that differ, e.g. "headers", "website".
+	Fields []string `json:"fields"`
+}
+
+// Diff compares two fingerprint sets. All lists are sorted by name.
+func Diff(old, cur map[string]Fingerprint) (added, removed []string, changed []Change) {
+	for name, fp := range cur {
+		prev, ok := old[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		if fields := changedFields(prev, fp); len(fields) > 0 {
+			changed = append(changed, Change{Name: name, Fields: fields})
+		}
+	}
+	for name := range old {
+		if _, ok := cur[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Name < changed[j].Name })
+	return added, removed, changed
+}
+
+// changedFields compares two entries key by key in their JSON form, which
+// is order-insensitive for maps.
+func changedFields(a, b Fingerprint) []string {
+	am, bm := fields(a), fields(b)
+	var out []string
+	for k, v := range bm {
+		if !bytes.Equal(am[k], v) {
+			out = append(out, k)
+		}
+	}
+	for k := range am {
+		if _, ok := bm[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func fields(f Fingerprint) map[string]json.RawMessage {
+	data, _ := json.Marshal(f)
+	var m map[string]json.RawMessage
+	json.Unmarshal(data, &m)
+	return m
+}
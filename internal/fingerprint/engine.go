@@ -0,0 +1,194 @@
+package fingerprint
+
+import (
+	"sort"
+	"strings"
+)
+
+// Detection is a technology an engine found in a response.
+type Detection struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Confidence int      `json:"confidence"`
+	Categories []int    `json:"categories,omitempty"`
+	Evidence   []string `json:"evidence"`
+}
+
+// keyed is a matcher against a named value: a header, cookie or meta tag.
+type keyed struct {
+	key      string
+	patterns []*pattern
+}
+
+type technology struct {
+	name      string
+	cats      []int
+	headers   []keyed
+	cookies   []keyed
+	meta      []keyed
+	html      []*pattern
+	scripts   []*pattern
+	scriptSrc []*pattern
+	css       []*pattern
+	url       []*pattern
+}
+
+// Engine matches responses against a compiled set of fingerprints. It is
+// immutable once built and safe for concurrent use.
+type Engine struct {
+	techs []*technology
+	// Skipped counts the patterns that could not be compiled.
+	Skipped int
+}
+
+// New compiles fingerprints into an engine. Technologies without HTTP
+// matchers are left out.
+func New(fps map[string]Fingerprint) *Engine {
+	e := &Engine{}
+	for name, fp := range fps {
+		if !fp.HasMatchers() {
+			continue
+		}
+		t := &technology{
+			name:      name,
+			cats:      fp.Cats,
+			headers:   e.compileKeyed(fp.Headers),
+			cookies:   e.compileKeyed(fp.Cookies),
+			meta:      e.compileKeyed(fp.Meta),
+			html:      e.compileList(fp.HTML),
+			scripts:   e.compileList(fp.Scripts),
+			scriptSrc: e.compileList(fp.ScriptSrc),
+			css:       e.compileList(fp.CSS),
+			url:       e.compileList(fp.URL),
+		}
+		if !t.empty() {
+			e.techs = append(e.techs, t)
+		}
+	}
+	sort.Slice(e.techs, func(i, j int) bool { return e.techs[i].name < e.techs[j].name })
+	return e
+}
+
+// Len is the number of technologies the engine can detect.
+func (e *Engine) Len() int {
+	return len(e.techs)
+}
+
+// empty reports whether none of the technology's patterns compiled.
+func (t *technology) empty() bool {
+	return len(t.headers) == 0 && len(t.cookies) == 0 && len(t.meta) == 0 &&
+		len(t.html) == 0 && len(t.scripts) == 0 && len(t.scriptSrc) == 0 &&
+		len(t.css) == 0 && len(t.url) == 0
+}
+
+func (e *Engine) compileList(src Patterns) []*pattern {
+	var out []*pattern
+	for _, s := range src {
+		p, err := compilePattern(s)
+		if err != nil {
+			e.Skipped++
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (e *Engine) compileKeyed(src map[string]Patterns) []keyed {
+	var out []keyed
+	for key, list := range src {
+		if ps := e.compileList(list); len(ps) > 0 {
+			out = append(out, keyed{key: strings.ToLower(key), patterns: ps})
+		}
+	}
+	return out
+}
+
+// Analyze returns the technologies a response matches, sorted by name.
+// Confidence adds up over the matching patterns and is capped at 100; the
+// most specific version any pattern extracted is reported.
+func (e *Engine) Analyze(r *Response) []Detection {
+	pg := parse(r)
+	var out []Detection
+	for _, t := range e.techs {
+		m := &matcher{}
+		for _, k := range t.headers {
+			m.values("header:"+k.key, k.patterns, pg.headers[k.key])
+		}
+		for _, k := range t.cookies {
+			m.values("cookie:"+k.key, k.patterns, pg.cookie(k.key))
+		}
+		for _, k := range t.meta {
+			m.values("meta:"+k.key, k.patterns, pg.meta[k.key])
+		}
+		m.each("html", t.html, []string{pg.html})
+		m.each("scripts", t.scripts, pg.scripts)
+		m.each("scriptSrc", t.scriptSrc, pg.scriptSrc)
+		m.each("css", t.css, pg.css)
+		m.each("url", t.url, []string{pg.url})
+		if len(m.evidence) == 0 {
+			continue
+		}
+		out = append(out, Detection{
+			Name:       t.name,
+			Version:    m.version,
+			Confidence: min(m.confidence, 100),
+			Categories: t.cats,
+			Evidence:   m.evidence,
+		})
+	}
+	return out
+}
+
+// matcher accumulates the matches of one technology.
+type matcher struct {
+	confidence int
+	version    string
+	evidence   []string
+}
+
+// values matches named values, where presence alone can be the signal.
+func (m *matcher) values(where string, patterns []*pattern, values []string) {
+	if values == nil {
+		return
+	}
+	if len(values) == 0 {
+		values = []string{""}
+	}
+	m.each(where, patterns, values)
+}
+
+// each records at most one match per pattern, taking the first value that
+// matches it.
+func (m *matcher) each(where string, patterns []*pattern, values []string) {
+	matched := false
+	for _, p := range patterns {
+		for _, v := range values {
+			version, ok := p.match(v)
+			if !ok {
+				continue
+			}
+			matched = true
+			m.confidence += p.confidence
+			if len(version) > len(m.version) {
+				m.version = version
+			}
+			break
+		}
+	}
+	if matched {
+		m.evidence = append(m.evidence, where)
+	}
+}
+
+// Test runs a single fingerprint against a response, after checking that
+// all of its patterns compile. It returns nil when nothing matched.
+func Test(name string, fp Fingerprint, r *Response) (*Detection, error) {
+	if err := fp.Validate(); err != nil {
+		return nil, err
+	}
+	for _, d := range New(map[string]Fingerprint{name: fp}).Analyze(r) {
+		return &d, nil
+	}
+	return nil, nil
+}
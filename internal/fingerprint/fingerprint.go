@@ -0,0 +1,124 @@
+// Package fingerprint detects web technologies in-process from the matchers
+// of the Wappalyzer fingerprint schema (as distributed by wappalyzergo), so
+// scans need no external binaries. An Engine is compiled from a set of
+// fingerprints and analyzes fetched or saved HTTP responses.
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Patterns is a list of matcher patterns. The schema uses a bare string when
+// there is one pattern and an array otherwise; both decode to a list.
+type Patterns []string
+
+func (p *Patterns) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*p = nil
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*p = Patterns{s}
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var list []string
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		*p = list
+		return nil
+	}
+	return fmt.Errorf("fingerprint: pattern must be a string or array, got %s", data)
+}
+
+// Fingerprint is one technology's entry in fingerprints_data.json. JS and
+// DOM matchers need a browser; they are kept so they survive a round trip
+// through the database but are not evaluated.
+type Fingerprint struct {
+	Cats        []int               `json:"cats,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Website     string              `json:"website,omitempty"`
+	Icon        string              `json:"icon,omitempty"`
+	CPE         string              `json:"cpe,omitempty"`
+	Headers     map[string]Patterns `json:"headers,omitempty"`
+	Cookies     map[string]Patterns `json:"cookies,omitempty"`
+	Meta        map[string]Patterns `json:"meta,omitempty"`
+	HTML        Patterns            `json:"html,omitempty"`
+	Scripts     Patterns            `json:"scripts,omitempty"`
+	ScriptSrc   Patterns            `json:"scriptSrc,omitempty"`
+	CSS         Patterns            `json:"css,omitempty"`
+	URL         Patterns            `json:"url,omitempty"`
+	JS          map[string]Patterns `json:"js,omitempty"`
+	DOM         json.RawMessage     `json:"dom,omitempty"`
+	Implies     Patterns            `json:"implies,omitempty"`
+	Requires    Patterns            `json:"requires,omitempty"`
+	Excludes    Patterns            `json:"excludes,omitempty"`
+}
+
+// HasMatchers reports whether the fingerprint can be detected from an HTTP
+// response at all.
+func (f Fingerprint) HasMatchers() bool {
+	return len(f.Headers) > 0 || len(f.Cookies) > 0 || len(f.Meta) > 0 ||
+		len(f.HTML) > 0 || len(f.Scripts) > 0 || len(f.ScriptSrc) > 0 ||
+		len(f.CSS) > 0 || len(f.URL) > 0
+}
+
+// Validate checks that the fingerprint can be detected and that every
+// pattern compiles, naming the first one that does not. The engine skips
+// such patterns silently, which hides mistakes in hand-written entries.
+func (f Fingerprint) Validate() error {
+	if !f.HasMatchers() {
+		return errors.New("no HTTP matchers: set headers, cookies, meta, html, scripts, scriptSrc, css or url")
+	}
+	lists := map[string]Patterns{"html": f.HTML, "scripts": f.Scripts, "scriptSrc": f.ScriptSrc, "css": f.CSS, "url": f.URL}
+	for kind, keyed := range map[string]map[string]Patterns{"headers": f.Headers, "cookies": f.Cookies, "meta": f.Meta} {
+		for key, ps := range keyed {
+			lists[kind+"."+key] = ps
+		}
+	}
+	where := make([]string, 0, len(lists))
+	for w := range lists {
+		where = append(where, w)
+	}
+	sort.Strings(where)
+	for _, w := range where {
+		for _, p := range lists[w] {
+			if _, err := compilePattern(p); err != nil {
+				return fmt.Errorf("%s: pattern %q: %w", w, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Matchers returns the fingerprint without its descriptive fields: the part
+// stored alongside the technology row.
+func (f Fingerprint) Matchers() Fingerprint {
+	f.Description, f.Website, f.Icon = "", "", ""
+	return f
+}
+
+// Decode reads a fingerprints_data.json document, which wraps the
+// technologies in an "apps" (or, in newer exports, "technologies") object.
+func Decode(data []byte) (map[string]Fingerprint, error) {
+	var wrapper struct {
+		Apps         map[string]Fingerprint `json:"apps"`
+		Technologies map[string]Fingerprint `json:"technologies"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	if wrapper.Apps != nil {
+		return wrapper.Apps, nil
+	}
+	return wrapper.Technologies, nil
+}
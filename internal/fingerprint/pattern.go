@@ -0,0 +1,91 @@
+package fingerprint
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pattern is one compiled matcher. The source form is the Wappalyzer one:
+// a JavaScript regular expression followed by optional tags, e.g.
+// `jquery-([\d.]+)\.js\;version:\1\;confidence:50`.
+type pattern struct {
+	re         *regexp.Regexp // nil matches any value, including empty
+	version    string
+	confidence int
+}
+
+var ternaries [10]*regexp.Regexp
+
+func init() {
+	for i := 1; i < len(ternaries); i++ {
+		ternaries[i] = regexp.MustCompile(`\\` + strconv.Itoa(i) + `\?([^:]+):(.*)$`)
+	}
+}
+
+// compilePattern parses a pattern and its tags. Expressions RE2 cannot
+// compile (lookarounds, backreferences) return an error and are skipped by
+// the engine.
+func compilePattern(src string) (*pattern, error) {
+	parts := strings.Split(src, `\;`)
+	p := &pattern{confidence: 100}
+	for _, tag := range parts[1:] {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			p.version = value
+		case "confidence":
+			if n, err := strconv.Atoi(value); err == nil {
+				p.confidence = n
+			}
+		}
+	}
+	if parts[0] == "" {
+		return p, nil
+	}
+	re, err := regexp.Compile("(?i)" + parts[0])
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+	return p, nil
+}
+
+// match tests a value and returns the version the pattern extracts from it.
+func (p *pattern) match(value string) (string, bool) {
+	if p.re == nil {
+		return "", true
+	}
+	groups := p.re.FindStringSubmatch(value)
+	if groups == nil {
+		return "", false
+	}
+	return p.resolveVersion(groups), true
+}
+
+// resolveVersion substitutes \N references and \N?then:else ternaries in
+// the version tag with the submatches, as Wappalyzer does.
+func (p *pattern) resolveVersion(groups []string) string {
+	v := p.version
+	if v == "" {
+		return ""
+	}
+	for i := 1; i < len(ternaries); i++ {
+		group := ""
+		if i < len(groups) {
+			group = groups[i]
+		}
+		if m := ternaries[i].FindStringSubmatch(v); m != nil {
+			choice := m[2]
+			if group != "" {
+				choice = m[1]
+			}
+			v = strings.Replace(v, m[0], choice, 1)
+		}
+		v = strings.ReplaceAll(v, `\`+strconv.Itoa(i), group)
+	}
+	return strings.TrimSpace(v)
+}
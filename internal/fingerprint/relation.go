@@ -0,0 +1,41 @@
+package fingerprint
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Relation is an entry of implies, requires or excludes: another
+// technology's name, tagged like a pattern ("PHP\;confidence:50").
+type Relation struct {
+	Name       string
+	Confidence int
+}
+
+// Relations returns the fingerprint's relations keyed by kind: "implies",
+// "requires" and "excludes", as in the schema.
+func (f Fingerprint) Relations() map[string][]Relation {
+	out := make(map[string][]Relation)
+	for kind, ps := range map[string]Patterns{"implies": f.Implies, "requires": f.Requires, "excludes": f.Excludes} {
+		for _, p := range ps {
+			if rel, ok := parseRelation(p); ok {
+				out[kind] = append(out[kind], rel)
+			}
+		}
+	}
+	return out
+}
+
+func parseRelation(s string) (Relation, bool) {
+	parts := strings.Split(s, `\;`)
+	rel := Relation{Name: strings.TrimSpace(parts[0]), Confidence: 100}
+	for _, tag := range parts[1:] {
+		key, value, _ := strings.Cut(tag, ":")
+		if key == "confidence" {
+			if c, err := strconv.Atoi(value); err == nil && c >= 0 && c <= 100 {
+				rel.Confidence = c
+			}
+		}
+	}
+	return rel, rel.Name != ""
+}
@@ -0,0 +1,220 @@
+package fingerprint
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxBody caps how much of a response body is read and analyzed.
+const MaxBody = 2 << 20
+
+// UserAgent is sent on fetches; some sites serve bots a bare page.
+const UserAgent = "Mozilla/5.0 (compatible; SigMap/1.0; +https://github.com/Abhaythakor/SigMap)"
+
+// Response is the part of an HTTP response the engine looks at.
+type Response struct {
+	// URL is the final URL after redirects.
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// NewClient returns a client suited to fingerprinting: certificates are not
+// verified (TLS findings are collected separately) and redirects are
+// followed as a browser would.
+func NewClient() *http.Client {
+	return &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// Fetch GETs a URL and reads up to MaxBody of the response.
+func Fetch(ctx context.Context, client *http.Client, target string) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBody))
+	if err != nil {
+		return nil, err
+	}
+	return &Response{URL: resp.Request.URL.String(), StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// ReadResponse reads a saved response: a raw HTTP message as written by
+// `curl -i` or an intercepting proxy, or, failing that, a bare HTML body.
+// target is the URL the response was served from and may be empty.
+func ReadResponse(r io.Reader, target string) (*Response, error) {
+	br := bufio.NewReader(io.LimitReader(r, MaxBody+64<<10))
+	peek, _ := br.Peek(5)
+	if string(peek) != "HTTP/" {
+		body, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{URL: target, StatusCode: http.StatusOK, Header: http.Header{}, Body: body}, nil
+	}
+
+	// net/http only parses HTTP/1.x status lines; curl prints "HTTP/2 200".
+	status, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if proto, rest, ok := strings.Cut(status, " "); ok && !strings.Contains(proto, ".") {
+		status = "HTTP/1.1 " + rest
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(io.MultiReader(strings.NewReader(status), br)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if gz, err := gzip.NewReader(resp.Body); err == nil {
+			body = gz
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(body, MaxBody))
+	if err != nil && len(data) == 0 {
+		return nil, err
+	}
+	return &Response{URL: target, StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
+
+// ParseHeaders reads pasted response headers, one "Name: value" per line.
+// A leading status line and anything after the first blank line are
+// ignored, so a whole copied response also works.
+func ParseHeaders(text string) http.Header {
+	h := http.Header{}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(h) > 0 {
+				break
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, "HTTP/") || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	return h
+}
+
+var (
+	scriptTag = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	styleTag  = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style>`)
+	metaTag   = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	attribute = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// page is a response broken into the inputs the matchers run against.
+// Header, cookie and meta names are lower-cased.
+type page struct {
+	url       string
+	headers   map[string][]string
+	cookies   map[string]string
+	meta      map[string][]string
+	html      string
+	scripts   []string
+	scriptSrc []string
+	css       []string
+}
+
+func parse(r *Response) *page {
+	pg := &page{
+		url:     r.URL,
+		headers: map[string][]string{},
+		cookies: map[string]string{},
+		meta:    map[string][]string{},
+		html:    string(r.Body),
+	}
+	for name, values := range r.Header {
+		pg.headers[strings.ToLower(name)] = values
+	}
+	for _, c := range (&http.Response{Header: r.Header}).Cookies() {
+		pg.cookies[strings.ToLower(c.Name)] = c.Value
+	}
+
+	for _, m := range scriptTag.FindAllStringSubmatch(pg.html, -1) {
+		if src := attrs(m[1])["src"]; src != "" {
+			pg.scriptSrc = append(pg.scriptSrc, src)
+		}
+		if inline := strings.TrimSpace(m[2]); inline != "" {
+			pg.scripts = append(pg.scripts, inline)
+		}
+	}
+	for _, m := range styleTag.FindAllStringSubmatch(pg.html, -1) {
+		pg.css = append(pg.css, m[1])
+	}
+	for _, tag := range metaTag.FindAllString(pg.html, -1) {
+		a := attrs(tag)
+		name := a["name"]
+		if name == "" {
+			name = a["property"]
+		}
+		if name == "" {
+			name = a["http-equiv"]
+		}
+		if name != "" {
+			name = strings.ToLower(name)
+			pg.meta[name] = append(pg.meta[name], a["content"])
+		}
+	}
+	return pg
+}
+
+// cookie returns the value of a cookie, or nil when it is absent. A
+// trailing * in the name matches any cookie with that prefix.
+func (pg *page) cookie(name string) []string {
+	if prefix, ok := strings.CutSuffix(name, "*"); ok {
+		var values []string
+		for n, v := range pg.cookies {
+			if strings.HasPrefix(n, prefix) {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	if v, ok := pg.cookies[name]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+// attrs parses the attributes of an HTML start tag, lower-casing names.
+func attrs(tag string) map[string]string {
+	out := map[string]string{}
+	for _, m := range attribute.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(m[1])
+		if _, seen := out[name]; !seen {
+			out[name] = strings.TrimSpace(m[2] + m[3] + m[4])
+		}
+	}
+	return out
+}
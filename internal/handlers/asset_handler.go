@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/go-chi/chi/v5"
+)
+
+type AssetHandler struct {
+	Repo      *repositories.AssetRepository
+	templates map[string]*template.Template
+}
+
+func NewAssetHandler(repo *repositories.AssetRepository) *AssetHandler {
+	h := &AssetHandler{Repo: repo, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *AssetHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "ip_detail.html"),
+	}
+	h.templates["ip"] = template.Must(template.New("base").ParseFiles(files...))
+}
+
+// IPDetail lists every host resolving to an IP and its open services.
+func (h *AssetHandler) IPDetail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	ip, err := h.Repo.GetIP(r.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching IP details: %v", err)
+		http.Error(w, "IP not found", http.StatusNotFound)
+		return
+	}
+
+	data := struct {
+		CurrentPage string
+		IP          repositories.IPDetail
+	}{
+		CurrentPage: "domains",
+		IP:          ip,
+	}
+
+	if err := h.templates["ip"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering IP detail: %v", err)
+	}
+}
+
+func (h *AssetHandler) RedirectByAddress(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if net.ParseIP(address) == nil {
+		http.Error(w, "Valid address required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.Repo.GetIPIDByAddress(r.Context(), address)
+	if err != nil {
+		http.Redirect(w, r, "/domains", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/ips/%d", id), http.StatusSeeOther)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+const auditPageSize = 100
+
+// auditTargetTypes are the target types offered in the filter form.
+var auditTargetTypes = []string{"domain", "note", "alert_channel", "api_token", "workspace", "user"}
+
+type AuditHandler struct {
+	Repo      *repositories.AuditRepository
+	templates map[string]*template.Template
+}
+
+func NewAuditHandler(repo *repositories.AuditRepository) *AuditHandler {
+	h := &AuditHandler{Repo: repo, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *AuditHandler) parseTemplates() {
+	funcMap := template.FuncMap{
+		"json": func(raw json.RawMessage) string { return string(raw) },
+		"add":  func(a, b int) int { return a + b },
+		"sub":  func(a, b int) int { return a - b },
+	}
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_audit.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").Funcs(funcMap).ParseFiles(files...))
+}
+
+// parseAuditFilters reads the filter form; dates are YYYY-MM-DD and "to" is inclusive.
+func parseAuditFilters(q url.Values) repositories.AuditFilters {
+	f := repositories.AuditFilters{
+		Actor:      q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		IP:         q.Get("ip"),
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("from")); err == nil {
+		f.From = &t
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("to")); err == nil {
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+	return f
+}
+
+func (h *AuditHandler) View(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filters := parseAuditFilters(q)
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	events, err := h.Repo.List(r.Context(), filters, auditPageSize, (page-1)*auditPageSize)
+	if err != nil {
+		log.Printf("Audit list error: %v", err)
+		http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	total, err := h.Repo.Count(r.Context(), filters)
+	if err != nil {
+		http.Error(w, "Failed to count audit events", http.StatusInternalServerError)
+		return
+	}
+	actions, _ := h.Repo.ListActions(r.Context())
+
+	q.Del("page")
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Events      []models.AuditEvent
+		Actions     []string
+		TargetTypes []string
+		Query       url.Values
+		QueryString template.URL
+		Total       int
+		Page        int
+		HasPrev     bool
+		HasNext     bool
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "audit",
+		Events:      events,
+		Actions:     actions,
+		TargetTypes: auditTargetTypes,
+		Query:       r.URL.Query(),
+		QueryString: template.URL(q.Encode()),
+		Total:       total,
+		Page:        page,
+		HasPrev:     page > 1,
+		HasNext:     page*auditPageSize < total,
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering audit log: %v", err)
+	}
+}
+
+// Export streams the matching events as JSON Lines, oldest first.
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filters := parseAuditFilters(r.URL.Query())
+	audit.Describe(r.Context(), "audit.export", "", 0, "", nil, map[string]interface{}{"filters": filters})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", "attachment;filename=audit_events.jsonl")
+
+	enc := json.NewEncoder(w)
+	if err := h.Repo.Each(r.Context(), filters, func(e models.AuditEvent) error {
+		return enc.Encode(e)
+	}); err != nil {
+		log.Printf("Audit export error: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+)
+
+// loginCookie carries the state, nonce and PKCE verifier across the redirect.
+const loginCookie = "sigmap_oidc"
+
+type AuthHandler struct {
+	AuthSvc   *services.AuthService
+	templates map[string]*template.Template
+}
+
+func NewAuthHandler(authSvc *services.AuthService) *AuthHandler {
+	h := &AuthHandler{AuthSvc: authSvc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *AuthHandler) parseTemplates() {
+	h.templates["logged_out"] = template.Must(template.ParseFiles(filepath.Join("templates", "logged_out.html")))
+	h.templates["badge"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "user_badge.html")))
+}
+
+// Login starts the authorization code flow with PKCE.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !h.AuthSvc.Enabled() {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	authURL, ls, err := h.AuthSvc.BeginLogin(r.Context())
+	if err != nil {
+		log.Printf("Auth: failed to start login: %v", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	v := url.Values{}
+	v.Set("state", ls.State)
+	v.Set("nonce", ls.Nonce)
+	v.Set("verifier", ls.Verifier)
+	v.Set("next", safeNext(r.URL.Query().Get("next")))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookie,
+		Value:    v.Encode(),
+		Path:     "/auth/",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   h.secureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes the login and opens a session.
+func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if errCode := q.Get("error"); errCode != "" {
+		log.Printf("Auth: provider returned error %s: %s", errCode, q.Get("error_description"))
+		http.Error(w, "Login failed: "+errCode, http.StatusUnauthorized)
+		return
+	}
+
+	c, err := r.Cookie(loginCookie)
+	if err != nil {
+		http.Error(w, "Login session expired, please try again", http.StatusBadRequest)
+		return
+	}
+	saved, err := url.ParseQuery(c.Value)
+	if err != nil || saved.Get("state") == "" || saved.Get("state") != q.Get("state") {
+		http.Error(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: loginCookie, Path: "/auth/", MaxAge: -1})
+
+	ls := services.LoginState{State: saved.Get("state"), Nonce: saved.Get("nonce"), Verifier: saved.Get("verifier")}
+	_, token, expiresAt, err := h.AuthSvc.CompleteLogin(r.Context(), q.Get("code"), ls)
+	if err != nil {
+		log.Printf("Auth: login failed: %v", err)
+		audit.Describe(r.Context(), "auth.login_failed", "", 0, "", nil, map[string]string{"error": err.Error()})
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     customMiddleware.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   h.secureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, safeNext(saved.Get("next")), http.StatusSeeOther)
+}
+
+// Logout ends the current session.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(customMiddleware.SessionCookie); err == nil && c.Value != "" {
+		if err := h.AuthSvc.Logout(r.Context(), c.Value); err != nil {
+			log.Printf("Auth: failed to delete session: %v", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: customMiddleware.SessionCookie, Path: "/", MaxAge: -1})
+
+	w.Header().Set("HX-Redirect", "/auth/logged-out")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AuthHandler) LoggedOut(w http.ResponseWriter, r *http.Request) {
+	if err := h.templates["logged_out"].ExecuteTemplate(w, "logged_out", nil); err != nil {
+		log.Printf("Error rendering logged out page: %v", err)
+	}
+}
+
+// Badge renders the signed-in user panel for the sidebar.
+func (h *AuthHandler) Badge(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		User *models.User
+	}{
+		User: customMiddleware.UserFromContext(r.Context()),
+	}
+
+	if err := h.templates["badge"].ExecuteTemplate(w, "user_badge", data); err != nil {
+		log.Printf("Error rendering user badge: %v", err)
+	}
+}
+
+func (h *AuthHandler) secureCookies() bool {
+	return strings.HasPrefix(h.AuthSvc.OIDC.Config.RedirectURL, "https://")
+}
+
+// safeNext only allows local redirect targets.
+func safeNext(next string) string {
+	if next == "" || !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
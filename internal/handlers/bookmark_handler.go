@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/Abhaythakor/SigMap/internal/audit"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
@@ -81,6 +82,8 @@ func (h *BookmarkHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to toggle bookmark", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "bookmark.toggle", "domain", id, "",
+		map[string]bool{"is_bookmarked": !isBookmarked}, map[string]bool{"is_bookmarked": isBookmarked})
 
 	data := struct {
 		ID           int
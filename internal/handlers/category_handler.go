@@ -4,14 +4,19 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
 
 	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/search"
+	"github.com/go-chi/chi/v5"
 )
 
 type CategoryHandler struct {
 	Repo     *repositories.CategoryRepository
 	template *template.Template
+	detail   *template.Template
 }
 
 func NewCategoryHandler(repo *repositories.CategoryRepository) *CategoryHandler {
@@ -32,6 +37,14 @@ func (h *CategoryHandler) parseTemplates() {
 		log.Fatalf("Error parsing category templates: %v", err)
 	}
 	h.template = tmpl
+
+	detailFiles := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "category_detail.html"),
+	}
+	h.detail = template.Must(template.ParseFiles(detailFiles...))
 }
 
 func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -53,3 +66,41 @@ func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error rendering categories: %v", err)
 	}
 }
+
+// categoryTechRow links a member technology to its domains.
+type categoryTechRow struct {
+	repositories.CategoryTech
+	DomainsURL string
+}
+
+// Detail shows a category's risk, its member technologies and the domains
+// running them, each linking to its own page or domain search.
+func (h *CategoryHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	category, err := h.Repo.GetDetail(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+
+	techs := make([]categoryTechRow, len(category.Technologies))
+	for i, t := range category.Technologies {
+		techs[i] = categoryTechRow{CategoryTech: t, DomainsURL: "/domains?q=" + url.QueryEscape("tech:"+search.Quote(t.Name))}
+	}
+
+	data := struct {
+		CurrentPage  string
+		Category     *repositories.CategoryDetail
+		Technologies []categoryTechRow
+		DomainsURL   string
+	}{
+		CurrentPage:  "categories",
+		Category:     category,
+		Technologies: techs,
+		DomainsURL:   "/domains?q=" + url.QueryEscape("category:"+search.Quote(category.Name)),
+	}
+
+	if err := h.detail.ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering category detail: %v", err)
+	}
+}
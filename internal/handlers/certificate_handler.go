@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/go-chi/chi/v5"
+)
+
+const certificatePageSize = 50
+
+type CertificateHandler struct {
+	Repo      *repositories.CertificateRepository
+	templates map[string]*template.Template
+}
+
+func NewCertificateHandler(repo *repositories.CertificateRepository) *CertificateHandler {
+	h := &CertificateHandler{Repo: repo, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *CertificateHandler) parseTemplates() {
+	funcMap := template.FuncMap{
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+	}
+	base := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "cert_flags.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").Funcs(funcMap).ParseFiles(append(base, filepath.Join("templates", "certificates.html"))...))
+	h.templates["detail"] = template.Must(template.New("base").Funcs(funcMap).ParseFiles(append(base, filepath.Join("templates", "certificate_detail.html"))...))
+}
+
+// List shows the workspace's certificates, optionally narrowed to one finding.
+func (h *CertificateHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("filter")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	certs, err := h.Repo.List(r.Context(), filter, certificatePageSize, (page-1)*certificatePageSize)
+	if err != nil {
+		log.Printf("Error fetching certificates: %v", err)
+		http.Error(w, "Failed to fetch certificates", http.StatusBadRequest)
+		return
+	}
+	total, _ := h.Repo.Count(r.Context(), filter)
+
+	data := struct {
+		CurrentPage  string
+		Certificates []models.Certificate
+		Filter       string
+		Filters      []string
+		Total        int
+		Page         int
+		HasPrev      bool
+		HasNext      bool
+	}{
+		CurrentPage:  "certificates",
+		Certificates: certs,
+		Filter:       filter,
+		Filters: []string{repositories.CertFilterExpired, repositories.CertFilterExpiring,
+			repositories.CertFilterSelfSigned, repositories.CertFilterWeak},
+		Total:   total,
+		Page:    page,
+		HasPrev: page > 1,
+		HasNext: page*certificatePageSize < total,
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering certificates: %v", err)
+	}
+}
+
+// Detail shows a certificate with every host and port serving it.
+func (h *CertificateHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	cert, err := h.Repo.Get(r.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching certificate: %v", err)
+		http.Error(w, "Certificate not found", http.StatusNotFound)
+		return
+	}
+
+	data := struct {
+		CurrentPage string
+		Certificate repositories.CertificateDetail
+	}{
+		CurrentPage: "certificates",
+		Certificate: cert,
+	}
+
+	if err := h.templates["detail"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering certificate detail: %v", err)
+	}
+}
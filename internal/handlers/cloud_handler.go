@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+)
+
+// cloudGapLimit caps each gap table on the cloud page.
+const cloudGapLimit = 500
+
+type CloudHandler struct {
+	Svc       *services.CloudService
+	templates map[string]*template.Template
+}
+
+func NewCloudHandler(svc *services.CloudService) *CloudHandler {
+	h := &CloudHandler{Svc: svc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *CloudHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "cloud.html"),
+	}
+	h.templates["index"] = template.Must(template.ParseFiles(files...))
+}
+
+// View shows the imported accounts and the two reconciliation gaps: DNS
+// pointing at addresses no account owns, and resources never scanned.
+func (h *CloudHandler) View(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.Svc.Repo.Accounts(r.Context())
+	if err != nil {
+		log.Printf("Error fetching cloud accounts: %v", err)
+		http.Error(w, "Failed to fetch cloud inventory", http.StatusInternalServerError)
+		return
+	}
+	unowned, err := h.Svc.Repo.UnownedTargets(r.Context(), cloudGapLimit)
+	if err != nil {
+		log.Printf("Error fetching unowned DNS targets: %v", err)
+	}
+	unscanned, err := h.Svc.Repo.Unscanned(r.Context(), cloudGapLimit)
+	if err != nil {
+		log.Printf("Error fetching unscanned cloud resources: %v", err)
+	}
+
+	data := struct {
+		CurrentPage string
+		Accounts    []models.CloudAccount
+		Unowned     []models.UnownedTarget
+		Unscanned   []models.CloudResource
+		Limit       int
+	}{
+		CurrentPage: "cloud",
+		Accounts:    accounts,
+		Unowned:     unowned,
+		Unscanned:   unscanned,
+		Limit:       cloudGapLimit,
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering cloud inventory: %v", err)
+	}
+}
+
+// ImportJSON accepts one inventory export as the request body, the same
+// files read by -cloud-import. source names the export: a later upload with
+// the same source replaces it. account is needed for Route53, classic ELB
+// and Cloud DNS exports.
+func (h *CloudHandler) ImportJSON(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		http.Error(w, "source is required", http.StatusBadRequest)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, maxIngestBody)
+	defer body.Close()
+
+	res, err := h.Svc.Import(r.Context(), body, r.URL.Query().Get("account"), source)
+	audit.Describe(r.Context(), "cloud.import", "cloud_inventory", 0, source, nil, res)
+	if err != nil {
+		log.Printf("Cloud import of %s failed: %v", source, err)
+		http.Error(w, "Import failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -1,21 +1,33 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"html/template"
 	"log"
 	"net/http"
 	"path/filepath"
+	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/Abhaythakor/SigMap/internal/timewindow"
 )
 
+// defaultDashboardRange is the window of the trends widget without one.
+const defaultDashboardRange = "7d"
+
 type DashboardHandler struct {
 	Repo     *repositories.DashboardRepository
+	Svc      *services.DashboardService
 	template *template.Template
 }
 
-func NewDashboardHandler(repo *repositories.DashboardRepository) *DashboardHandler {
-	h := &DashboardHandler{Repo: repo}
+func NewDashboardHandler(repo *repositories.DashboardRepository, svc *services.DashboardService) *DashboardHandler {
+	h := &DashboardHandler{Repo: repo, Svc: svc}
 	h.parseTemplates()
 	return h
 }
@@ -41,22 +53,116 @@ func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	trends, _ := h.Repo.GetTrendData(r.Context())
+	win, windowErr := trendWindow(r.URL.Query(), defaultDashboardRange)
+	trends, _ := h.Repo.GetTrendData(r.Context(), win)
 	dist, _ := h.Repo.GetDistributionData(r.Context())
+	refreshes, _ := h.Svc.Refreshes(r.Context())
+
+	// Widgets served from a stale view were queried live
+	now := time.Now()
+	widgets := map[string]widgetFreshness{}
+	for _, v := range refreshes {
+		widgets[v.Name] = widgetFreshness{ViewRefresh: v, Live: !v.Fresh(now)}
+	}
 
+	user := customMiddleware.UserFromContext(r.Context())
 	data := struct {
 		CurrentPage  string
 		Stats        repositories.DashboardStats
 		Trends       []repositories.TrendPoint
 		Distribution []repositories.DistributionPoint
+		TrendsView   widgetFreshness
+		DistView     widgetFreshness
+		Refreshes    []repositories.ViewRefresh
+		IsAdmin      bool
+		Window       timewindow.Window
+		WindowError  string
+		Ranges       []string
+		TrendsURL    string
 	}{
 		CurrentPage:  "dashboard",
 		Stats:        stats,
 		Trends:       trends,
 		Distribution: dist,
+		TrendsView:   widgets[repositories.ViewDashboardTrends],
+		DistView:     widgets[repositories.ViewDashboardDistribution],
+		Refreshes:    refreshes,
+		IsAdmin:      user == nil || user.HasRole(models.RoleAdmin),
+		Window:       win,
+		Ranges:       timewindow.Ranges[:len(timewindow.Ranges)-1],
+		TrendsURL:    "/trends?" + win.Values().Encode(),
+	}
+	if !repositories.TrendsViewCovers(win) {
+		data.TrendsView.Live = true
+	}
+	if windowErr != nil {
+		data.WindowError = windowErr.Error()
 	}
 
 	if err := h.template.ExecuteTemplate(w, "base", data); err != nil {
 		log.Printf("Error rendering dashboard: %v", err)
 	}
 }
+
+// widgetFreshness is the refresh state of the view behind a widget, and
+// whether the widget was queried live instead.
+type widgetFreshness struct {
+	repositories.ViewRefresh
+	Live bool
+}
+
+// refresh refreshes the dashboard views now and audits it. It outlives the
+// request so a disconnect does not leave a view half refreshed.
+func (h *DashboardHandler) refresh(ctx context.Context) error {
+	err := h.Svc.Refresh(context.WithoutCancel(ctx), services.RefreshManual)
+	after := map[string]interface{}{"views": repositories.DashboardViews}
+	if err != nil {
+		after["error"] = err.Error()
+	}
+	audit.Describe(ctx, "dashboard.refresh", "dashboard", 0, "dashboard", nil, after)
+	return err
+}
+
+// Refresh refreshes the dashboard views from the dashboard's button.
+func (h *DashboardHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if err := h.refresh(r.Context()); err != nil {
+		http.Error(w, "Refresh failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/")
+	w.WriteHeader(http.StatusOK)
+}
+
+// RefreshJSON refreshes the dashboard views and returns their refresh state.
+func (h *DashboardHandler) RefreshJSON(w http.ResponseWriter, r *http.Request) {
+	if err := h.refresh(r.Context()); err != nil {
+		http.Error(w, "Refresh failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.RefreshesJSON(w, r)
+}
+
+// RefreshesJSON returns the refresh state of the dashboard views.
+func (h *DashboardHandler) RefreshesJSON(w http.ResponseWriter, r *http.Request) {
+	refreshes, err := h.Svc.Refreshes(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch refresh state", http.StatusInternalServerError)
+		return
+	}
+	type view struct {
+		Name        string     `json:"name"`
+		RefreshedAt *time.Time `json:"refreshed_at"`
+		DurationMS  int        `json:"duration_ms"`
+		Trigger     string     `json:"trigger,omitempty"`
+		Error       string     `json:"error,omitempty"`
+		DirtySince  *time.Time `json:"dirty_since"`
+		Fresh       bool       `json:"fresh"`
+	}
+	now := time.Now()
+	out := make([]view, len(refreshes))
+	for i, v := range refreshes {
+		out[i] = view{v.Name, v.RefreshedAt, v.DurationMS, v.Trigger, v.Error, v.DirtySince, v.Fresh(now)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
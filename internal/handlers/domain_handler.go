@@ -5,21 +5,27 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
 type DomainHandler struct {
 	Repo      *repositories.DomainRepository
+	Queries   *repositories.SavedQueryRepository
 	templates map[string]*template.Template
 }
 
-func NewDomainHandler(repo *repositories.DomainRepository) *DomainHandler {
+func NewDomainHandler(repo *repositories.DomainRepository, queries *repositories.SavedQueryRepository) *DomainHandler {
 	h := &DomainHandler{
 		Repo:      repo,
+		Queries:   queries,
 		templates: make(map[string]*template.Template),
 	}
 	h.parseTemplates()
@@ -31,6 +37,7 @@ func (h *DomainHandler) getFuncMap() template.FuncMap {
 		"add": func(a, b int) int { return a + b },
 		"sub": func(a, b int) int { return a - b },
 		"mul": func(a, b int) int { return a * b },
+		"join": strings.Join,
 		"min": func(a, b int) int {
 			if a < b {
 				return a
@@ -52,6 +59,7 @@ func (h *DomainHandler) parseTemplates() {
 		filepath.Join("templates", "partials", "domain_rows.html"),
 		filepath.Join("templates", "partials", "bookmark_button.html"),
 		filepath.Join("templates", "partials", "pagination.html"),
+		filepath.Join("templates", "partials", "saved_queries.html"),
 	}
 	h.templates["index"] = template.Must(template.New("base").Funcs(funcMap).ParseFiles(baseFiles...))
 
@@ -62,6 +70,7 @@ func (h *DomainHandler) parseTemplates() {
 		filepath.Join("templates", "partials", "header.html"),
 		filepath.Join("templates", "domain_detail.html"),
 		filepath.Join("templates", "partials", "bookmark_button.html"),
+		filepath.Join("templates", "partials", "cert_flags.html"),
 	}
 	h.templates["detail"] = template.Must(template.New("base").Funcs(funcMap).ParseFiles(detailFiles...))
 
@@ -74,27 +83,33 @@ func (h *DomainHandler) parseTemplates() {
 	h.templates["rows"] = template.Must(template.New("rows").Funcs(funcMap).ParseFiles(rowFiles...))
 }
 
+// domainFilters reads the domain list filters shared by the list, the bulk
+// editor, the CSV export and the API.
+func domainFilters(v url.Values) (repositories.DomainFilters, error) {
+	return repositories.ParseDomainFilters(v)
+}
+
 func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
-	filters := repositories.DomainFilters{
-		Search:       r.URL.Query().Get("search"),
-		Category:     r.URL.Query().Get("category"),
-		Confidence:   r.URL.Query().Get("confidence"),
-		IsBookmarked: r.URL.Query().Get("bookmarked") == "true",
-	}
+	filters, queryErr := domainFilters(r.URL.Query())
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 { page = 1 }
 	limit := 20
 	offset := (page - 1) * limit
 
-	items, err := h.Repo.List(r.Context(), limit, offset, filters)
-	if err != nil {
-		log.Printf("Error fetching domains: %v", err)
-		http.Error(w, "Failed to fetch domains", http.StatusInternalServerError)
-		return
+	var items []repositories.DomainListItem
+	total := 0
+	if queryErr == nil {
+		var err error
+		items, err = h.Repo.List(r.Context(), limit, offset, filters)
+		if err != nil {
+			log.Printf("Error fetching domains: %v", err)
+			http.Error(w, "Failed to fetch domains", http.StatusInternalServerError)
+			return
+		}
+		total, _ = h.Repo.Count(r.Context(), filters)
 	}
-
-	total, _ := h.Repo.Count(r.Context(), filters)
+	saved, _ := h.Queries.List(r.Context(), models.QueryTargetDomains)
 	data := struct {
 		CurrentPage string
 		Domains     []repositories.DomainListItem
@@ -103,6 +118,10 @@ func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
 		TotalPages  int
 		TotalItems  int
 		Limit       int
+		Levels      []string
+		Query       string
+		QueryError  string
+		Saved       []models.SavedQuery
 	}{
 		CurrentPage: "domains",
 		Domains:     items,
@@ -111,6 +130,12 @@ func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
 		TotalPages:  (total + limit - 1) / limit,
 		TotalItems:  total,
 		Limit:       limit,
+		Levels:      models.CriticalityLevels,
+		Query:       r.URL.Query().Get("q"),
+		Saved:       saved,
+	}
+	if queryErr != nil {
+		data.QueryError = queryErr.Error()
 	}
 
 	if r.Header.Get("HX-Request") == "true" {
@@ -135,9 +160,11 @@ func (h *DomainHandler) Detail(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		CurrentPage string
 		Domain      repositories.DomainDetail
+		Levels      []string
 	}{
 		CurrentPage: "domains",
 		Domain:      detail,
+		Levels:      models.CriticalityLevels,
 	}
 
 	if err := h.templates["detail"].ExecuteTemplate(w, "base", data); err != nil {
@@ -152,8 +179,7 @@ func (h *DomainHandler) RedirectByName(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var id int
-	err := h.Repo.Pool.QueryRow(r.Context(), "SELECT id FROM domains WHERE name = $1", name).Scan(&id)
+	id, err := h.Repo.GetDomainIDByName(r.Context(), name)
 	if err != nil {
 		http.Redirect(w, r, "/domains", http.StatusSeeOther)
 		return
@@ -161,3 +187,127 @@ func (h *DomainHandler) RedirectByName(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, fmt.Sprintf("/domains/%d", id), http.StatusSeeOther)
 }
+
+// parseDomainEdit turns one of the edit actions offered on the list and
+// detail pages into a repository edit: "tag" adds tags, "untag" removes tag
+// keys, "owner" and "criticality" set (or, when empty, clear) the field.
+func parseDomainEdit(action, value string) (repositories.DomainEdit, error) {
+	var edit repositories.DomainEdit
+	switch action {
+	case "tag", "untag":
+		tags, err := models.ParseTags(value)
+		if err != nil {
+			return edit, err
+		}
+		if len(tags) == 0 {
+			return edit, fmt.Errorf("no tags given")
+		}
+		if action == "tag" {
+			edit.AddTags = tags
+			return edit, nil
+		}
+		for _, t := range tags {
+			edit.RemoveKeys = append(edit.RemoveKeys, t.Key)
+		}
+	case "owner":
+		owner := strings.TrimSpace(value)
+		if len(owner) > 255 {
+			return edit, fmt.Errorf("owner is longer than 255 characters")
+		}
+		edit.Owner = &owner
+	case "criticality":
+		c, err := models.NormalizeCriticality(value)
+		if err != nil {
+			return edit, err
+		}
+		edit.Criticality = &c
+	default:
+		return edit, fmt.Errorf("unknown action %q", action)
+	}
+	return edit, nil
+}
+
+// Edit changes the tags, owner or criticality of one domain.
+func (h *DomainHandler) Edit(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	action, value := r.FormValue("action"), r.FormValue("value")
+	edit, err := parseDomainEdit(action, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := h.Repo.EditDomains(r.Context(), []int{id}, edit)
+	if err != nil {
+		log.Printf("Error editing domain %d: %v", id, err)
+		http.Error(w, "Failed to update domain", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Domain not found", http.StatusNotFound)
+		return
+	}
+	audit.Describe(r.Context(), "domain.edit", "domain", id, "", nil, map[string]interface{}{"action": action, "value": value})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// BulkEdit applies one edit to the domains ticked on the list page, or with
+// all=true to every domain matching the list filters sent along.
+func (h *DomainHandler) BulkEdit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	action, value := r.FormValue("action"), r.FormValue("value")
+	edit, err := parseDomainEdit(action, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var ids []int
+	filters, err := domainFilters(r.Form)
+	if err != nil {
+		http.Error(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("all") == "true" {
+		if ids, err = h.Repo.MatchingDomainIDs(r.Context(), filters); err != nil {
+			log.Printf("Error resolving bulk edit filters: %v", err)
+			http.Error(w, "Failed to fetch domains", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		for _, raw := range r.Form["ids"] {
+			if id, err := strconv.Atoi(raw); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		http.Error(w, "No domains selected", http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.Repo.EditDomains(r.Context(), ids, edit)
+	if err != nil {
+		log.Printf("Error bulk editing domains: %v", err)
+		http.Error(w, "Failed to update domains", http.StatusInternalServerError)
+		return
+	}
+	details := map[string]interface{}{"action": action, "value": value, "domains": n}
+	if r.FormValue("all") == "true" {
+		details["filters"] = filters
+	}
+	audit.Describe(r.Context(), "domain.bulk_edit", "domain", 0, "", nil, details)
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
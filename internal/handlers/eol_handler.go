@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/eol"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+)
+
+// eolProduct is an endoflife.date product name, e.g. nginx or craft-cms.
+var eolProduct = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+type EOLHandler struct {
+	Svc       *services.EOLService
+	templates map[string]*template.Template
+}
+
+func NewEOLHandler(svc *services.EOLService) *EOLHandler {
+	h := &EOLHandler{Svc: svc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *EOLHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_eol.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+}
+
+// View lists the imported products and the technology mappings, and the
+// release cycles of ?product=.
+func (h *EOLHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	products, err := h.Svc.Repo.Products(ctx)
+	if err != nil {
+		log.Printf("Error fetching EOL products: %v", err)
+		http.Error(w, "Failed to load end-of-life data", http.StatusInternalServerError)
+		return
+	}
+	mappings, err := h.Svc.Repo.Mappings(ctx)
+	if err != nil {
+		log.Printf("Error fetching EOL mappings: %v", err)
+		http.Error(w, "Failed to load end-of-life data", http.StatusInternalServerError)
+		return
+	}
+
+	product := r.URL.Query().Get("product")
+	var cycles []eol.Cycle
+	if product != "" {
+		if cycles, err = h.Svc.Repo.Cycles(ctx, product); err != nil {
+			http.Error(w, "Failed to load release cycles", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Source      string
+		Products    []models.EOLProduct
+		Mappings    []models.EOLMapping
+		Product     string
+		Cycles      []eol.Cycle
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "eol",
+		Source:      h.Svc.Source,
+		Products:    products,
+		Mappings:    mappings,
+		Product:     product,
+		Cycles:      cycles,
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering EOL settings: %v", err)
+	}
+}
+
+// importFrom imports from the given source, or the configured one, and
+// audits it. It outlives the request, which a full import can exceed.
+func (h *EOLHandler) importFrom(ctx context.Context, source string) (*services.EOLImport, error) {
+	res, err := h.Svc.Import(context.WithoutCancel(ctx), source)
+	if source == "" {
+		source = h.Svc.Source
+	}
+	after := map[string]interface{}{"source": source}
+	if res != nil {
+		after["products"] = res.Products
+		after["cycles"] = res.Cycles
+	}
+	audit.Describe(ctx, "eol.import", "eol_product", 0, source, nil, after)
+	return res, err
+}
+
+func (h *EOLHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.importFrom(r.Context(), strings.TrimSpace(r.FormValue("source"))); err != nil {
+		log.Printf("EOL import failed: %v", err)
+		http.Error(w, "Import failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/settings/eol")
+	w.WriteHeader(http.StatusOK)
+}
+
+// setMapping validates and stores a mapping, audits it and judges the
+// detections again.
+func (h *EOLHandler) setMapping(ctx context.Context, technology, product string) error {
+	technology, product = strings.TrimSpace(technology), strings.ToLower(strings.TrimSpace(product))
+	if technology == "" {
+		return fmt.Errorf("technology is required")
+	}
+	if !eolProduct.MatchString(product) {
+		return fmt.Errorf("%q is not an endoflife.date product name", product)
+	}
+	if err := h.Svc.Repo.SetMapping(ctx, technology, product); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "eol.map", "technology", 0, technology, nil, map[string]string{"product": product})
+	h.evaluate(ctx)
+	return nil
+}
+
+func (h *EOLHandler) deleteMapping(ctx context.Context, technology string) error {
+	if err := h.Svc.Repo.DeleteMapping(ctx, technology); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "eol.unmap", "technology", 0, technology, nil, nil)
+	h.evaluate(ctx)
+	return nil
+}
+
+// evaluate judges detections again after a mapping changed. The mapping is
+// stored either way; the hourly run retries.
+func (h *EOLHandler) evaluate(ctx context.Context) {
+	if err := h.Svc.Evaluate(context.WithoutCancel(ctx)); err != nil {
+		log.Printf("EOL evaluation failed: %v", err)
+	}
+}
+
+func (h *EOLHandler) SetMapping(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	if err := h.setMapping(r.Context(), r.FormValue("technology"), r.FormValue("product")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/settings/eol")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteMapping unmaps ?technology=.
+func (h *EOLHandler) DeleteMapping(w http.ResponseWriter, r *http.Request) {
+	if err := h.deleteMapping(r.Context(), r.URL.Query().Get("technology")); err != nil {
+		log.Printf("Error deleting EOL mapping: %v", err)
+		http.Error(w, "Failed to delete mapping", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/settings/eol")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ProductsJSON returns the imported products.
+func (h *EOLHandler) ProductsJSON(w http.ResponseWriter, r *http.Request) {
+	products, err := h.Svc.Repo.Products(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load end-of-life data", http.StatusInternalServerError)
+		return
+	}
+	if products == nil {
+		products = []models.EOLProduct{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// MappingsJSON returns the technology to product mappings.
+func (h *EOLHandler) MappingsJSON(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.Svc.Repo.Mappings(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load end-of-life data", http.StatusInternalServerError)
+		return
+	}
+	if mappings == nil {
+		mappings = []models.EOLMapping{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mappings)
+}
+
+// ImportJSON imports from {"source": "..."}, or the configured source when
+// the body is empty.
+func (h *EOLHandler) ImportJSON(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	res, err := h.importFrom(r.Context(), strings.TrimSpace(req.Source))
+	if err != nil {
+		log.Printf("EOL import failed: %v", err)
+		http.Error(w, "Import failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// SetMappingJSON maps {"technology": "...", "product": "..."}.
+func (h *EOLHandler) SetMappingJSON(w http.ResponseWriter, r *http.Request) {
+	var m models.EOLMapping
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := h.setMapping(r.Context(), m.Technology, m.Product); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteMappingJSON unmaps ?technology=.
+func (h *EOLHandler) DeleteMappingJSON(w http.ResponseWriter, r *http.Request) {
+	if err := h.deleteMapping(r.Context(), r.URL.Query().Get("technology")); err != nil {
+		http.Error(w, "Failed to delete mapping", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/Abhaythakor/SigMap/internal/audit"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
@@ -19,11 +21,10 @@ func NewExportHandler(repo *repositories.DomainRepository) *ExportHandler {
 }
 
 func (h *ExportHandler) Domains(w http.ResponseWriter, r *http.Request) {
-	filters := repositories.DomainFilters{
-		Search:       r.URL.Query().Get("search"),
-		Category:     r.URL.Query().Get("category"),
-		Confidence:   r.URL.Query().Get("confidence"),
-		IsBookmarked: r.URL.Query().Get("bookmarked") == "true",
+	filters, err := domainFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Fetch all matching records (limit 10000 for export)
@@ -34,6 +35,8 @@ func (h *ExportHandler) Domains(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Describe(r.Context(), "export.domains", "", 0, "", nil, map[string]interface{}{"filters": filters, "rows": len(items)})
+
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", "attachment;filename=domains_export.csv")
 
@@ -41,7 +44,7 @@ func (h *ExportHandler) Domains(w http.ResponseWriter, r *http.Request) {
 	defer writer.Flush()
 
 	// Header
-	writer.Write([]string{"Domain", "Technologies", "Categories", "Confidence", "Last Seen"})
+	writer.Write([]string{"Domain", "Technologies", "Categories", "Confidence", "Last Seen", "Owner", "Criticality", "Tags", "Risk Score", "Risk Level"})
 
 	for _, item := range items {
 		techNames := make([]string, len(item.Technologies))
@@ -55,6 +58,11 @@ func (h *ExportHandler) Domains(w http.ResponseWriter, r *http.Request) {
 			strings.Join(item.Categories, "; "),
 			fmt.Sprintf("%d%%", item.Confidence),
 			item.LastSeen,
+			item.Owner,
+			item.Criticality,
+			strings.Join(item.Tags, "; "),
+			strconv.Itoa(item.RiskScore),
+			item.RiskLevel,
 		})
 	}
 }
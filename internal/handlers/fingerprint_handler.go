@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// exampleFingerprint seeds the editor for a new custom fingerprint.
+const exampleFingerprint = `{
+  "cats": [1],
+  "headers": {
+    "X-Powered-By": "^Acme/([\\d.]+)\\;version:\\1"
+  },
+  "html": [
+    "<div id=\"acme-root\""
+  ]
+}`
+
+type FingerprintHandler struct {
+	Svc       *services.FingerprintService
+	templates map[string]*template.Template
+}
+
+func NewFingerprintHandler(svc *services.FingerprintService) *FingerprintHandler {
+	h := &FingerprintHandler{Svc: svc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *FingerprintHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "partials", "fingerprint_test.html"),
+		filepath.Join("templates", "settings_fingerprints.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+	h.templates["test"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "fingerprint_test.html")))
+}
+
+type detectResult struct {
+	URL          string                  `json:"url,omitempty"`
+	StatusCode   int                     `json:"status_code,omitempty"`
+	Technologies []fingerprint.Detection `json:"technologies"`
+}
+
+// DetectJSON runs the local engine. A JSON body {"url": "..."} fetches the
+// URL; any other body is taken as a saved response (raw HTTP or HTML), with
+// ?url= naming where it was served from.
+func (h *FingerprintHandler) DetectJSON(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, fingerprint.MaxBody+64<<10)
+	defer body.Close()
+
+	var res detectResult
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var req struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "url must be an absolute http(s) URL", http.StatusBadRequest)
+			return
+		}
+		resp, detections, err := h.Svc.Detect(r.Context(), req.URL)
+		if err != nil {
+			h.detectError(w, err)
+			return
+		}
+		res = detectResult{URL: resp.URL, StatusCode: resp.StatusCode, Technologies: detections}
+	} else {
+		target := r.URL.Query().Get("url")
+		detections, err := h.Svc.DetectSaved(r.Context(), body, target)
+		if err != nil {
+			h.detectError(w, err)
+			return
+		}
+		res = detectResult{URL: target, Technologies: detections}
+	}
+	if res.Technologies == nil {
+		res.Technologies = []fingerprint.Detection{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *FingerprintHandler) detectError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrNoFingerprints) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Fingerprint detection failed: %v", err)
+	http.Error(w, "Detection failed: "+err.Error(), http.StatusBadGateway)
+}
+
+// View lists the custom fingerprints with an editor for a new one or, given
+// ?id=, an existing one. ?revision= loads an earlier revision into the
+// editor; saving it restores it as a new revision.
+func (h *FingerprintHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	custom, err := h.Svc.Repo.ListCustom(ctx)
+	if err != nil {
+		log.Printf("Error fetching custom fingerprints: %v", err)
+		http.Error(w, "Failed to load fingerprints", http.StatusInternalServerError)
+		return
+	}
+
+	var editing *repositories.CustomFingerprint
+	var revisions []repositories.FingerprintRevision
+	editor := exampleFingerprint
+	loaded := 0
+	if id, _ := strconv.Atoi(r.URL.Query().Get("id")); id > 0 {
+		if editing, err = h.Svc.Repo.GetCustom(ctx, id); err != nil {
+			http.Error(w, "Fingerprint not found", http.StatusNotFound)
+			return
+		}
+		revisions, _ = h.Svc.Repo.Revisions(ctx, id)
+		fp := editing.Fingerprint
+		loaded = editing.Revision
+		if rev, _ := strconv.Atoi(r.URL.Query().Get("revision")); rev > 0 {
+			for _, old := range revisions {
+				if old.Revision == rev {
+					fp, loaded = old.Fingerprint, rev
+					editing.Description, editing.Website = old.Description, old.Website
+				}
+			}
+		}
+		editor = matchersJSON(fp)
+	}
+
+	user := customMiddleware.UserFromContext(ctx)
+	data := struct {
+		CurrentPage  string
+		SettingsTab  string
+		Fingerprints []repositories.CustomFingerprint
+		Editing      *repositories.CustomFingerprint
+		Revisions    []repositories.FingerprintRevision
+		Loaded       int
+		Editor       string
+		CanEdit      bool
+	}{
+		CurrentPage:  "settings",
+		SettingsTab:  "fingerprints",
+		Fingerprints: custom,
+		Editing:      editing,
+		Revisions:    revisions,
+		Loaded:       loaded,
+		Editor:       editor,
+		CanEdit:      user == nil || user.HasRole(models.RoleAdmin),
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering fingerprints: %v", err)
+	}
+}
+
+// matchersJSON is the editor form of a fingerprint: everything but the
+// description and website, which have their own fields.
+func matchersJSON(fp fingerprint.Fingerprint) string {
+	fp.Description, fp.Website = "", ""
+	data, _ := json.MarshalIndent(fp, "", "  ")
+	return string(data)
+}
+
+// fingerprintForm reads the editor: the matchers JSON plus the
+// description and website fields.
+func fingerprintForm(r *http.Request) (string, fingerprint.Fingerprint, error) {
+	var fp fingerprint.Fingerprint
+	if err := json.Unmarshal([]byte(r.FormValue("matchers")), &fp); err != nil {
+		return "", fp, fmt.Errorf("%w: matchers: %v", services.ErrInvalidFingerprint, err)
+	}
+	fp.Description = strings.TrimSpace(r.FormValue("description"))
+	fp.Website = strings.TrimSpace(r.FormValue("website"))
+	return r.FormValue("name"), fp, nil
+}
+
+type fingerprintTest struct {
+	Name      string
+	Detection *fingerprint.Detection
+	Error     string
+}
+
+func (h *FingerprintHandler) renderTest(w http.ResponseWriter, data fingerprintTest) {
+	if err := h.templates["test"].ExecuteTemplate(w, "fingerprint_test", data); err != nil {
+		log.Printf("Error rendering fingerprint test: %v", err)
+	}
+}
+
+// save stores a custom fingerprint and audits it.
+func (h *FingerprintHandler) save(r *http.Request, id int, name string, fp fingerprint.Fingerprint) (int, int, error) {
+	ctx := r.Context()
+	createdBy := ""
+	if user := customMiddleware.UserFromContext(ctx); user != nil {
+		createdBy = user.DisplayName()
+	}
+	id, revision, err := h.Svc.SaveCustom(ctx, id, name, fp, createdBy)
+	if err != nil {
+		return 0, 0, err
+	}
+	audit.Describe(ctx, "fingerprint.save", "technology", id, strings.TrimSpace(name), nil,
+		map[string]interface{}{"revision": revision, "fingerprint": fp.Matchers()})
+	return id, revision, nil
+}
+
+// Save stores the editor's fingerprint as a new revision. Validation errors
+// are shown in the test panel.
+func (h *FingerprintHandler) Save(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	name, fp, err := fingerprintForm(r)
+	if err == nil {
+		id, _, err = h.save(r, id, name, fp)
+	}
+	if errors.Is(err, services.ErrInvalidFingerprint) {
+		w.Header().Set("HX-Retarget", "#fingerprint-test-result")
+		h.renderTest(w, fingerprintTest{Name: name, Error: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving fingerprint %q: %v", name, err)
+		http.Error(w, "Failed to save fingerprint", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/settings/fingerprints?id=%d", id))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Test runs the editor's fingerprint, saved or not, against pasted headers
+// and HTML.
+func (h *FingerprintHandler) Test(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	name, fp, err := fingerprintForm(r)
+	if name == "" {
+		name = "Untitled"
+	}
+	res := fingerprintTest{Name: name}
+	if err == nil {
+		resp := &fingerprint.Response{
+			URL:        r.FormValue("url"),
+			StatusCode: http.StatusOK,
+			Header:     fingerprint.ParseHeaders(r.FormValue("headers")),
+			Body:       []byte(r.FormValue("html")),
+		}
+		res.Detection, err = fingerprint.Test(name, fp, resp)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	h.renderTest(w, res)
+}
+
+// deleteCustom drops a custom fingerprint and audits it.
+func (h *FingerprintHandler) deleteCustom(r *http.Request) (int, error) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	before, err := h.Svc.Repo.GetCustom(ctx, id)
+	if err != nil {
+		return http.StatusNotFound, err
+	}
+	if err := h.Svc.Repo.DeleteCustom(ctx, id); err != nil {
+		if err == pgx.ErrNoRows {
+			return http.StatusNotFound, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	audit.Describe(ctx, "fingerprint.delete", "technology", id, before.Name,
+		map[string]interface{}{"revision": before.Revision, "fingerprint": before.Fingerprint}, nil)
+	return http.StatusOK, nil
+}
+
+func (h *FingerprintHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if status, err := h.deleteCustom(r); err != nil {
+		http.Error(w, "Failed to delete fingerprint", status)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/settings/fingerprints")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListJSON returns the custom fingerprints.
+func (h *FingerprintHandler) ListJSON(w http.ResponseWriter, r *http.Request) {
+	custom, err := h.Svc.Repo.ListCustom(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load fingerprints", http.StatusInternalServerError)
+		return
+	}
+	if custom == nil {
+		custom = []repositories.CustomFingerprint{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(custom)
+}
+
+type fingerprintRequest struct {
+	Name        string                  `json:"name"`
+	Fingerprint fingerprint.Fingerprint `json:"fingerprint"`
+	// Test harness input
+	Headers string `json:"headers"`
+	HTML    string `json:"html"`
+	URL     string `json:"url"`
+}
+
+// SaveJSON creates a custom fingerprint by name, or with {id} in the path
+// updates that one.
+func (h *FingerprintHandler) SaveJSON(w http.ResponseWriter, r *http.Request) {
+	var req fingerprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	id, revision, err := h.save(r, id, req.Name, req.Fingerprint)
+	if errors.Is(err, services.ErrInvalidFingerprint) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == pgx.ErrNoRows {
+		http.Error(w, "Fingerprint not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving fingerprint %q: %v", req.Name, err)
+		http.Error(w, "Failed to save fingerprint", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"id": id, "revision": revision})
+}
+
+func (h *FingerprintHandler) DeleteJSON(w http.ResponseWriter, r *http.Request) {
+	if status, err := h.deleteCustom(r); err != nil {
+		http.Error(w, "Failed to delete fingerprint", status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevisionsJSON returns a custom fingerprint's history, newest first.
+func (h *FingerprintHandler) RevisionsJSON(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	revisions, err := h.Svc.Repo.Revisions(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load revisions", http.StatusInternalServerError)
+		return
+	}
+	if len(revisions) == 0 {
+		http.Error(w, "Fingerprint not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// TestJSON runs {fingerprint} against {headers}, {html} and {url}; the
+// detection is null when nothing matched.
+func (h *FingerprintHandler) TestJSON(w http.ResponseWriter, r *http.Request) {
+	var req fingerprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = "Untitled"
+	}
+	resp := &fingerprint.Response{URL: req.URL, StatusCode: http.StatusOK, Header: fingerprint.ParseHeaders(req.Headers), Body: []byte(req.HTML)}
+	d, err := fingerprint.Test(req.Name, req.Fingerprint, resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"detection": d})
+}
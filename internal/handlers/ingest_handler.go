@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/services"
+)
+
+// maxIngestBody caps a single ingestion upload.
+const maxIngestBody = 64 << 20
+
+type IngestHandler struct {
+	IngestSvc *services.IngestionService
+}
+
+func NewIngestHandler(ingestSvc *services.IngestionService) *IngestHandler {
+	return &IngestHandler{IngestSvc: ingestSvc}
+}
+
+// Ingest accepts newline-delimited ScanResult JSON, the same format read by -ingest.
+func (h *IngestHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxIngestBody)
+	defer body.Close()
+
+	stored, err := h.IngestSvc.IngestReader(r.Context(), body, "API upload")
+	audit.Describe(r.Context(), "ingest.upload", "", 0, "", nil, map[string]int{"stored": stored})
+	if err != nil {
+		log.Printf("API ingestion error after %d detections: %v", stored, err)
+		http.Error(w, "Ingestion failed", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"stored": stored})
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/Abhaythakor/SigMap/internal/audit"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
@@ -82,14 +83,17 @@ func (h *NoteHandler) List(w http.ResponseWriter, r *http.Request) {
 func (h *NoteHandler) New(w http.ResponseWriter, r *http.Request) {
 	domainIDStr := r.URL.Query().Get("domain_id")
 	domainID, _ := strconv.Atoi(domainIDStr)
+	technologyID, _ := strconv.Atoi(r.URL.Query().Get("technology_id"))
 
 	data := struct {
-		DomainID int
-		IsEdit   bool
-		Note     repositories.NoteListItem
+		DomainID     int
+		TechnologyID int
+		IsEdit       bool
+		Note         repositories.NoteListItem
 	}{
-		DomainID: domainID,
-		IsEdit:   false,
+		DomainID:     domainID,
+		TechnologyID: technologyID,
+		IsEdit:       false,
 	}
 
 	if err := h.templates["form"].ExecuteTemplate(w, "note_form", data); err != nil {
@@ -108,9 +112,10 @@ func (h *NoteHandler) Edit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		DomainID int
-		IsEdit   bool
-		Note     repositories.NoteListItem
+		DomainID     int
+		TechnologyID int
+		IsEdit       bool
+		Note         repositories.NoteListItem
 	}{
 		IsEdit: true,
 		Note:   note,
@@ -128,13 +133,26 @@ func (h *NoteHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	domainID, _ := strconv.Atoi(r.FormValue("domain_id"))
+	technologyID, _ := strconv.Atoi(r.FormValue("technology_id"))
 	content := r.FormValue("content")
 	author := r.FormValue("author")
 
+	if technologyID > 0 {
+		if err := h.Repo.CreateTechnologyNote(r.Context(), technologyID, content, author); err != nil {
+			http.Error(w, "Failed to save note", http.StatusInternalServerError)
+			return
+		}
+		audit.Describe(r.Context(), "note.create", "technology", technologyID, "", nil, map[string]string{"content": content, "author": author})
+		w.Header().Set("HX-Redirect", "/technologies/"+strconv.Itoa(technologyID))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	if err := h.Repo.CreateNote(r.Context(), domainID, content, author); err != nil {
 		http.Error(w, "Failed to save note", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "note.create", "domain", domainID, "", nil, map[string]string{"content": content, "author": author})
 
 	w.Header().Set("HX-Redirect", "/notes")
 	w.WriteHeader(http.StatusOK)
@@ -151,10 +169,18 @@ func (h *NoteHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	content := r.FormValue("content")
 
+	before, err := h.Repo.GetNoteByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
 	if err := h.Repo.UpdateNote(r.Context(), id, content); err != nil {
 		http.Error(w, "Failed to update note", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "note.update", "note", id, before.Target,
+		map[string]string{"content": before.Content}, map[string]string{"content": content})
 
 	w.Header().Set("HX-Redirect", "/notes")
 	w.WriteHeader(http.StatusOK)
@@ -164,10 +190,18 @@ func (h *NoteHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 
+	before, err := h.Repo.GetNoteByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
 	if err := h.Repo.DeleteNote(r.Context(), id); err != nil {
 		http.Error(w, "Failed to delete note", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "note.delete", "note", id, before.Target,
+		map[string]string{"content": before.Content, "author": before.Author}, nil)
 
 	w.WriteHeader(http.StatusOK)
 }
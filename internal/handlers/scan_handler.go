@@ -2,31 +2,35 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 
+	"github.com/Abhaythakor/SigMap/internal/audit"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 	"github.com/Abhaythakor/SigMap/internal/services"
 	"github.com/Abhaythakor/SigMap/internal/vulnintel"
 )
 
 type ScanHandler struct {
-	DomainRepo *repositories.DomainRepository
-	IngestSvc  *services.IngestionService
-	VulnSvc    *vulnintel.Service
-	ChaosSvc   *services.ChaosService
-	HTTPXSvc   *services.HTTPXService
-	NucleiSvc  *services.NucleiService
+	DomainRepo   *repositories.DomainRepository
+	IngestSvc    *services.IngestionService
+	VulnSvc      *vulnintel.Service
+	DiscoverySvc *services.DiscoveryService
+	TLSSvc       *services.TLSService
+	HTTPXSvc     *services.HTTPXService
+	NucleiSvc    *services.NucleiService
 }
 
-func NewScanHandler(repo *repositories.DomainRepository, ingestSvc *services.IngestionService, vulnSvc *vulnintel.Service, chaosSvc *services.ChaosService, httpxSvc *services.HTTPXService, nucleiSvc *services.NucleiService) *ScanHandler {
+func NewScanHandler(repo *repositories.DomainRepository, ingestSvc *services.IngestionService, vulnSvc *vulnintel.Service, discoverySvc *services.DiscoveryService, tlsSvc *services.TLSService, httpxSvc *services.HTTPXService, nucleiSvc *services.NucleiService) *ScanHandler {
 	return &ScanHandler{
-		DomainRepo: repo,
-		IngestSvc:  ingestSvc,
-		VulnSvc:    vulnSvc,
-		ChaosSvc:   chaosSvc,
-		HTTPXSvc:   httpxSvc,
-		NucleiSvc:  nucleiSvc,
+		DomainRepo:   repo,
+		IngestSvc:    ingestSvc,
+		VulnSvc:      vulnSvc,
+		DiscoverySvc: discoverySvc,
+		TLSSvc:       tlsSvc,
+		HTTPXSvc:     httpxSvc,
+		NucleiSvc:    nucleiSvc,
 	}
 }
 
@@ -50,25 +54,43 @@ func (h *ScanHandler) Trigger(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to ensure domain", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(ctx, "scan.trigger", "domain", domainID, domainName, nil,
+		map[string][]string{"stages": {"infra", "discovery", "tls", "httpx", "nuclei"}})
 
 	// 1. Infrastructure Enrichment
 	h.IngestSvc.LookupInfrastructure(ctx, domainID, domainName)
 
 	// 2. Subdomain Discovery (Background)
-	go h.ChaosSvc.DiscoverSubdomains(ctx, domainName)
+	// Background work outlives the request but keeps its workspace.
+	bg := context.WithoutCancel(ctx)
+	go h.DiscoverySvc.DiscoverSubdomains(bg, domainName)
 
-	// 3. Live Tech Detection (via HTTPX)
+	// 3. Certificates & SAN discovery (Background)
 	go func() {
-		if err := h.HTTPXSvc.ScanDomain(context.Background(), domainName); err != nil {
+		if err := h.TLSSvc.ScanHost(bg, domainID, domainName); err != nil {
+			log.Printf("TLS error for %s: %v", domainName, err)
+		}
+	}()
+
+	// 4. Live Tech Detection (via HTTPX)
+	go func() {
+		if err := h.HTTPXSvc.ScanDomain(bg, domainName); err != nil {
 			log.Printf("Scan error for %s: %v", domainName, err)
 		}
 		
-		// 4. Active Vulnerability Scan (Nuclei) - Run after tech detection
-		if err := h.NucleiSvc.ScanAndStore(context.Background(), domainID, domainName); err != nil {
+		// 5. Active Vulnerability Scan (Nuclei) - Run after tech detection
+		if err := h.NucleiSvc.ScanAndStore(bg, domainID, domainName); err != nil {
 			log.Printf("Nuclei error for %s: %v", domainName, err)
 		}
 	}()
 
+	if r.Header.Get("HX-Request") != "true" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"domain": domainName, "domain_id": domainID, "status": "queued"})
+		return
+	}
+
 	w.Header().Set("HX-Refresh", "true")
 	w.WriteHeader(http.StatusOK)
 }
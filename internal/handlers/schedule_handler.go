@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type ScheduleHandler struct {
+	ScheduleSvc *services.ScheduleService
+	templates   map[string]*template.Template
+}
+
+func NewScheduleHandler(scheduleSvc *services.ScheduleService) *ScheduleHandler {
+	h := &ScheduleHandler{ScheduleSvc: scheduleSvc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *ScheduleHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "schedules.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+}
+
+func (h *ScheduleHandler) List(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.ScheduleSvc.Repo.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load schedules", http.StatusInternalServerError)
+		return
+	}
+
+	user := customMiddleware.UserFromContext(r.Context())
+	data := struct {
+		CurrentPage string
+		Schedules   []models.ScanSchedule
+		Stages      []string
+		CanEdit     bool
+	}{
+		CurrentPage: "schedules",
+		Schedules:   schedules,
+		Stages:      models.AllStages,
+		CanEdit:     user == nil || user.HasRole(models.RoleAnalyst),
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering schedules: %v", err)
+	}
+}
+
+func (h *ScheduleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	jitter, _ := strconv.Atoi(r.FormValue("jitter_seconds"))
+	concurrency, _ := strconv.Atoi(r.FormValue("max_concurrency"))
+	sch := models.ScanSchedule{
+		Name:           r.FormValue("name"),
+		CronExpr:       r.FormValue("cron"),
+		Timezone:       r.FormValue("timezone"),
+		Stages:         r.Form["stages"],
+		JitterSeconds:  jitter,
+		MaxConcurrency: concurrency,
+	}
+	if user := customMiddleware.UserFromContext(r.Context()); user != nil {
+		sch.CreatedBy = user.DisplayName()
+	}
+
+	if _, err := h.ScheduleSvc.Create(r.Context(), sch, r.FormValue("targets")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/schedules")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ScheduleHandler) Toggle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err := h.ScheduleSvc.SetActive(r.Context(), id, r.FormValue("active") == "true"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ScheduleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err := h.ScheduleSvc.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ScheduleHandler) AddTargets(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.ScheduleSvc.AddTargets(r.Context(), id, r.FormValue("targets")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ScheduleHandler) RemoveTarget(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	targetID, _ := strconv.Atoi(chi.URLParam(r, "targetID"))
+
+	if err := h.ScheduleSvc.RemoveTarget(r.Context(), id, targetID); err != nil {
+		http.Error(w, "Failed to remove target", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// RunNow queues a target for the scheduler's next tick.
+func (h *ScheduleHandler) RunNow(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	targetID, _ := strconv.Atoi(chi.URLParam(r, "targetID"))
+
+	if err := h.ScheduleSvc.RunNow(r.Context(), id, targetID); err != nil {
+		http.Error(w, "Failed to queue target", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
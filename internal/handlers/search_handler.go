@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/search"
+	"github.com/go-chi/chi/v5"
+)
+
+// Autocomplete and API result limits.
+const (
+	suggestLimit   = 15
+	searchPageSize = 100
+)
+
+var errSavedQueryNotFound = errors.New("saved query not found")
+
+// SearchHandler serves the list query language: autocomplete, saved queries
+// and running queries from the API.
+type SearchHandler struct {
+	Domains   *repositories.DomainRepository
+	Techs     *repositories.TechRepository
+	Queries   *repositories.SavedQueryRepository
+	templates map[string]*template.Template
+}
+
+func NewSearchHandler(domains *repositories.DomainRepository, techs *repositories.TechRepository, queries *repositories.SavedQueryRepository) *SearchHandler {
+	h := &SearchHandler{Domains: domains, Techs: techs, Queries: queries, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *SearchHandler) parseTemplates() {
+	h.templates["suggest"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "search_suggest.html")))
+	h.templates["pinned"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "pinned_views.html")))
+	h.templates["views"] = template.Must(template.ParseFiles(
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "queries.html"),
+	))
+}
+
+type suggestion struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// suggest completes the last word of a query: field names until a ':' is
+// typed, then known values of the field. Each suggestion is the whole query
+// with the word completed.
+func (h *SearchHandler) suggest(ctx context.Context, target, q string) ([]suggestion, error) {
+	fields, err := models.QueryFields(target)
+	if err != nil {
+		return nil, err
+	}
+	c := search.Complete(q)
+	prefix := c.Prefix
+	if c.Negated {
+		prefix += "-"
+	}
+
+	var out []suggestion
+	if c.Field == "" {
+		for _, f := range fields {
+			if strings.HasPrefix(f.Name, strings.ToLower(c.Partial)) {
+				out = append(out, suggestion{Value: prefix + f.Name + ":", Label: f.Help + ", e.g. " + f.Example})
+			}
+		}
+		return out, nil
+	}
+
+	f, ok := search.Lookup(fields, c.Field)
+	if !ok {
+		return nil, nil
+	}
+	var values []string
+	switch f.Kind {
+	case search.KindLevel, search.KindFlag:
+		for _, v := range f.Values {
+			if strings.HasPrefix(v, strings.ToLower(c.Partial)) {
+				values = append(values, v)
+			}
+		}
+	case search.KindText:
+		field := f.Name
+		if target == models.QueryTargetTechnologies && field == "name" {
+			field = "techname"
+		}
+		if values, err = h.Domains.SuggestValues(ctx, field, c.Partial, suggestLimit); err != nil {
+			return nil, err
+		}
+	default:
+		return []suggestion{{Value: q, Label: f.Help + ", e.g. " + f.Example}}, nil
+	}
+	for _, v := range values {
+		out = append(out, suggestion{Value: prefix + f.Name + ":" + c.Compare + search.Quote(v), Label: f.Help})
+	}
+	return out, nil
+}
+
+// Suggest renders autocomplete options for the query box of a list page.
+func (h *SearchHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.suggest(r.Context(), r.URL.Query().Get("target"), r.URL.Query().Get("q"))
+	if err != nil {
+		log.Printf("Error building query suggestions: %v", err)
+	}
+	if err := h.templates["suggest"].ExecuteTemplate(w, "search_suggest", suggestions); err != nil {
+		log.Printf("Error rendering query suggestions: %v", err)
+	}
+}
+
+// SuggestJSON returns autocomplete suggestions for API clients.
+func (h *SearchHandler) SuggestJSON(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.suggest(r.Context(), r.URL.Query().Get("target"), r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// FieldsJSON documents the query fields of a target.
+func (h *SearchHandler) FieldsJSON(w http.ResponseWriter, r *http.Request) {
+	fields, err := models.QueryFields(r.URL.Query().Get("target"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fields)
+}
+
+// save validates and stores a saved query. Filters are kept for domain
+// views only, and only the list's known filter parameters.
+func (h *SearchHandler) save(ctx context.Context, q models.SavedQuery) (int, error) {
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" || len(q.Name) > 255 {
+		return 0, fmt.Errorf("name must be 1-255 characters")
+	}
+	fields, err := models.QueryFields(q.Target)
+	if err != nil {
+		return 0, err
+	}
+	if q.Target == models.QueryTargetDomains {
+		raw, err := url.ParseQuery(q.Filters)
+		if err != nil {
+			return 0, fmt.Errorf("invalid filters: %w", err)
+		}
+		q.Filters = domainFilterValues(raw).Encode()
+	} else {
+		q.Filters = ""
+	}
+	if strings.TrimSpace(q.Query) == "" && q.Filters == "" {
+		return 0, fmt.Errorf("query is empty")
+	}
+	if _, err := search.Parse(q.Query, fields); err != nil {
+		return 0, fmt.Errorf("invalid query: %w", err)
+	}
+	if user := customMiddleware.UserFromContext(ctx); user != nil {
+		q.CreatedBy = user.DisplayName()
+	}
+
+	id, err := h.Queries.Save(ctx, q)
+	if err != nil {
+		log.Printf("Error saving query %q: %v", q.Name, err)
+		return 0, fmt.Errorf("failed to save query")
+	}
+	audit.Describe(ctx, "saved_query.save", "saved_query", id, q.Name, nil,
+		map[string]interface{}{"target": q.Target, "query": q.Query, "filters": q.Filters})
+	return id, nil
+}
+
+// domainFilterValues keeps the non-empty domain list filters of v.
+func domainFilterValues(v url.Values) url.Values {
+	out := url.Values{}
+	for _, key := range repositories.DomainFilterKeys {
+		for _, value := range v[key] {
+			if value = strings.TrimSpace(value); value != "" {
+				out.Add(key, value)
+			}
+		}
+	}
+	return out
+}
+
+// delete removes a saved query.
+func (h *SearchHandler) delete(ctx context.Context, id int) error {
+	before, err := h.Queries.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := h.Queries.Delete(ctx, id); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "saved_query.delete", "saved_query", id, before.Name,
+		map[string]interface{}{"target": before.Target, "query": before.Query}, nil)
+	return nil
+}
+
+// Save stores the query and filters of a list page as a named view and
+// reloads the list with it.
+func (h *SearchHandler) Save(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	q := models.SavedQuery{
+		Name:    r.FormValue("name"),
+		Target:  r.FormValue("target"),
+		Query:   r.FormValue("q"),
+		Filters: domainFilterValues(r.Form).Encode(),
+		Pinned:  r.FormValue("pinned") == "true",
+	}
+	id, err := h.save(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := h.Queries.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load saved query", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", saved.URL())
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *SearchHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := h.delete(r.Context(), id); err != nil {
+		http.Error(w, "Saved query not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListJSON returns the saved queries of the workspace, optionally for one
+// target.
+func (h *SearchHandler) ListJSON(w http.ResponseWriter, r *http.Request) {
+	queries, err := h.Queries.List(r.Context(), r.URL.Query().Get("target"))
+	if err != nil {
+		http.Error(w, "Failed to load saved queries", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queries)
+}
+
+// CreateJSON saves a query from a JSON request.
+func (h *SearchHandler) CreateJSON(w http.ResponseWriter, r *http.Request) {
+	var q models.SavedQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if q.Target == "" {
+		q.Target = models.QueryTargetDomains
+	}
+	id, err := h.save(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := h.Queries.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load saved query", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+func (h *SearchHandler) DeleteJSON(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := h.delete(r.Context(), id); err != nil {
+		http.Error(w, "Saved query not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runJSON writes one page of the results of the query in v, plus the
+// domain list filters for domains.
+func (h *SearchHandler) runJSON(w http.ResponseWriter, r *http.Request, target string, v url.Values) {
+	fields, err := models.QueryFields(target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := v.Get("q")
+	q, err := search.Parse(query, fields)
+	if err != nil {
+		http.Error(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	filters, _ := repositories.ParseDomainFilters(v)
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * searchPageSize
+
+	var results interface{}
+	if target == models.QueryTargetTechnologies {
+		results, err = h.Techs.List(r.Context(), searchPageSize, offset, q)
+	} else {
+		results, err = h.Domains.List(r.Context(), searchPageSize, offset, filters)
+	}
+	if err != nil {
+		log.Printf("Error running %s query: %v", target, err)
+		http.Error(w, "Failed to run query", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Target  string      `json:"target"`
+		Query   string      `json:"query"`
+		Page    int         `json:"page"`
+		Results interface{} `json:"results"`
+	}{target, query, page, results})
+}
+
+// SearchJSON runs ?q= against ?target= (domains by default).
+func (h *SearchHandler) SearchJSON(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		target = models.QueryTargetDomains
+	}
+	h.runJSON(w, r, target, r.URL.Query())
+}
+
+// ResultsJSON runs a saved query.
+func (h *SearchHandler) ResultsJSON(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	q, err := h.Queries.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Saved query not found", http.StatusNotFound)
+		return
+	}
+	h.runJSON(w, r, q.Target, q.Values())
+}
+
+// savedView is a saved query on the views page.
+type savedView struct {
+	models.SavedQuery
+	Subscribed map[int]bool
+	Changes    []models.SavedQueryChange
+}
+
+// Views lists the saved views of the workspace with their pins,
+// subscriptions and latest membership changes.
+func (h *SearchHandler) Views(w http.ResponseWriter, r *http.Request) {
+	queries, err := h.Queries.List(r.Context(), "")
+	if err != nil {
+		log.Printf("Error fetching saved queries: %v", err)
+		http.Error(w, "Failed to load saved queries", http.StatusInternalServerError)
+		return
+	}
+	channels, _ := h.Domains.ListAlertChannels(r.Context())
+	changes, _ := h.Queries.ListChanges(r.Context(), 0, 200)
+
+	views := make([]savedView, len(queries))
+	for i, q := range queries {
+		views[i] = savedView{SavedQuery: q, Subscribed: make(map[int]bool)}
+		for _, id := range q.Channels {
+			views[i].Subscribed[id] = true
+		}
+		for _, c := range changes {
+			if c.SavedQueryID == q.ID && len(views[i].Changes) < 5 {
+				views[i].Changes = append(views[i].Changes, c)
+			}
+		}
+	}
+
+	user := customMiddleware.UserFromContext(r.Context())
+	data := struct {
+		CurrentPage string
+		Views       []savedView
+		Channels    []models.AlertChannel
+		CanEdit     bool
+	}{
+		CurrentPage: "queries",
+		Views:       views,
+		Channels:    channels,
+		CanEdit:     user == nil || user.HasRole(models.RoleAnalyst),
+	}
+	if err := h.templates["views"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering saved views: %v", err)
+	}
+}
+
+// Pinned renders the views pinned to the sidebar.
+func (h *SearchHandler) Pinned(w http.ResponseWriter, r *http.Request) {
+	queries, err := h.Queries.ListPinned(r.Context())
+	if err != nil {
+		log.Printf("Error fetching pinned views: %v", err)
+	}
+	if err := h.templates["pinned"].ExecuteTemplate(w, "pinned_views", queries); err != nil {
+		log.Printf("Error rendering pinned views: %v", err)
+	}
+}
+
+// Pin pins a view to the sidebar, or unpins it.
+func (h *SearchHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	q, err := h.Queries.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Saved query not found", http.StatusNotFound)
+		return
+	}
+	pinned := r.FormValue("pinned") == "true"
+	if err := h.Queries.SetPinned(r.Context(), id, pinned); err != nil {
+		http.Error(w, "Failed to pin saved query", http.StatusInternalServerError)
+		return
+	}
+	audit.Describe(r.Context(), "saved_query.pin", "saved_query", id, q.Name,
+		map[string]bool{"pinned": q.Pinned}, map[string]bool{"pinned": pinned})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// subscribe replaces the channels subscribed to a domain view.
+func (h *SearchHandler) subscribe(ctx context.Context, id int, channelIDs []int) error {
+	q, err := h.Queries.Get(ctx, id)
+	if err != nil {
+		return errSavedQueryNotFound
+	}
+	if q.Target != models.QueryTargetDomains {
+		return fmt.Errorf("only domain views can be subscribed to")
+	}
+	createdBy := ""
+	if user := customMiddleware.UserFromContext(ctx); user != nil {
+		createdBy = user.DisplayName()
+	}
+	if err := h.Queries.SetSubscriptions(ctx, id, channelIDs, createdBy); err != nil {
+		log.Printf("Error subscribing to saved query %d: %v", id, err)
+		return fmt.Errorf("failed to update subscriptions")
+	}
+	after, _ := h.Queries.Get(ctx, id)
+	audit.Describe(ctx, "saved_query.subscribe", "saved_query", id, q.Name,
+		map[string][]int{"channel_ids": q.Channels}, map[string][]int{"channel_ids": after.Channels})
+	return nil
+}
+
+func subscribeStatus(err error) int {
+	if errors.Is(err, errSavedQueryNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// Subscribe sets the alert channels notified when domains enter or leave a
+// view, from the channel_id checkboxes of the views page.
+func (h *SearchHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	var channelIDs []int
+	for _, v := range r.Form["channel_id"] {
+		if n, err := strconv.Atoi(v); err == nil {
+			channelIDs = append(channelIDs, n)
+		}
+	}
+	if err := h.subscribe(r.Context(), id, channelIDs); err != nil {
+		http.Error(w, err.Error(), subscribeStatus(err))
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// SubscriptionsJSON sets the subscribed channels of a view from
+// {"channel_ids": [...]}; an empty list unsubscribes every channel.
+func (h *SearchHandler) SubscriptionsJSON(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ChannelIDs []int `json:"channel_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if err := h.subscribe(r.Context(), id, body.ChannelIDs); err != nil {
+		http.Error(w, err.Error(), subscribeStatus(err))
+		return
+	}
+	saved, err := h.Queries.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to load saved query", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// ChangesJSON returns the latest membership changes of a view.
+func (h *SearchHandler) ChangesJSON(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if _, err := h.Queries.Get(r.Context(), id); err != nil {
+		http.Error(w, "Saved query not found", http.StatusNotFound)
+		return
+	}
+	changes, err := h.Queries.ListChanges(r.Context(), id, searchPageSize)
+	if err != nil {
+		http.Error(w, "Failed to load changes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
@@ -4,10 +4,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/Abhaythakor/SigMap/internal/audit"
 	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
@@ -31,10 +33,20 @@ func (h *SettingsHandler) parseTemplates() {
 		filepath.Join("templates", "layouts", "base.html"),
 		filepath.Join("templates", "partials", "sidebar.html"),
 		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
 		filepath.Join("templates", "settings_alerts.html"),
 		filepath.Join("templates", "partials", "toast.html"),
 	}
 	h.templates["alerts"] = template.Must(template.New("base").ParseFiles(files...))
+
+	tagFiles := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_tags.html"),
+	}
+	h.templates["tags"] = template.Must(template.New("base").ParseFiles(tagFiles...))
 }
 
 func (h *SettingsHandler) AlertsView(w http.ResponseWriter, r *http.Request) {
@@ -46,10 +58,14 @@ func (h *SettingsHandler) AlertsView(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		CurrentPage string
+		SettingsTab string
 		Channels    []models.AlertChannel
+		Levels      []string
 	}{
 		CurrentPage: "settings",
+		SettingsTab: "alerts",
 		Channels:    channels,
+		Levels:      models.CriticalityLevels,
 	}
 
 	if err := h.templates["alerts"].ExecuteTemplate(w, "base", data); err != nil {
@@ -63,14 +79,30 @@ func (h *SettingsHandler) AddChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	name := r.FormValue("name")
-	cType := r.FormValue("type")
-	url := r.FormValue("url")
+	channel := models.AlertChannel{
+		Name:     r.FormValue("name"),
+		Type:     r.FormValue("type"),
+		URL:      r.FormValue("url"),
+		IsActive: true,
+	}
+	tags, err := models.ParseTags(r.FormValue("route_tags"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, t := range tags {
+		channel.RouteTags = append(channel.RouteTags, t.String())
+	}
+	if channel.MinCriticality, err = models.NormalizeCriticality(r.FormValue("min_criticality")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.Repo.AddAlertChannel(r.Context(), name, cType, url); err != nil {
+	if err := h.Repo.AddAlertChannel(r.Context(), channel); err != nil {
 		http.Error(w, "Failed to add channel", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "alert_channel.create", "alert_channel", 0, channel.Name, nil, channelSnapshot(channel))
 
 	w.Header().Set("HX-Redirect", "/settings/alerts")
 	w.WriteHeader(http.StatusOK)
@@ -80,10 +112,105 @@ func (h *SettingsHandler) DeleteChannel(w http.ResponseWriter, r *http.Request)
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 
+	before, err := h.Repo.GetAlertChannel(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
 	if err := h.Repo.DeleteAlertChannel(r.Context(), id); err != nil {
 		http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
 		return
 	}
+	audit.Describe(r.Context(), "alert_channel.delete", "alert_channel", id, before.Name, channelSnapshot(before), nil)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// channelSnapshot is the audit view of a channel. Webhook URLs carry secrets,
+// so only their host is kept.
+func channelSnapshot(c models.AlertChannel) map[string]interface{} {
+	host := ""
+	if u, err := url.Parse(c.URL); err == nil {
+		host = u.Host
+	}
+	return map[string]interface{}{"name": c.Name, "type": c.Type, "host": host, "is_active": c.IsActive,
+		"route_tags": c.RouteTags, "min_criticality": c.MinCriticality}
+}
+
+// TagsView lists the tag rules that label hosts automatically by name.
+func (h *SettingsHandler) TagsView(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.Repo.ListTagRules(r.Context())
+	if err != nil {
+		log.Printf("Error fetching tag rules: %v", err)
+		http.Error(w, "Failed to load tag rules", http.StatusInternalServerError)
+		return
+	}
+	keys, _ := h.Repo.ListTagKeys(r.Context())
+
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Rules       []models.TagRule
+		Keys        []string
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "tags",
+		Rules:       rules,
+		Keys:        keys,
+	}
+
+	if err := h.templates["tags"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering tag rules: %v", err)
+	}
+}
+
+// AddTagRule creates a tag rule and applies it to the hosts already tracked.
+func (h *SettingsHandler) AddTagRule(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	pattern, err := models.NormalizeTagPattern(r.FormValue("pattern"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tag, err := models.ParseTag(r.FormValue("tag"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, n, err := h.Repo.AddTagRule(r.Context(), pattern, tag)
+	if err != nil {
+		log.Printf("Error adding tag rule: %v", err)
+		http.Error(w, "Failed to add tag rule", http.StatusInternalServerError)
+		return
+	}
+	audit.Describe(r.Context(), "tag_rule.create", "tag_rule", id, pattern, nil,
+		map[string]interface{}{"pattern": pattern, "tag": tag.String(), "tagged": n})
+
+	w.Header().Set("HX-Redirect", "/settings/tags")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteTagRule removes a tag rule and the tags it applied.
+func (h *SettingsHandler) DeleteTagRule(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	before, err := h.Repo.GetTagRule(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Tag rule not found", http.StatusNotFound)
+		return
+	}
+	if err := h.Repo.DeleteTagRule(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete tag rule", http.StatusInternalServerError)
+		return
+	}
+	audit.Describe(r.Context(), "tag_rule.delete", "tag_rule", id, before.Pattern,
+		map[string]interface{}{"pattern": before.Pattern, "tag": before.Tag.String()}, nil)
 
 	w.WriteHeader(http.StatusOK)
 }
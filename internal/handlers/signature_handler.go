@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type SignatureHandler struct {
+	Svc       *services.SyncService
+	templates map[string]*template.Template
+}
+
+func NewSignatureHandler(svc *services.SyncService) *SignatureHandler {
+	h := &SignatureHandler{Svc: svc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *SignatureHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_signatures.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+}
+
+// View lists the recorded signature versions and, given ?from= and ?to=,
+// the differences between two of them.
+func (h *SignatureHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	versions, err := h.Svc.Versions.List(ctx)
+	if err != nil {
+		log.Printf("Error fetching signature versions: %v", err)
+		http.Error(w, "Failed to load signature versions", http.StatusInternalServerError)
+		return
+	}
+
+	var diff *models.SignatureDiff
+	from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+	to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+	if from > 0 && to > 0 {
+		if diff, err = h.Svc.Diff(ctx, from, to); err != nil {
+			http.Error(w, "Signature version not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Source      string
+		Versions    []models.SignatureVersion
+		Diff        *models.SignatureDiff
+		From, To    int
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "signatures",
+		Source:      h.Svc.Source,
+		Versions:    versions,
+		Diff:        diff,
+		From:        from,
+		To:          to,
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering signature versions: %v", err)
+	}
+}
+
+// sync runs a sync from the given source, or the configured one. It
+// outlives the request: an applied version must not be cut off halfway.
+func (h *SignatureHandler) sync(ctx context.Context, source string) (*services.SyncResult, error) {
+	if source == "" {
+		source = h.Svc.Source
+	}
+	res, err := h.Svc.SyncFrom(context.WithoutCancel(ctx), source)
+	after := map[string]interface{}{"source": source}
+	if res != nil {
+		after["version"] = res.Version.ID
+		after["applied"] = res.Applied
+		after["new"] = res.New
+	}
+	audit.Describe(ctx, "signatures.sync", "signature_version", 0, source, nil, after)
+	return res, err
+}
+
+// Sync fetches signatures now, from the form's source or the configured one.
+func (h *SignatureHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.sync(r.Context(), r.FormValue("source")); err != nil {
+		log.Printf("Signature sync failed: %v", err)
+		http.Error(w, "Sync failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/settings/signatures")
+	w.WriteHeader(http.StatusOK)
+}
+
+// change applies, pins or unpins a version and audits it.
+func (h *SignatureHandler) change(r *http.Request, action string) (*models.SignatureVersion, int, error) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	before, err := h.Svc.Versions.Get(ctx, id)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+
+	switch action {
+	case "apply":
+		err = h.Svc.Apply(context.WithoutCancel(ctx), id)
+	case "pin":
+		err = h.Svc.Pin(context.WithoutCancel(ctx), id)
+	case "unpin":
+		err = h.Svc.Unpin(ctx, id)
+	}
+	if err != nil {
+		log.Printf("Signature version %d %s failed: %v", id, action, err)
+		return nil, http.StatusInternalServerError, err
+	}
+	after, err := h.Svc.Versions.Get(ctx, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	audit.Describe(ctx, "signatures."+action, "signature_version", id, before.ShortHash(),
+		map[string]bool{"active": before.Active, "pinned": before.Pinned},
+		map[string]bool{"active": after.Active, "pinned": after.Pinned})
+	return after, http.StatusOK, nil
+}
+
+func (h *SignatureHandler) respond(w http.ResponseWriter, r *http.Request, action string) {
+	if _, status, err := h.change(r, action); err != nil {
+		http.Error(w, "Failed to "+action+" signature version", status)
+		return
+	}
+	w.Header().Set("HX-Redirect", "/settings/signatures")
+	w.WriteHeader(http.StatusOK)
+}
+
+// Apply rolls signatures back (or forward) to a recorded version. Unless
+// it is pinned, the next sync applies the newest version again.
+func (h *SignatureHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, "apply")
+}
+
+// Pin applies a version and keeps syncs from replacing it.
+func (h *SignatureHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, "pin")
+}
+
+func (h *SignatureHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, "unpin")
+}
+
+// ListJSON returns the recorded versions, newest first.
+func (h *SignatureHandler) ListJSON(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.Svc.Versions.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load signature versions", http.StatusInternalServerError)
+		return
+	}
+	if versions == nil {
+		versions = []models.SignatureVersion{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// DiffJSON reports the technologies added, removed or changed between
+// ?from= and ?to=.
+func (h *SignatureHandler) DiffJSON(w http.ResponseWriter, r *http.Request) {
+	from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+	to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+	diff, err := h.Svc.Diff(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, "Signature version not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// SyncJSON syncs from {"source": "..."}, or the configured source when the
+// body is empty.
+func (h *SignatureHandler) SyncJSON(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	res, err := h.sync(r.Context(), req.Source)
+	if err != nil {
+		log.Printf("Signature sync failed: %v", err)
+		http.Error(w, "Sync failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *SignatureHandler) changeJSON(w http.ResponseWriter, r *http.Request, action string) {
+	v, status, err := h.change(r, action)
+	if err != nil {
+		http.Error(w, "Failed to "+action+" signature version", status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *SignatureHandler) ApplyJSON(w http.ResponseWriter, r *http.Request) {
+	h.changeJSON(w, r, "apply")
+}
+
+func (h *SignatureHandler) PinJSON(w http.ResponseWriter, r *http.Request) {
+	h.changeJSON(w, r, "pin")
+}
+
+func (h *SignatureHandler) UnpinJSON(w http.ResponseWriter, r *http.Request) {
+	h.changeJSON(w, r, "unpin")
+}
@@ -4,18 +4,28 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/search"
+	"github.com/Abhaythakor/SigMap/internal/version"
+	"github.com/Abhaythakor/SigMap/internal/vulnintel"
 )
 
 type TechHandler struct {
 	Repo     *repositories.TechRepository
+	Queries  *repositories.SavedQueryRepository
+	Vulns    *vulnintel.Service
 	template *template.Template
+	detail   *template.Template
 }
 
-func NewTechHandler(repo *repositories.TechRepository) *TechHandler {
-	h := &TechHandler{Repo: repo}
+func NewTechHandler(repo *repositories.TechRepository, queries *repositories.SavedQueryRepository, vulns *vulnintel.Service) *TechHandler {
+	h := &TechHandler{Repo: repo, Queries: queries, Vulns: vulns}
 	h.parseTemplates()
 	return h
 }
@@ -26,30 +36,131 @@ func (h *TechHandler) parseTemplates() {
 		filepath.Join("templates", "partials", "sidebar.html"),
 		filepath.Join("templates", "partials", "header.html"),
 		filepath.Join("templates", "technologies.html"),
+		filepath.Join("templates", "partials", "saved_queries.html"),
 	}
 	tmpl, err := template.ParseFiles(files...)
 	if err != nil {
 		log.Fatalf("Error parsing tech templates: %v", err)
 	}
 	h.template = tmpl
+
+	detailFiles := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "technology_detail.html"),
+	}
+	h.detail = template.Must(template.ParseFiles(detailFiles...))
 }
 
 func (h *TechHandler) List(w http.ResponseWriter, r *http.Request) {
-	items, err := h.Repo.List(r.Context(), 50, 0)
-	if err != nil {
-		http.Error(w, "Failed to fetch technologies", http.StatusInternalServerError)
-		return
+	query := r.URL.Query().Get("q")
+	q, queryErr := search.Parse(query, search.TechnologyFields)
+
+	var items []repositories.TechListItem
+	if queryErr == nil {
+		var err error
+		if items, err = h.Repo.List(r.Context(), 50, 0, q); err != nil {
+			http.Error(w, "Failed to fetch technologies", http.StatusInternalServerError)
+			return
+		}
 	}
+	saved, _ := h.Queries.List(r.Context(), models.QueryTargetTechnologies)
 
 	data := struct {
 		CurrentPage  string
 		Technologies []repositories.TechListItem
+		Query        string
+		QueryError   string
+		Saved        []models.SavedQuery
 	}{
 		CurrentPage:  "technologies",
 		Technologies: items,
+		Query:        query,
+		Saved:        saved,
+	}
+	if queryErr != nil {
+		data.QueryError = queryErr.Error()
 	}
 
 	if err := h.template.ExecuteTemplate(w, "base", data); err != nil {
 		log.Printf("Error rendering technologies: %v", err)
 	}
 }
+
+// techVersionRow is a detected version with the CVEs whose affected range
+// includes it.
+type techVersionRow struct {
+	repositories.TechVersion
+	CVEs       []string
+	DomainsURL string // the domain list filtered to this version
+}
+
+func (h *TechHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	tech, err := h.Repo.GetDetail(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Technology not found", http.StatusNotFound)
+		return
+	}
+
+	profile, err := h.Vulns.GetTechVulnProfile(r.Context(), tech.Name)
+	if err != nil {
+		log.Printf("Error fetching vulnerability profile for %s: %v", tech.Name, err)
+	}
+	unranged := 0
+	for _, f := range profile.DetailedVulns {
+		if f.AffectedVersions == "" {
+			unranged++
+		}
+	}
+
+	rows := make([]techVersionRow, len(tech.Versions))
+	var labels []string
+	var counts, affected []int
+	for i, v := range tech.Versions {
+		query := "tech:" + search.Quote(tech.Name)
+		if _, err := version.Parse(v.Version); err == nil {
+			query += " version:" + search.Quote(v.Version)
+		}
+		rows[i] = techVersionRow{TechVersion: v, DomainsURL: "/domains?q=" + url.QueryEscape(query)}
+		for _, f := range profile.DetailedVulns {
+			if hit, known := f.Affects(v.Version); hit && known {
+				rows[i].CVEs = append(rows[i].CVEs, f.CVE)
+			}
+		}
+		label := v.Version
+		if label == "" {
+			label = "unknown"
+		}
+		labels = append(labels, label)
+		counts = append(counts, v.DomainCount)
+		affected = append(affected, len(rows[i].CVEs))
+	}
+
+	data := struct {
+		CurrentPage string
+		DomainsURL  string
+		Tech        *repositories.TechDetail
+		Versions    []techVersionRow
+		Profile     vulnintel.VulnProfile
+		Unranged    int
+		ChartLabels []string
+		ChartCounts []int
+		ChartCVEs   []int
+	}{
+		CurrentPage: "technologies",
+		DomainsURL:  "/domains?q=" + url.QueryEscape("tech:"+search.Quote(tech.Name)),
+		Tech:        tech,
+		Versions:    rows,
+		Profile:     profile,
+		Unranged:    unranged,
+		ChartLabels: labels,
+		ChartCounts: counts,
+		ChartCVEs:   affected,
+	}
+
+	if err := h.detail.ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering technology detail: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+type TokenHandler struct {
+	TokenSvc  *services.TokenService
+	templates map[string]*template.Template
+}
+
+func NewTokenHandler(tokenSvc *services.TokenService) *TokenHandler {
+	h := &TokenHandler{TokenSvc: tokenSvc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *TokenHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_tokens.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+	h.templates["created"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "token_created.html")))
+}
+
+func (h *TokenHandler) View(w http.ResponseWriter, r *http.Request) {
+	user := customMiddleware.UserFromContext(r.Context())
+	tokens, err := h.TokenSvc.List(r.Context(), user)
+	if err != nil {
+		http.Error(w, "Failed to load tokens", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Tokens      []models.APIToken
+		Scopes      []string
+		IsAdmin     bool
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "tokens",
+		Tokens:      tokens,
+		Scopes:      models.AllScopes,
+		IsAdmin:     user == nil || user.HasRole(models.RoleAdmin),
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering tokens: %v", err)
+	}
+}
+
+// Create issues a token from the settings form and shows the secret once.
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.FormValue("expires_days"))
+	raw, token, err := h.TokenSvc.Create(r.Context(), r.FormValue("name"), r.FormValue("kind"), r.Form["scopes"],
+		time.Duration(days)*24*time.Hour, customMiddleware.UserFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := struct {
+		Token models.APIToken
+		Raw   string
+	}{
+		Token: token,
+		Raw:   raw,
+	}
+
+	if err := h.templates["created"].ExecuteTemplate(w, "token_created", data); err != nil {
+		log.Printf("Error rendering token: %v", err)
+	}
+}
+
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err := h.TokenSvc.Revoke(r.Context(), id, customMiddleware.UserFromContext(r.Context())); err != nil {
+		http.Error(w, "Failed to revoke token", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListJSON returns token metadata for automation with the admin scope.
+func (h *TokenHandler) ListJSON(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.TokenSvc.List(r.Context(), nil)
+	if err != nil {
+		http.Error(w, "Failed to load tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateJSON issues a service token from a JSON request.
+func (h *TokenHandler) CreateJSON(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string   `json:"name"`
+		Scopes      []string `json:"scopes"`
+		ExpiresDays int      `json:"expires_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	raw, token, err := h.TokenSvc.Create(r.Context(), req.Name, models.TokenService, req.Scopes,
+		time.Duration(req.ExpiresDays)*24*time.Hour, customMiddleware.UserFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		models.APIToken
+		Token string `json:"token"`
+	}{token, raw})
+}
+
+func (h *TokenHandler) RevokeJSON(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err := h.TokenSvc.Revoke(r.Context(), id, nil); err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
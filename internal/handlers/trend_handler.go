@@ -1,14 +1,32 @@
 package handlers
 
 import (
+	"encoding/json"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"time"
 
 	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/timewindow"
 )
 
+// defaultTrendRange is the window of the trends page without one.
+const defaultTrendRange = "30d"
+
+// trendWindow reads the window from URL parameters. An invalid one falls
+// back to def, and its error is returned for the page to show.
+func trendWindow(v url.Values, def string) (timewindow.Window, error) {
+	now := time.Now()
+	w, err := timewindow.Parse(v, def, now)
+	if err != nil {
+		w, _ = timewindow.Parse(url.Values{}, def, now)
+	}
+	return w, err
+}
+
 type TrendHandler struct {
 	Repo     *repositories.TrendRepo
 	template *template.Template
@@ -35,25 +53,90 @@ func (h *TrendHandler) parseTemplates() {
 }
 
 func (h *TrendHandler) List(w http.ResponseWriter, r *http.Request) {
-	trends, err := h.Repo.GetTrends(r.Context())
+	win, windowErr := trendWindow(r.URL.Query(), defaultTrendRange)
+	filter := repositories.ParseTrendFilter(r.URL.Query())
+
+	trends, err := h.Repo.GetTrends(r.Context(), win, filter)
 	if err != nil {
 		http.Error(w, "Failed to fetch trends", http.StatusInternalServerError)
 		return
 	}
 
-	velocity, _ := h.Repo.GetVelocityData(r.Context())
+	velocity, _ := h.Repo.GetVelocityData(r.Context(), win, filter)
 
 	data := struct {
-		CurrentPage string
-		Trends      []repositories.TrendStat
-		Velocity    []repositories.TrendPoint
+		CurrentPage   string
+		Trends        []repositories.TrendStat
+		Velocity      []repositories.TrendPoint
+		Window        timewindow.Window
+		WindowError   string
+		Filter        repositories.TrendFilter
+		Ranges        []string
+		Granularities []string
+		Auto          bool   // granularity chosen from the range
+		From, To      string // custom range bounds, as dates
 	}{
-		CurrentPage: "trends",
-		Trends:      trends,
-		Velocity:    velocity,
+		CurrentPage:   "trends",
+		Trends:        trends,
+		Velocity:      velocity,
+		Window:        win,
+		Filter:        filter,
+		Ranges:        timewindow.Ranges,
+		Granularities: timewindow.Granularities,
+		Auto:          r.URL.Query().Get("granularity") == "",
+		From:          win.Start.In(win.Location).Format("2006-01-02"),
+		To:            win.End.Add(-time.Nanosecond).In(win.Location).Format("2006-01-02"),
+	}
+	if windowErr != nil {
+		data.WindowError = windowErr.Error()
 	}
 
 	if err := h.template.ExecuteTemplate(w, "base", data); err != nil {
 		log.Printf("Error rendering trends: %v", err)
 	}
 }
+
+// ListJSON returns the trend summary and velocity series of a window.
+func (h *TrendHandler) ListJSON(w http.ResponseWriter, r *http.Request) {
+	win, err := timewindow.Parse(r.URL.Query(), defaultTrendRange, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := repositories.ParseTrendFilter(r.URL.Query())
+
+	trends, err := h.Repo.GetTrends(r.Context(), win, filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch trends", http.StatusInternalServerError)
+		return
+	}
+	velocity, err := h.Repo.GetVelocityData(r.Context(), win, filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch trends", http.StatusInternalServerError)
+		return
+	}
+
+	type stat struct {
+		Label  string  `json:"label"`
+		Value  int     `json:"value"`
+		Change float64 `json:"change_pct"`
+	}
+	stats := make([]stat, len(trends))
+	for i, t := range trends {
+		stats[i] = stat{t.Label, t.Value, t.Trend}
+	}
+	if velocity == nil {
+		velocity = []repositories.TrendPoint{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"range":       win.Range,
+		"start":       win.Start,
+		"end":         win.End,
+		"granularity": win.Granularity,
+		"timezone":    win.Location.String(),
+		"filter":      filter,
+		"stats":       stats,
+		"points":      velocity,
+	})
+}
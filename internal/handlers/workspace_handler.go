@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	customMiddleware "github.com/Abhaythakor/SigMap/internal/middleware"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/go-chi/chi/v5"
+)
+
+type WorkspaceHandler struct {
+	WorkspaceSvc *services.WorkspaceService
+	templates    map[string]*template.Template
+}
+
+func NewWorkspaceHandler(workspaceSvc *services.WorkspaceService) *WorkspaceHandler {
+	h := &WorkspaceHandler{WorkspaceSvc: workspaceSvc, templates: make(map[string]*template.Template)}
+	h.parseTemplates()
+	return h
+}
+
+func (h *WorkspaceHandler) parseTemplates() {
+	files := []string{
+		filepath.Join("templates", "layouts", "base.html"),
+		filepath.Join("templates", "partials", "sidebar.html"),
+		filepath.Join("templates", "partials", "header.html"),
+		filepath.Join("templates", "partials", "settings_nav.html"),
+		filepath.Join("templates", "settings_workspaces.html"),
+	}
+	h.templates["index"] = template.Must(template.New("base").ParseFiles(files...))
+	h.templates["switcher"] = template.Must(template.ParseFiles(filepath.Join("templates", "partials", "workspace_switcher.html")))
+}
+
+// Switcher renders the workspace picker for the sidebar.
+func (h *WorkspaceHandler) Switcher(w http.ResponseWriter, r *http.Request) {
+	list, err := h.WorkspaceSvc.Available(r.Context(), customMiddleware.UserFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to load workspaces", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Workspaces []models.Workspace
+		CurrentID  int
+	}{
+		Workspaces: list,
+		CurrentID:  workspace.FromContext(r.Context()),
+	}
+
+	if err := h.templates["switcher"].ExecuteTemplate(w, "workspace_switcher", data); err != nil {
+		log.Printf("Error rendering workspace switcher: %v", err)
+	}
+}
+
+// Switch selects the active workspace for the browser.
+func (h *WorkspaceHandler) Switch(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(r.FormValue("workspace_id"))
+	if err != nil {
+		http.Error(w, "Invalid workspace", http.StatusBadRequest)
+		return
+	}
+
+	resolved, _, err := h.WorkspaceSvc.Resolve(r.Context(), customMiddleware.UserFromContext(r.Context()), id)
+	if err != nil || resolved != id {
+		http.Error(w, "Forbidden: no access to this workspace", http.StatusForbidden)
+		return
+	}
+
+	setWorkspaceCookie(w, id)
+	h.reload(w, r)
+}
+
+func (h *WorkspaceHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := customMiddleware.UserFromContext(ctx)
+
+	current, err := h.WorkspaceSvc.Repo.Get(ctx, workspace.FromContext(ctx))
+	if err != nil {
+		http.Error(w, "Failed to load workspace", http.StatusInternalServerError)
+		return
+	}
+	members, err := h.WorkspaceSvc.Repo.ListMembers(ctx, current.ID)
+	if err != nil {
+		http.Error(w, "Failed to load members", http.StatusInternalServerError)
+		return
+	}
+	list, err := h.WorkspaceSvc.Available(ctx, user)
+	if err != nil {
+		http.Error(w, "Failed to load workspaces", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		CurrentPage string
+		SettingsTab string
+		Current     models.Workspace
+		Members     []models.WorkspaceMember
+		Workspaces  []models.Workspace
+		CanCreate   bool
+		CanManage   bool
+	}{
+		CurrentPage: "settings",
+		SettingsTab: "workspaces",
+		Current:     current,
+		Members:     members,
+		Workspaces:  list,
+		CanCreate:   user == nil || user.IsGlobalAdmin(),
+		CanManage:   user == nil || user.HasRole(models.RoleAdmin),
+	}
+
+	if err := h.templates["index"].ExecuteTemplate(w, "base", data); err != nil {
+		log.Printf("Error rendering workspaces: %v", err)
+	}
+}
+
+// Create adds a workspace and switches to it. Only global admins may create workspaces.
+func (h *WorkspaceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := customMiddleware.UserFromContext(r.Context())
+	if user != nil && !user.IsGlobalAdmin() {
+		http.Error(w, "Forbidden: admin role required", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	ws, err := h.WorkspaceSvc.Create(r.Context(), r.FormValue("name"), user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	setWorkspaceCookie(w, ws.ID)
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetMember adds a user to the current workspace or changes their role.
+func (h *WorkspaceHandler) SetMember(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WorkspaceSvc.SetMember(r.Context(), r.FormValue("email"), r.FormValue("role")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveMember removes a user from the current workspace.
+func (h *WorkspaceHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WorkspaceSvc.RemoveMember(r.Context(), userID); err != nil {
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// reload refreshes settings pages in place; elsewhere the current page may
+// belong to the previous workspace, so go back to the dashboard.
+func (h *WorkspaceHandler) reload(w http.ResponseWriter, r *http.Request) {
+	if u, err := url.Parse(r.Header.Get("HX-Current-URL")); err == nil && strings.HasPrefix(u.Path, "/settings/") {
+		w.Header().Set("HX-Refresh", "true")
+	} else {
+		w.Header().Set("HX-Redirect", "/")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func setWorkspaceCookie(w http.ResponseWriter, id int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     customMiddleware.WorkspaceCookie,
+		Value:    strconv.Itoa(id),
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
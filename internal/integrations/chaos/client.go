@@ -17,6 +17,11 @@ func NewClient(apiKey string) *Client {
 	return &Client{APIKey: apiKey}
 }
 
+// Name identifies the source on discovered domains.
+func (c *Client) Name() string {
+	return "chaos"
+}
+
 // FetchSubdomains retrieves subdomains for a given domain.
 func (c *Client) FetchSubdomains(ctx context.Context, domain string) ([]string, error) {
 	log.Printf("Chaos: Fetching subdomains for %s", domain)
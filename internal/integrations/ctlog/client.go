@@ -0,0 +1,167 @@
+package ctlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public crt.sh service. Mirrors serving the same JSON
+// output can be used instead.
+const DefaultBaseURL = "https://crt.sh"
+
+// Client queries a crt.sh-compatible Certificate Transparency search for
+// names in certificates issued under a domain.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		// crt.sh is slow for large domains.
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Name identifies the source on discovered domains.
+func (c *Client) Name() string {
+	return "ct"
+}
+
+// Entry is one certificate in crt.sh's JSON output. NameValue holds the
+// certificate's names separated by newlines.
+type Entry struct {
+	ID             int64  `json:"id"`
+	IssuerName     string `json:"issuer_name"`
+	CommonName     string `json:"common_name"`
+	NameValue      string `json:"name_value"`
+	NotBefore      string `json:"not_before"`
+	NotAfter       string `json:"not_after"`
+	EntryTimestamp string `json:"entry_timestamp"`
+}
+
+// Names returns the entry's hostnames, lowercased and without wildcard labels.
+func (e Entry) Names() []string {
+	var names []string
+	for _, n := range strings.Split(e.CommonName+"\n"+e.NameValue, "\n") {
+		if n = Normalize(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// Normalize lowercases a certificate name and strips a leading wildcard and
+// trailing dot. Names that aren't hostnames (emails, spaces) return "".
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "*."), ".")
+	if name == "" || strings.ContainsAny(name, "@ */:") {
+		return ""
+	}
+	return name
+}
+
+// Under reports whether name is domain or one of its subdomains.
+func Under(name, domain string) bool {
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+// FetchSubdomains returns the unique names at or below domain found in CT.
+func (c *Client) FetchSubdomains(ctx context.Context, domain string) ([]string, error) {
+	u := fmt.Sprintf("%s/?q=%s&output=json", c.BaseURL, url.QueryEscape("%."+domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ct search returned %s", resp.Status)
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	err = Decode(resp.Body, func(name string) error {
+		if !seen[name] && Under(name, domain) {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// Decode streams the hostnames in a CT result or offline dump to fn. It
+// accepts crt.sh's JSON array, newline-delimited JSON entries, or plain text
+// with one name per line. Names are normalized but not deduplicated.
+func Decode(r io.Reader, fn func(name string) error) error {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if first != '[' && first != '{' {
+		scanner := bufio.NewScanner(br)
+		for scanner.Scan() {
+			if name := Normalize(scanner.Text()); name != "" {
+				if err := fn(name); err != nil {
+					return err
+				}
+			}
+		}
+		return scanner.Err()
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		for _, name := range e.Names() {
+			if err := fn(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		br.ReadByte()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -22,7 +23,7 @@ func (w *AlertWorker) Run(ctx context.Context) error {
 	log.Println("Starting alert worker pass...")
 
 	query := `
-		SELECT d.name, t.name, COALESCE(vp.risk_level, t.risk_level) as risk
+		SELECT d.workspace_id, d.name, t.name, COALESCE(vp.risk_level, t.risk_level) as risk
 		FROM detections det
 		JOIN domains d ON det.domain_id = d.id
 		JOIN technologies t ON det.technology_id = t.id
@@ -44,10 +45,11 @@ func (w *AlertWorker) Run(ctx context.Context) error {
 	defer rows.Close()
 
 	for rows.Next() {
+		var wsID int
 		var domain, tech, risk string
-		if err := rows.Scan(&domain, &tech, &risk); err == nil {
+		if err := rows.Scan(&wsID, &domain, &tech, &risk); err == nil {
 			log.Printf("ALERT TRIGGERED: %s detected on %s (Risk: %s)", tech, domain, risk)
-			w.AlertService.DispatchAlert(ctx, domain, tech, risk)
+			w.AlertService.DispatchAlert(workspace.WithID(ctx, wsID), domain, tech, risk)
 		}
 	}
 
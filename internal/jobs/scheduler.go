@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/services"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+// defaultSchedulerTick is how often due watchlist targets are checked.
+const defaultSchedulerTick = 30 * time.Second
+
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs recurring watchlist scans and SigMap's periodic
+// maintenance jobs from a single loop.
+type Scheduler struct {
+	Repo      *repositories.ScheduleRepository
+	Schedules *services.ScheduleService
+	Scans     *services.ScanService
+	Audit     *services.AuditService
+	Tick      time.Duration
+	MaxRuns   int // global cap on concurrently running targets
+
+	mu      sync.Mutex
+	tasks   []*periodicTask
+	running map[int]int // running targets per schedule
+	active  int
+	wg      sync.WaitGroup
+}
+
+func NewScheduler(repo *repositories.ScheduleRepository, schedules *services.ScheduleService, scans *services.ScanService, auditSvc *services.AuditService) *Scheduler {
+	return &Scheduler{
+		Repo:      repo,
+		Schedules: schedules,
+		Scans:     scans,
+		Audit:     auditSvc,
+		Tick:      defaultSchedulerTick,
+		MaxRuns:   8,
+		running:   make(map[int]int),
+	}
+}
+
+// Every registers a maintenance job that first runs one interval from now.
+func (s *Scheduler) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, &periodicTask{name: name, interval: interval, fn: fn, next: time.Now().Add(interval)})
+}
+
+// Run blocks until ctx is cancelled, then waits for running scans.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.Repo.ResetStale(ctx); err != nil {
+		log.Printf("Scheduler: failed to reset interrupted runs: %v", err)
+	}
+	log.Printf("Scheduler: started (tick %s, max %d concurrent targets)", s.Tick, s.MaxRuns)
+
+	ticker := time.NewTicker(s.Tick)
+	defer ticker.Stop()
+
+	for {
+		s.runTasks(ctx)
+		s.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runTasks(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if t.running || now.Before(t.next) {
+			continue
+		}
+		t.running = true
+		t.next = now.Add(t.interval)
+
+		s.wg.Add(1)
+		go func(t *periodicTask) {
+			defer s.wg.Done()
+			if err := t.fn(ctx); err != nil {
+				log.Printf("Scheduler: %s job error: %v", t.name, err)
+			}
+			s.mu.Lock()
+			t.running = false
+			s.mu.Unlock()
+		}(t)
+	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context) {
+	now := time.Now()
+	due, err := s.Repo.Due(ctx, now, 100)
+	if err != nil {
+		log.Printf("Scheduler: failed to load due targets: %v", err)
+		return
+	}
+
+	for _, d := range due {
+		if d.Target.LastStatus == "running" || !s.acquire(d.Schedule) {
+			continue
+		}
+
+		next, err := s.Schedules.NextRun(d.Schedule, now)
+		if err != nil {
+			log.Printf("Scheduler: schedule %q: %v", d.Schedule.Name, err)
+			next = now.Add(24 * time.Hour)
+		}
+		claimed, err := s.Repo.Claim(ctx, d.Target.ID, now, next)
+		if err != nil || !claimed {
+			s.release(d.Schedule.ID)
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.runTarget(ctx, d)
+	}
+}
+
+// acquire reserves a run slot under both the schedule's and the global cap.
+func (s *Scheduler) acquire(sch models.ScanSchedule) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active >= s.MaxRuns || s.running[sch.ID] >= sch.MaxConcurrency {
+		return false
+	}
+	s.running[sch.ID]++
+	s.active++
+	return true
+}
+
+func (s *Scheduler) release(scheduleID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[scheduleID]--
+	if s.running[scheduleID] <= 0 {
+		delete(s.running, scheduleID)
+	}
+	s.active--
+}
+
+func (s *Scheduler) runTarget(ctx context.Context, d repositories.DueTarget) {
+	defer s.wg.Done()
+	defer s.release(d.Schedule.ID)
+
+	ctx = workspace.WithID(ctx, d.Schedule.WorkspaceID)
+	log.Printf("Scheduler: %s running %s (%v)", d.Schedule.Name, d.Target.Target, d.Schedule.Stages)
+	started := time.Now()
+
+	status, errMsg := "ok", ""
+	if err := s.Scans.ScanTarget(ctx, d.Target.Target, d.Target.Kind, d.Schedule.Stages); err != nil {
+		status, errMsg = "failed", err.Error()
+		log.Printf("Scheduler: %s failed for %s: %v", d.Schedule.Name, d.Target.Target, err)
+	}
+
+	bg := context.WithoutCancel(ctx)
+	if err := s.Repo.Finish(bg, d.Target.ID, status, errMsg); err != nil {
+		log.Printf("Scheduler: failed to record run of %s: %v", d.Target.Target, err)
+	}
+	s.Audit.Record(bg, "schedule.run", "schedule_target", d.Target.ID, d.Target.Target, nil, map[string]interface{}{
+		"schedule": d.Schedule.Name,
+		"stages":   d.Schedule.Stages,
+		"status":   status,
+		"error":    errMsg,
+		"duration": time.Since(started).Round(time.Second).String(),
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// Audit opens an audit event for every request. Mutating requests are always
+// persisted; reads only when a handler described them (logins, exports).
+type Audit struct {
+	Persist func(ctx context.Context, e *models.AuditEvent) error
+}
+
+func (a *Audit) Record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		e := &models.AuditEvent{
+			WorkspaceID: workspace.FromContext(ctx),
+			ActorType:   models.ActorAnonymous,
+			IP:          ClientIP(r),
+			Method:      r.Method,
+			Path:        r.URL.Path,
+		}
+		if token := TokenFromContext(ctx); token != nil {
+			e.ActorType = models.ActorToken
+			e.ActorID = strconv.Itoa(token.ID)
+			e.ActorName = token.Name
+			if token.Owner != "" {
+				e.ActorName += " (" + token.Owner + ")"
+			}
+		} else if user := UserFromContext(ctx); user != nil {
+			e.ActorType = models.ActorUser
+			e.ActorID = strconv.Itoa(user.ID)
+			e.ActorName = user.DisplayName()
+		}
+
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		actx, rec := audit.Begin(ctx, e)
+		next.ServeHTTP(ww, r.WithContext(actx))
+		rec.Close()
+
+		mutating := r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+		if e.Action == "" {
+			if !mutating {
+				return
+			}
+			e.Action = "http." + strings.ToLower(r.Method)
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				e.TargetName = rctx.RoutePattern()
+			}
+		}
+		e.Status = ww.Status()
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+
+		if err := a.Persist(context.WithoutCancel(ctx), e); err != nil {
+			log.Printf("Audit: failed to record %s by %s: %v", e.Action, e.ActorName, err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// SessionCookie is the name of the browser session cookie.
+const SessionCookie = "sigmap_session"
+
+type contextKey string
+
+const (
+	userContextKey  contextKey = "user"
+	tokenContextKey contextKey = "api_token"
+)
+
+// Auth resolves the caller's identity for each request. When Enabled is
+// false every request without a bearer token passes through unauthenticated.
+type Auth struct {
+	Enabled        bool
+	ResolveSession func(ctx context.Context, token string) (*models.User, error)
+	ResolveToken   func(ctx context.Context, token, ip string) (*models.APIToken, *models.User, error)
+}
+
+// UserFromContext returns the authenticated user, or nil.
+func UserFromContext(ctx context.Context) *models.User {
+	u, _ := ctx.Value(userContextKey).(*models.User)
+	return u
+}
+
+// WithUser returns a context carrying the given user.
+func WithUser(ctx context.Context, u *models.User) context.Context {
+	return context.WithValue(ctx, userContextKey, u)
+}
+
+// TokenFromContext returns the API token used for the request, or nil.
+func TokenFromContext(ctx context.Context) *models.APIToken {
+	t, _ := ctx.Value(tokenContextKey).(*models.APIToken)
+	return t
+}
+
+// Authenticate requires a valid session or bearer token on every route
+// except the public ones. Bearer tokens need the read scope for reads.
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if raw, ok := bearerToken(r); ok && a.ResolveToken != nil {
+			token, owner, err := a.ResolveToken(r.Context(), raw, ClientIP(r))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "Invalid API token", http.StatusUnauthorized)
+				return
+			}
+			if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !token.HasScope(models.ScopeRead) {
+				http.Error(w, "Forbidden: read scope required", http.StatusForbidden)
+				return
+			}
+			ctx := context.WithValue(r.Context(), tokenContextKey, token)
+			if owner != nil {
+				ctx = WithUser(ctx, owner)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if !a.Enabled || isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c, err := r.Cookie(SessionCookie); err == nil && c.Value != "" {
+			if user, err := a.ResolveSession(r.Context(), c.Value); err == nil {
+				next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+				return
+			}
+		}
+
+		unauthorized(w, r)
+	})
+}
+
+// RequireRole rejects users whose role ranks below the given one. These
+// routes are interactive only and are not reachable with API tokens.
+func (a *Auth) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if TokenFromContext(r.Context()) != nil {
+				http.Error(w, "Forbidden: route is not available to API tokens", http.StatusForbidden)
+				return
+			}
+			if !a.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			user := UserFromContext(r.Context())
+			if user == nil {
+				unauthorized(w, r)
+				return
+			}
+			if !user.HasRole(role) {
+				http.Error(w, "Forbidden: "+role+" role required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope guards routes usable by both API tokens and signed-in users:
+// tokens need the scope, users need the role.
+func (a *Auth) RequireScope(scope, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := TokenFromContext(r.Context()); token != nil {
+				if !token.HasScope(scope) {
+					http.Error(w, "Forbidden: "+scope+" scope required", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !a.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			user := UserFromContext(r.Context())
+			if user == nil {
+				unauthorized(w, r)
+				return
+			}
+			if !user.HasRole(role) {
+				http.Error(w, "Forbidden: "+role+" role required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:]), true
+	}
+	return "", false
+}
+
+// ClientIP returns the caller address; RemoteAddr is already rewritten by RealIP.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func isPublicPath(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/auth/") || strings.HasPrefix(path, "/static/")
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	loginURL := "/auth/login?next=" + url.QueryEscape(r.URL.RequestURI())
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", loginURL)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, loginURL, http.StatusSeeOther)
+		return
+	}
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
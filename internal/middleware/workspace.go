@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+// WorkspaceCookie remembers the workspace selected in the browser.
+const WorkspaceCookie = "sigmap_workspace"
+
+// Workspaces selects the active workspace for each request.
+type Workspaces struct {
+	// Resolve validates the requested workspace (0 = none requested) for the
+	// user, which is nil when auth is disabled or for service tokens. It
+	// returns the workspace to use and the user's effective role in it.
+	Resolve func(ctx context.Context, user *models.User, requested int) (int, string, error)
+}
+
+// Scope puts the active workspace into the request context. API tokens are
+// bound to the workspace they were created in; browsers pick one with the
+// workspace cookie. The user's role is replaced by their workspace role.
+func (ws *Workspaces) Scope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		user := UserFromContext(ctx)
+		token := TokenFromContext(ctx)
+
+		requested := 0
+		if token != nil {
+			requested = token.WorkspaceID
+		} else if c, err := r.Cookie(WorkspaceCookie); err == nil {
+			requested, _ = strconv.Atoi(c.Value)
+		}
+
+		id, role, err := ws.Resolve(ctx, user, requested)
+		if err != nil || (token != nil && id != token.WorkspaceID) {
+			http.Error(w, "Forbidden: no access to this workspace", http.StatusForbidden)
+			return
+		}
+		ctx = workspace.WithID(ctx, id)
+
+		if user != nil && role != "" {
+			scoped := *user
+			scoped.GlobalRole = user.Role
+			scoped.Role = role
+			ctx = WithUser(ctx, &scoped)
+
+			if token != nil {
+				capped := *token
+				capped.Scopes = nil
+				for _, sc := range token.Scopes {
+					if scoped.HasRole(models.ScopeRole(sc)) {
+						capped.Scopes = append(capped.Scopes, sc)
+					}
+				}
+				ctx = context.WithValue(ctx, tokenContextKey, &capped)
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
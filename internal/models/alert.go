@@ -9,12 +9,21 @@ type AlertChannel struct {
 	URL       string    `json:"url"`
 	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// RouteTags and MinCriticality limit the channel to alerts on hosts
+	// carrying all of the tags ("key" or "key=value") and at least the
+	// criticality. Empty means every host.
+	RouteTags      []string `json:"route_tags"`
+	MinCriticality string   `json:"min_criticality,omitempty"`
 }
 
 type AlertPayload struct {
-	Domain    string `json:"domain"`
-	Tech      string `json:"technology"`
-	Risk      string `json:"risk_level"`
-	Message   string `json:"message"`
-	Timestamp string `json:"timestamp"`
+	Domain      string   `json:"domain"`
+	Tech        string   `json:"technology"`
+	Risk        string   `json:"risk_level"`
+	Owner       string   `json:"owner,omitempty"`
+	Criticality string   `json:"criticality,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Message     string   `json:"message"`
+	Timestamp   string   `json:"timestamp"`
 }
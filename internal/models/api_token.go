@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// API token scopes.
+const (
+	ScopeRead    = "read"
+	ScopeScan    = "scan"
+	ScopeIngest  = "ingest"
+	ScopeQueries = "queries"
+	ScopeAdmin   = "admin"
+)
+
+// AllScopes lists the scopes in display order.
+var AllScopes = []string{ScopeRead, ScopeScan, ScopeIngest, ScopeQueries, ScopeAdmin}
+
+// Token kinds.
+const (
+	TokenPersonal = "personal"
+	TokenService  = "service"
+)
+
+// APIToken is a non-interactive credential. The secret itself is never stored.
+type APIToken struct {
+	ID          int        `json:"id"`
+	WorkspaceID int        `json:"workspace_id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	Prefix      string     `json:"prefix"`
+	UserID      *int       `json:"user_id,omitempty"`
+	Owner       string     `json:"owner,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token grants a scope. The admin scope implies all others.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the token is neither revoked nor expired.
+func (t *APIToken) IsActive() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || t.ExpiresAt.After(time.Now()))
+}
+
+// ScopeRole returns the minimum user role allowed to hold a scope.
+func ScopeRole(scope string) string {
+	switch scope {
+	case ScopeAdmin:
+		return RoleAdmin
+	case ScopeScan, ScopeIngest, ScopeQueries:
+		return RoleAnalyst
+	default:
+		return RoleViewer
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// RootDomain is a registrable domain (public suffix plus one label) that
+// hosts roll up to.
+type RootDomain struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	PublicSuffix string    `json:"public_suffix"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IPAddress is an address seen in a workspace with its network metadata.
+type IPAddress struct {
+	ID            int       `json:"id"`
+	Address       string    `json:"address"`
+	Version       int       `json:"version"`
+	ASN           int       `json:"asn,omitempty"`
+	ASNOrg        string    `json:"asn_org,omitempty"`
+	CloudProvider string    `json:"cloud_provider,omitempty"`
+	Country       string    `json:"country,omitempty"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// Resolution links a host to an IP it resolved to.
+type Resolution struct {
+	DomainID   int       `json:"domain_id"`
+	DomainName string    `json:"domain"`
+	IPID       int       `json:"ip_id"`
+	Address    string    `json:"address"`
+	RecordType string    `json:"record_type"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Service is an open port on an IP and what answers on it.
+type Service struct {
+	ID        int       `json:"id"`
+	IPID      int       `json:"ip_id"`
+	Port      int       `json:"port"`
+	Protocol  string    `json:"protocol"`
+	Service   string    `json:"service,omitempty"`
+	Product   string    `json:"product,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actor types.
+const (
+	ActorUser      = "user"
+	ActorToken     = "token"
+	ActorSystem    = "system"
+	ActorAnonymous = "anonymous"
+)
+
+// AuditEvent is one entry of the append-only audit log. Before and After hold
+// only the fields that changed.
+type AuditEvent struct {
+	ID          int64           `json:"id"`
+	WorkspaceID int             `json:"workspace_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	ActorType   string          `json:"actor_type"`
+	ActorID     string          `json:"actor_id,omitempty"`
+	ActorName   string          `json:"actor_name,omitempty"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type,omitempty"`
+	TargetID    string          `json:"target_id,omitempty"`
+	TargetName  string          `json:"target_name,omitempty"`
+	IP          string          `json:"ip,omitempty"`
+	Method      string          `json:"method,omitempty"`
+	Path        string          `json:"path,omitempty"`
+	Status      int             `json:"status,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// CertExpiryWarning is how close to expiry a certificate counts as expiring soon.
+const CertExpiryWarning = 30 * 24 * time.Hour
+
+// Certificate is an X.509 certificate served by an endpoint, as a leaf or
+// part of its chain.
+type Certificate struct {
+	ID                 int       `json:"id"`
+	Fingerprint        string    `json:"fingerprint_sha256"`
+	Subject            string    `json:"subject"`
+	SubjectCN          string    `json:"subject_cn"`
+	Issuer             string    `json:"issuer"`
+	IssuerCN           string    `json:"issuer_cn"`
+	Serial             string    `json:"serial"`
+	SANs               []string  `json:"sans"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits,omitempty"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	SelfSigned         bool      `json:"self_signed"`
+	WeakReasons        []string  `json:"weak_reasons,omitempty"`
+	FirstSeen          time.Time `json:"first_seen"`
+	LastSeen           time.Time `json:"last_seen"`
+
+	// Hosts counts the endpoints currently serving the certificate (list views only).
+	Hosts int `json:"hosts,omitempty"`
+}
+
+// Expired reports whether the certificate is past its validity.
+func (c Certificate) Expired() bool {
+	return time.Now().After(c.NotAfter)
+}
+
+// ExpiringSoon reports whether the certificate expires within CertExpiryWarning.
+func (c Certificate) ExpiringSoon() bool {
+	return !c.Expired() && time.Until(c.NotAfter) < CertExpiryWarning
+}
+
+// DaysLeft is the number of whole days until expiry, negative once expired.
+func (c Certificate) DaysLeft() int {
+	return int(time.Until(c.NotAfter).Hours() / 24)
+}
+
+// Weak reports whether the certificate uses weak keys or signatures.
+func (c Certificate) Weak() bool {
+	return len(c.WeakReasons) > 0
+}
+
+// TLSEndpoint is the latest handshake with a host on one port.
+type TLSEndpoint struct {
+	ID          int       `json:"id"`
+	DomainID    int       `json:"domain_id"`
+	DomainName  string    `json:"domain"`
+	Port        int       `json:"port"`
+	Address     string    `json:"address,omitempty"`
+	Version     string    `json:"tls_version,omitempty"`
+	CipherSuite string    `json:"cipher_suite,omitempty"`
+	VerifyError string    `json:"verify_error,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+
+	// Chain is leaf first.
+	Chain []Certificate `json:"chain,omitempty"`
+}
+
+// Leaf returns the certificate the endpoint presented for itself.
+func (e TLSEndpoint) Leaf() *Certificate {
+	if len(e.Chain) == 0 {
+		return nil
+	}
+	return &e.Chain[0]
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Cloud providers, spelled the way IP metadata reports them.
+const (
+	CloudAWS   = "AWS"
+	CloudGCP   = "GCP"
+	CloudAzure = "Azure"
+)
+
+// Cloud resource types.
+const (
+	CloudDNSRecord    = "dns-record"
+	CloudInstance     = "instance"
+	CloudLoadBalancer = "load-balancer"
+)
+
+// CloudResource is an asset read from a cloud inventory export. Addresses
+// are IPs the account owns; Targets are what a DNS record points at.
+type CloudResource struct {
+	ID         int       `json:"id"`
+	Provider   string    `json:"provider"`
+	Account    string    `json:"account"`
+	Region     string    `json:"region,omitempty"`
+	Type       string    `json:"type"`
+	ResourceID string    `json:"resource_id"`
+	Name       string    `json:"name"`
+	RecordType string    `json:"record_type,omitempty"`
+	Hostnames  []string  `json:"hostnames"`
+	Addresses  []string  `json:"addresses"`
+	Targets    []string  `json:"targets"`
+	Source     string    `json:"source"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+
+	// Match is how the resource was tied to a host (domain views only).
+	Match string `json:"match,omitempty"`
+}
+
+// CloudAccount summarizes the imported resources of one account.
+type CloudAccount struct {
+	Provider      string    `json:"provider"`
+	Account       string    `json:"account"`
+	Records       int       `json:"dns_records"`
+	Instances     int       `json:"instances"`
+	LoadBalancers int       `json:"load_balancers"`
+	Linked        int       `json:"linked_domains"`
+	LastImported  time.Time `json:"last_imported"`
+}
+
+// UnownedTarget is an A or AAAA record pointing at an address that none of
+// the imported accounts own.
+type UnownedTarget struct {
+	Name       string `json:"name"`
+	RecordType string `json:"record_type"`
+	Address    string `json:"address"`
+	// Origin is the export the record came from, or "live" for DNS
+	// resolution of a tracked host.
+	Origin        string `json:"origin"`
+	Account       string `json:"account,omitempty"`
+	CloudProvider string `json:"cloud_provider,omitempty"`
+	DomainID      *int   `json:"domain_id,omitempty"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// Sources a host can be discovered through, recorded in domain_sources.
+// Subdomain sources record their own Name().
+const (
+	SourceTLSSAN     = "tls-san"
+	SourceCTDump     = "ct-dump"
+	SourcePassiveDNS = "passive-dns"
+)
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// DNSRecord is a record a host has published, now or in the past.
+type DNSRecord struct {
+	Type      string     `json:"type"`
+	Value     string     `json:"value"`
+	TTL       int        `json:"ttl,omitempty"`
+	FirstSeen time.Time  `json:"first_seen"`
+	LastSeen  time.Time  `json:"last_seen"`
+	RemovedAt *time.Time `json:"removed_at,omitempty"`
+}
+
+// DNSChange is one appearance ("added") or disappearance ("removed") of a record.
+type DNSChange struct {
+	Type       string    `json:"type"`
+	Value      string    `json:"value"`
+	Change     string    `json:"change"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// DNSStatus is the outcome of the latest resolution of a host.
+type DNSStatus struct {
+	Resolver        string    `json:"resolver"`
+	Rcode           string    `json:"rcode"`
+	CNAMEChain      []string  `json:"cname_chain"`
+	Dangling        bool      `json:"dangling"`
+	TakeoverTarget  string    `json:"takeover_target,omitempty"`
+	TakeoverService string    `json:"takeover_service,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// PassiveDNSRecord is a historical resolution imported from a passive DNS
+// export. IPID is set for A and AAAA records.
+type PassiveDNSRecord struct {
+	DomainID   int       `json:"domain_id"`
+	DomainName string    `json:"domain"`
+	Type       string    `json:"type"`
+	Value      string    `json:"value"`
+	IPID       *int      `json:"ip_id,omitempty"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Count      int64     `json:"count,omitempty"`
+	Source     string    `json:"source"`
+}
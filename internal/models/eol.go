@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EOLProduct is an endoflife.date product whose release cycles have been
+// imported.
+type EOLProduct struct {
+	Product      string    `json:"product"`
+	Source       string    `json:"source"`
+	CycleCount   int       `json:"cycle_count"`
+	Technologies []string  `json:"technologies"` // mapped to it
+	ImportedAt   time.Time `json:"imported_at"`
+}
+
+// EOLMapping says which product a technology is.
+type EOLMapping struct {
+	Technology string `json:"technology"`
+	Product    string `json:"product"`
+	Imported   bool   `json:"imported"` // the product's cycles are known
+}
+
+// EOLInfo is the support verdict on a detected version: Status is
+// supported, outdated or eol, or empty when unknown.
+type EOLInfo struct {
+	Status       string     `json:"status,omitempty"`
+	Cycle        string     `json:"cycle,omitempty"`
+	Date         *time.Time `json:"eol_date,omitempty"`
+	MajorsBehind int        `json:"majors_behind"`
+	MinorsBehind int        `json:"minors_behind"`
+}
+
+// Behind describes how far the version trails, e.g. "2 majors, 1 minor
+// behind"; empty when it is current.
+func (e EOLInfo) Behind() string {
+	var parts []string
+	if e.MajorsBehind > 0 {
+		parts = append(parts, countOf(e.MajorsBehind, "major"))
+	}
+	if e.MinorsBehind > 0 {
+		parts = append(parts, countOf(e.MinorsBehind, "minor"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, ", ") + " behind"
+}
+
+func countOf(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return strconv.Itoa(n) + " " + word + "s"
+}
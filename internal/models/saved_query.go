@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/search"
+)
+
+// Saved query targets: the list a query filters.
+const (
+	QueryTargetDomains      = "domains"
+	QueryTargetTechnologies = "technologies"
+)
+
+// SavedQuery is a named view of a list: a search query plus, for domains,
+// the other list filters. Domain views can be subscribed to by alert
+// channels, which hear about domains entering or leaving the result set.
+type SavedQuery struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Target      string     `json:"target"`
+	Query       string     `json:"query"`
+	Filters     string     `json:"filters,omitempty"` // URL-encoded list filters, e.g. "confidence=high&tag=env%3Dprod"
+	Pinned      bool       `json:"pinned"`
+	Channels    []int      `json:"channel_ids"` // subscribed alert channels
+	Members     int        `json:"members"`     // result size at the last evaluation
+	EvaluatedAt *time.Time `json:"evaluated_at,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Values returns the view's list parameters, query included.
+func (q SavedQuery) Values() url.Values {
+	v, _ := url.ParseQuery(q.Filters)
+	if v == nil {
+		v = url.Values{}
+	}
+	if q.Query != "" {
+		v.Set("q", q.Query)
+	}
+	return v
+}
+
+// URL is the list page showing the view.
+func (q SavedQuery) URL() string {
+	return "/" + q.Target + "?" + q.Values().Encode()
+}
+
+// SavedQueryChange is a change in the membership of a saved view. Entered
+// and Left hold at most the first names of each; the counts are complete.
+type SavedQueryChange struct {
+	ID           int       `json:"id"`
+	SavedQueryID int       `json:"saved_query_id"`
+	Entered      []string  `json:"entered"`
+	Left         []string  `json:"left"`
+	EnteredCount int       `json:"entered_count"`
+	LeftCount    int       `json:"left_count"`
+	ChangedAt    time.Time `json:"changed_at"`
+}
+
+// SavedQueryAlert is the webhook payload sent to subscribers of a view.
+type SavedQueryAlert struct {
+	Query        string   `json:"query"`
+	View         string   `json:"view"`
+	URL          string   `json:"url"`
+	Entered      []string `json:"entered"`
+	Left         []string `json:"left"`
+	EnteredCount int      `json:"entered_count"`
+	LeftCount    int      `json:"left_count"`
+	Message      string   `json:"message"`
+	Timestamp    string   `json:"timestamp"`
+}
+
+// QueryFields returns the search fields of a saved query target.
+func QueryFields(target string) ([]search.Field, error) {
+	switch target {
+	case QueryTargetDomains:
+		return search.DomainFields, nil
+	case QueryTargetTechnologies:
+		return search.TechnologyFields, nil
+	}
+	return nil, fmt.Errorf("unknown query target %q", target)
+}
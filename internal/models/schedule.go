@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Scan stages a schedule can run, in execution order.
+const (
+	StageDiscovery = "discovery"
+	StageInfra     = "infra"
+	StageTLS       = "tls"
+	StageHTTPX     = "httpx"
+	StageNuclei    = "nuclei"
+)
+
+// AllStages lists the scan stages in execution order.
+var AllStages = []string{StageDiscovery, StageInfra, StageTLS, StageHTTPX, StageNuclei}
+
+// Schedule target kinds: a single host, or a root domain together with every
+// known subdomain.
+const (
+	TargetDomain = "domain"
+	TargetRoot   = "root"
+)
+
+// ScanSchedule is a watchlist scanned on a cron schedule.
+type ScanSchedule struct {
+	ID             int        `json:"id"`
+	WorkspaceID    int        `json:"workspace_id"`
+	Name           string     `json:"name"`
+	CronExpr       string     `json:"cron"`
+	Timezone       string     `json:"timezone"`
+	Stages         []string   `json:"stages"`
+	JitterSeconds  int        `json:"jitter_seconds"`
+	MaxConcurrency int        `json:"max_concurrency"`
+	IsActive       bool       `json:"is_active"`
+	CreatedBy      string     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+
+	Targets []ScheduleTarget `json:"targets,omitempty"`
+}
+
+// HasStage reports whether the schedule runs a stage.
+func (s *ScanSchedule) HasStage(stage string) bool {
+	for _, st := range s.Stages {
+		if st == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleTarget is one watched domain or root domain with its run state.
+type ScheduleTarget struct {
+	ID             int        `json:"id"`
+	ScheduleID     int        `json:"schedule_id"`
+	Target         string     `json:"target"`
+	Kind           string     `json:"kind"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
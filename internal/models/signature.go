@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// SignatureVersion is one distinct set of synced Wappalyzer signatures.
+// Added, Removed and Changed compare it with the version that was active
+// when it was first synced.
+type SignatureVersion struct {
+	ID               int        `json:"id"`
+	Source           string     `json:"source"`
+	SHA256           string     `json:"sha256"`
+	FingerprintsETag string     `json:"-"`
+	CategoriesETag   string     `json:"-"`
+	TechnologyCount  int        `json:"technology_count"`
+	CategoryCount    int        `json:"category_count"`
+	Added            int        `json:"added"`
+	Removed          int        `json:"removed"`
+	Changed          int        `json:"changed"`
+	Active           bool       `json:"active"`
+	Pinned           bool       `json:"pinned"`
+	SyncedAt         time.Time  `json:"synced_at"`
+	CheckedAt        time.Time  `json:"checked_at"`
+	AppliedAt        *time.Time `json:"applied_at,omitempty"`
+}
+
+// ShortHash is the hash prefix shown in the UI.
+func (v SignatureVersion) ShortHash() string {
+	if len(v.SHA256) > 12 {
+		return v.SHA256[:12]
+	}
+	return v.SHA256
+}
+
+// SignatureChange is a technology whose signature differs between versions.
+type SignatureChange struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// SignatureDiff lists the technologies added, removed or changed going
+// from one version to another.
+type SignatureDiff struct {
+	From    SignatureVersion  `json:"from"`
+	To      SignatureVersion  `json:"to"`
+	Added   []string          `json:"added"`
+	Removed []string          `json:"removed"`
+	Changed []SignatureChange `json:"changed"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CriticalityLevels are the criticalities a domain can have, lowest first.
+var CriticalityLevels = []string{"Low", "Medium", "High", "Critical"}
+
+// CriticalityRank orders criticalities from 1 (Low) to 4 (Critical); 0 means
+// unset or unknown.
+func CriticalityRank(c string) int {
+	for i, level := range CriticalityLevels {
+		if strings.EqualFold(level, c) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// NormalizeCriticality returns the canonical spelling of a criticality, or an
+// error for an unknown one. An empty string clears it.
+func NormalizeCriticality(c string) (string, error) {
+	c = strings.TrimSpace(c)
+	if c == "" {
+		return "", nil
+	}
+	if rank := CriticalityRank(c); rank > 0 {
+		return CriticalityLevels[rank-1], nil
+	}
+	return "", fmt.Errorf("unknown criticality %q", c)
+}
+
+// Tag is a key/value label on a domain. Value may be empty.
+type Tag struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	RuleID *int   `json:"rule_id,omitempty"` // set when applied by a tag rule
+}
+
+// String renders the tag as "key=value", or "key" without a value.
+func (t Tag) String() string {
+	if t.Value == "" {
+		return t.Key
+	}
+	return t.Key + "=" + t.Value
+}
+
+// ParseTag reads "key=value" or "key". Keys are lower case letters, digits,
+// '-', '_', '.' and ':'.
+func ParseTag(s string) (Tag, error) {
+	key, value, _ := strings.Cut(strings.TrimSpace(s), "=")
+	t := Tag{Key: strings.ToLower(strings.TrimSpace(key)), Value: strings.TrimSpace(value)}
+	if t.Key == "" || len(t.Key) > 100 {
+		return t, fmt.Errorf("tag %q: key must be 1-100 characters", s)
+	}
+	for _, r := range t.Key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return t, fmt.Errorf("tag %q: key may only contain letters, digits, '-', '_', '.' and ':'", s)
+		}
+	}
+	if len(t.Value) > 255 {
+		return t, fmt.Errorf("tag %q: value is longer than 255 characters", s)
+	}
+	return t, nil
+}
+
+// ParseTags reads comma, space or newline separated tags.
+func ParseTags(raw string) ([]Tag, error) {
+	var tags []Tag
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t' }) {
+		t, err := ParseTag(item)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// TagRule applies a tag to every host matching Pattern: a host name, or
+// "*.example.com" for example.com and all hosts below it.
+type TagRule struct {
+	ID        int       `json:"id"`
+	Pattern   string    `json:"pattern"`
+	Tag       Tag       `json:"tag"`
+	Matches   int       `json:"matches"` // hosts currently tagged by the rule
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeTagPattern validates a rule pattern and returns it in lower case
+// without a trailing dot.
+func NormalizeTagPattern(p string) (string, error) {
+	p = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(p), "."))
+	name := strings.TrimPrefix(p, "*.")
+	if name == "" || strings.ContainsAny(name, "* /@") || !strings.Contains(name, ".") {
+		return "", fmt.Errorf("pattern %q must be a host name or *.domain", p)
+	}
+	return p, nil
+}
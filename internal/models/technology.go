@@ -12,13 +12,30 @@ type Category struct {
 
 // Technology represents a Wappalyzer technology signature.
 type Technology struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Website     string    `json:"website" db:"website"`
-	Icon        string    `json:"icon" db:"icon"`
-	RiskLevel   string    `json:"risk_level" db:"risk_level"`
-	Cats        []int     `json:"cats,omitempty"` // Matches Wappalyzer 'cats' field
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int                  `json:"id" db:"id"`
+	Name        string               `json:"name" db:"name"`
+	Description string               `json:"description" db:"description"`
+	Website     string               `json:"website" db:"website"`
+	Icon        string               `json:"icon" db:"icon"`
+	RiskLevel   string               `json:"risk_level" db:"risk_level"`
+	Cats        []int                `json:"cats,omitempty"` // Matches Wappalyzer 'cats' field
+	Relations   []TechnologyRelation `json:"relations,omitempty"`
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// Relation kinds, as named in the Wappalyzer schema.
+const (
+	RelationImplies  = "implies"
+	RelationRequires = "requires"
+	RelationExcludes = "excludes"
+)
+
+// TechnologyRelation links a technology to another that it implies,
+// requires or excludes.
+type TechnologyRelation struct {
+	Kind       string `json:"kind"`
+	RelatedID  int    `json:"related_id"`
+	Related    string `json:"related"`
+	Confidence int    `json:"confidence"` // scales detections inferred through an implies
 }
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Roles, ordered from least to most privileged.
+const (
+	RoleViewer  = "viewer"
+	RoleAnalyst = "analyst"
+	RoleAdmin   = "admin"
+)
+
+// User represents an account provisioned from the identity provider.
+type User struct {
+	ID          int        `json:"id"`
+	Issuer      string     `json:"issuer"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	Role        string     `json:"role"`
+	GlobalRole  string     `json:"-"`
+	IsActive    bool       `json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// DisplayName returns the best human-readable identifier for the user.
+func (u *User) DisplayName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if u.Email != "" {
+		return u.Email
+	}
+	return u.Subject
+}
+
+// HasRole reports whether the user's role is at least the given role.
+func (u *User) HasRole(role string) bool {
+	return RoleRank(u.Role) >= RoleRank(role)
+}
+
+// IsGlobalAdmin reports whether the user is an instance-wide admin. Once a
+// workspace is selected Role holds the workspace role and GlobalRole keeps
+// the role granted by the identity provider.
+func (u *User) IsGlobalAdmin() bool {
+	if u.GlobalRole != "" {
+		return u.GlobalRole == RoleAdmin
+	}
+	return u.Role == RoleAdmin
+}
+
+// RoleRank returns the privilege rank of a role; unknown roles rank lowest.
+func RoleRank(role string) int {
+	switch role {
+	case RoleAdmin:
+		return 3
+	case RoleAnalyst:
+		return 2
+	case RoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
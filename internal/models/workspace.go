@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Workspace is an isolated tenant holding its own domains, detections,
+// notes, bookmarks and alert channels.
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceMember is a user's membership and role within a workspace.
+type WorkspaceMember struct {
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
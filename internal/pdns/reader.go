@@ -0,0 +1,226 @@
+// Package pdns reads passive DNS exports: JSON lines in the common output
+// format used by most passive DNS providers, or CSV with a header row.
+package pdns
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is one observed resolution of Name to Data over a time window.
+type Record struct {
+	Name      string
+	Type      string
+	Data      []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int64
+}
+
+// LineError reports a record that could not be parsed. Reading can continue
+// past it.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// Reader reads records from an export, detecting the format from the first
+// byte: '{' for JSON lines, anything else for CSV.
+type Reader struct {
+	lines   *bufio.Scanner
+	csv     *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return &Reader{lines: bufio.NewScanner(br)}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !strings.ContainsAny(string(b), " \t\r\n") {
+			break
+		}
+		br.ReadByte()
+	}
+
+	if b, _ := br.Peek(1); b[0] == '{' {
+		lines := bufio.NewScanner(br)
+		lines.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &Reader{lines: lines}, nil
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"rrname", "rrtype", "rdata"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header has no %q column", required)
+		}
+	}
+	return &Reader{csv: cr, columns: columns, line: 1}, nil
+}
+
+// Read returns the next record, io.EOF at the end of the export, or a
+// *LineError for a malformed record.
+func (r *Reader) Read() (Record, error) {
+	if r.csv != nil {
+		return r.readCSV()
+	}
+	for r.lines.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.lines.Text())
+		if line == "" {
+			continue
+		}
+		rec, err := parseJSON([]byte(line))
+		if err != nil {
+			return rec, &LineError{Line: r.line, Err: err}
+		}
+		return rec, nil
+	}
+	if err := r.lines.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+type jsonRecord struct {
+	RRName        string          `json:"rrname"`
+	RRType        string          `json:"rrtype"`
+	RData         json.RawMessage `json:"rdata"`
+	TimeFirst     json.RawMessage `json:"time_first"`
+	TimeLast      json.RawMessage `json:"time_last"`
+	ZoneTimeFirst json.RawMessage `json:"zone_time_first"`
+	ZoneTimeLast  json.RawMessage `json:"zone_time_last"`
+	Count         int64           `json:"count"`
+}
+
+func parseJSON(line []byte) (Record, error) {
+	var jr jsonRecord
+	if err := json.Unmarshal(line, &jr); err != nil {
+		return Record{}, err
+	}
+
+	var data []string
+	if err := json.Unmarshal(jr.RData, &data); err != nil {
+		var single string
+		if err := json.Unmarshal(jr.RData, &single); err != nil {
+			return Record{}, fmt.Errorf("rdata must be a string or list of strings")
+		}
+		data = []string{single}
+	}
+
+	first, last := jr.TimeFirst, jr.TimeLast
+	if len(first) == 0 {
+		first = jr.ZoneTimeFirst
+	}
+	if len(last) == 0 {
+		last = jr.ZoneTimeLast
+	}
+	rec := Record{Name: jr.RRName, Type: jr.RRType, Data: data, Count: jr.Count}
+	var err error
+	if rec.FirstSeen, err = parseTime(strings.Trim(string(first), `"`)); err != nil {
+		return rec, fmt.Errorf("time_first: %w", err)
+	}
+	if rec.LastSeen, err = parseTime(strings.Trim(string(last), `"`)); err != nil {
+		return rec, fmt.Errorf("time_last: %w", err)
+	}
+	return normalize(rec)
+}
+
+func (r *Reader) readCSV() (Record, error) {
+	row, err := r.csv.Read()
+	if err == io.EOF {
+		return Record{}, io.EOF
+	}
+	r.line++
+	if err != nil {
+		return Record{}, &LineError{Line: r.line, Err: err}
+	}
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	rec := Record{Name: field("rrname"), Type: field("rrtype"), Data: []string{field("rdata")}}
+	if rec.FirstSeen, err = parseTime(field("time_first")); err != nil {
+		return rec, &LineError{Line: r.line, Err: fmt.Errorf("time_first: %w", err)}
+	}
+	if rec.LastSeen, err = parseTime(field("time_last")); err != nil {
+		return rec, &LineError{Line: r.line, Err: fmt.Errorf("time_last: %w", err)}
+	}
+	if c := field("count"); c != "" {
+		rec.Count, _ = strconv.ParseInt(c, 10, 64)
+	}
+	if rec, err = normalize(rec); err != nil {
+		return rec, &LineError{Line: r.line, Err: err}
+	}
+	return rec, nil
+}
+
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// parseTime accepts Unix seconds or a date in one of timeLayouts (UTC).
+func parseTime(s string) (time.Time, error) {
+	if s == "" || s == "null" {
+		return time.Time{}, fmt.Errorf("missing")
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+func normalize(rec Record) (Record, error) {
+	rec.Name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rec.Name), "."))
+	rec.Type = strings.ToUpper(strings.TrimSpace(rec.Type))
+	if rec.Name == "" || rec.Type == "" {
+		return rec, fmt.Errorf("rrname and rrtype are required")
+	}
+	data := rec.Data[:0]
+	for _, d := range rec.Data {
+		if d = strings.TrimSpace(d); d != "" {
+			data = append(data, d)
+		}
+	}
+	if len(data) == 0 {
+		return rec, fmt.Errorf("rdata is empty")
+	}
+	rec.Data = data
+	if rec.LastSeen.Before(rec.FirstSeen) {
+		rec.FirstSeen, rec.LastSeen = rec.LastSeen, rec.FirstSeen
+	}
+	return rec, nil
+}
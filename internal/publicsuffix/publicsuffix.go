@@ -0,0 +1,34 @@
+// Package publicsuffix derives registrable (root) domains using the rules of
+// the Public Suffix List (https://publicsuffix.org/list/).
+//
+// The full list is compiled in by golang.org/x/net/publicsuffix, so SigMap
+// works offline; this package normalizes names before looking them up.
+package publicsuffix
+
+import (
+	"fmt"
+	"strings"
+
+	xpublicsuffix "golang.org/x/net/publicsuffix"
+)
+
+// PublicSuffix returns the public suffix of a domain. Names under an unknown
+// TLD fall back to the implicit "*" rule, i.e. the TLD itself.
+func PublicSuffix(domain string) string {
+	suffix, _ := xpublicsuffix.PublicSuffix(normalize(domain))
+	return suffix
+}
+
+// EffectiveTLDPlusOne returns the registrable domain, the public suffix plus
+// one label: "a.b.example.co.uk" gives "example.co.uk".
+func EffectiveTLDPlusOne(domain string) (string, error) {
+	domain = normalize(domain)
+	if domain == "" || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("publicsuffix: invalid domain %q", domain)
+	}
+	return xpublicsuffix.EffectiveTLDPlusOne(domain)
+}
+
+func normalize(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"net"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AssetRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewAssetRepository(pool *pgxpool.Pool) *AssetRepository {
+	return &AssetRepository{Pool: pool}
+}
+
+// IPDetail is an IP with every host resolving to it and its open services.
+type IPDetail struct {
+	models.IPAddress
+	Hosts    []models.Resolution
+	Services []models.Service
+	// History lists names that resolved to the IP in passive DNS.
+	History []models.PassiveDNSRecord
+}
+
+// RecordType returns the DNS record type an address is published under.
+func RecordType(ip net.IP) string {
+	if ip.To4() != nil {
+		return "A"
+	}
+	return "AAAA"
+}
+
+// EnsureIP returns the ID of an address in the current workspace, creating
+// it if needed, and marks it as seen now.
+func (r *AssetRepository) EnsureIP(ctx context.Context, address string) (int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO ip_addresses (workspace_id, address, version)
+		VALUES ($1, $2::inet, family($2::inet))
+		ON CONFLICT (workspace_id, address) DO UPDATE SET last_seen = CURRENT_TIMESTAMP
+		RETURNING id
+	`, workspace.FromContext(ctx), address).Scan(&id)
+	return id, err
+}
+
+// LinkHost records that a host resolved to an IP.
+func (r *AssetRepository) LinkHost(ctx context.Context, domainID, ipID int, recordType string) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO domain_ips (domain_id, ip_id, record_type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (domain_id, ip_id) DO UPDATE SET last_seen = CURRENT_TIMESTAMP, record_type = EXCLUDED.record_type
+	`, domainID, ipID, recordType)
+	return err
+}
+
+// RecordResolution stores the A/AAAA answers of a host and returns the IP IDs
+// in the order given.
+func (r *AssetRepository) RecordResolution(ctx context.Context, domainID int, ips []net.IP) ([]int, error) {
+	ids := make([]int, 0, len(ips))
+	for _, ip := range ips {
+		id, err := r.EnsureIP(ctx, ip.String())
+		if err != nil {
+			return ids, err
+		}
+		if err := r.LinkHost(ctx, domainID, id, RecordType(ip)); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// UpdateIPDetails stores network metadata for an IP.
+func (r *AssetRepository) UpdateIPDetails(ctx context.Context, ipID, asn int, asnOrg, cloudProvider, country string) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE ip_addresses SET asn = NULLIF($2, 0), asn_org = $3, cloud_provider = $4, country = NULLIF($5, '')
+		WHERE id = $1
+	`, ipID, asn, asnOrg, cloudProvider, country)
+	return err
+}
+
+// RecordService upserts an open port on an address of the current workspace.
+func (r *AssetRepository) RecordService(ctx context.Context, address string, s models.Service) (int, error) {
+	ipID, err := r.EnsureIP(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	if s.Protocol == "" {
+		s.Protocol = "tcp"
+	}
+	_, err = r.Pool.Exec(ctx, `
+		INSERT INTO ip_services (ip_id, port, protocol, service, product, version, source)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+		ON CONFLICT (ip_id, port, protocol) DO UPDATE SET
+			service = COALESCE(EXCLUDED.service, ip_services.service),
+			product = COALESCE(EXCLUDED.product, ip_services.product),
+			version = COALESCE(EXCLUDED.version, ip_services.version),
+			source = EXCLUDED.source,
+			last_seen = CURRENT_TIMESTAMP
+	`, ipID, s.Port, s.Protocol, s.Service, s.Product, s.Version, s.Source)
+	return ipID, err
+}
+
+// RecordPassiveDNS stores a historical resolution of a host, widening the
+// window and keeping the highest count when the record is already known so
+// re-importing an export is idempotent. A/AAAA values are linked to the IP.
+func (r *AssetRepository) RecordPassiveDNS(ctx context.Context, rec models.PassiveDNSRecord) error {
+	if rec.Type == "A" || rec.Type == "AAAA" {
+		var ipID int
+		err := r.Pool.QueryRow(ctx, `
+			INSERT INTO ip_addresses (workspace_id, address, version, first_seen, last_seen)
+			VALUES ($1, $2::inet, family($2::inet), $3, $4)
+			ON CONFLICT (workspace_id, address) DO UPDATE SET
+				first_seen = LEAST(ip_addresses.first_seen, EXCLUDED.first_seen),
+				last_seen = GREATEST(ip_addresses.last_seen, EXCLUDED.last_seen)
+			RETURNING id
+		`, workspace.FromContext(ctx), rec.Value, rec.FirstSeen, rec.LastSeen).Scan(&ipID)
+		if err != nil {
+			return err
+		}
+		rec.IPID = &ipID
+	}
+
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO passive_dns (domain_id, record_type, value, ip_id, first_seen, last_seen, count, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (domain_id, record_type, value) DO UPDATE SET
+			ip_id = EXCLUDED.ip_id,
+			first_seen = LEAST(passive_dns.first_seen, EXCLUDED.first_seen),
+			last_seen = GREATEST(passive_dns.last_seen, EXCLUDED.last_seen),
+			count = GREATEST(passive_dns.count, EXCLUDED.count),
+			source = EXCLUDED.source,
+			imported_at = CURRENT_TIMESTAMP
+	`, rec.DomainID, rec.Type, rec.Value, rec.IPID, rec.FirstSeen, rec.LastSeen, rec.Count, rec.Source)
+	return err
+}
+
+// GetIPIDByAddress looks up an address in the current workspace.
+func (r *AssetRepository) GetIPIDByAddress(ctx context.Context, address string) (int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, "SELECT id FROM ip_addresses WHERE address = $1::inet AND workspace_id = $2", address, workspace.FromContext(ctx)).Scan(&id)
+	return id, err
+}
+
+// GetIP returns an IP of the current workspace with its hosts and services.
+func (r *AssetRepository) GetIP(ctx context.Context, id int) (IPDetail, error) {
+	var d IPDetail
+	err := r.Pool.QueryRow(ctx, `
+		SELECT id, host(address), version, COALESCE(asn, 0), COALESCE(asn_org, ''), COALESCE(cloud_provider, ''), COALESCE(country, ''), first_seen, last_seen
+		FROM ip_addresses WHERE id = $1 AND workspace_id = $2
+	`, id, workspace.FromContext(ctx)).Scan(&d.ID, &d.Address, &d.Version, &d.ASN, &d.ASNOrg, &d.CloudProvider, &d.Country, &d.FirstSeen, &d.LastSeen)
+	if err != nil {
+		return d, err
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+		SELECT d.id, d.name, di.record_type, di.first_seen, di.last_seen
+		FROM domain_ips di
+		JOIN domains d ON d.id = di.domain_id
+		WHERE di.ip_id = $1
+		ORDER BY di.last_seen DESC, d.name ASC
+	`, id)
+	if err != nil {
+		return d, err
+	}
+	for rows.Next() {
+		res := models.Resolution{IPID: d.ID, Address: d.Address}
+		if err := rows.Scan(&res.DomainID, &res.DomainName, &res.RecordType, &res.FirstSeen, &res.LastSeen); err == nil {
+			d.Hosts = append(d.Hosts, res)
+		}
+	}
+	rows.Close()
+
+	rows, err = r.Pool.Query(ctx, `
+		SELECT d.id, d.name, p.record_type, p.value, p.first_seen, p.last_seen, p.count, p.source
+		FROM passive_dns p
+		JOIN domains d ON d.id = p.domain_id
+		WHERE p.ip_id = $1
+		ORDER BY p.last_seen DESC, d.name ASC
+	`, id)
+	if err != nil {
+		return d, err
+	}
+	for rows.Next() {
+		rec := models.PassiveDNSRecord{IPID: &d.ID}
+		if err := rows.Scan(&rec.DomainID, &rec.DomainName, &rec.Type, &rec.Value, &rec.FirstSeen, &rec.LastSeen, &rec.Count, &rec.Source); err == nil {
+			d.History = append(d.History, rec)
+		}
+	}
+	rows.Close()
+
+	rows, err = r.Pool.Query(ctx, `
+		SELECT id, ip_id, port, protocol, COALESCE(service, ''), COALESCE(product, ''), COALESCE(version, ''), COALESCE(source, ''), first_seen, last_seen
+		FROM ip_services
+		WHERE ip_id = $1
+		ORDER BY port ASC, protocol ASC
+	`, id)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s models.Service
+		if err := rows.Scan(&s.ID, &s.IPID, &s.Port, &s.Protocol, &s.Service, &s.Product, &s.Version, &s.Source, &s.FirstSeen, &s.LastSeen); err == nil {
+			d.Services = append(d.Services, s)
+		}
+	}
+	return d, rows.Err()
+}
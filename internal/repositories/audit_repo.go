@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditFilters struct {
+	Actor      string
+	Action     string
+	TargetType string
+	IP         string
+	From       *time.Time
+	To         *time.Time
+}
+
+type AuditRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{Pool: pool}
+}
+
+const auditColumns = `
+	id, workspace_id, occurred_at, actor_type, COALESCE(actor_id, ''), COALESCE(actor_name, ''), action,
+	COALESCE(target_type, ''), COALESCE(target_id, ''), COALESCE(target_name, ''), COALESCE(ip, ''),
+	COALESCE(method, ''), COALESCE(path, ''), COALESCE(status, 0), before, after`
+
+func scanAuditEvent(row rowScanner) (models.AuditEvent, error) {
+	var e models.AuditEvent
+	err := row.Scan(&e.ID, &e.WorkspaceID, &e.OccurredAt, &e.ActorType, &e.ActorID, &e.ActorName, &e.Action,
+		&e.TargetType, &e.TargetID, &e.TargetName, &e.IP, &e.Method, &e.Path, &e.Status, &e.Before, &e.After)
+	return e, err
+}
+
+// Insert appends an event to the audit log.
+func (r *AuditRepository) Insert(ctx context.Context, e *models.AuditEvent) error {
+	return r.Pool.QueryRow(ctx, `
+		INSERT INTO audit_events (workspace_id, actor_type, actor_id, actor_name, action, target_type, target_id, target_name,
+			ip, method, path, status, before, after)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+			NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, 0), $13, $14)
+		RETURNING id, occurred_at
+	`, e.WorkspaceID, e.ActorType, e.ActorID, e.ActorName, e.Action, e.TargetType, e.TargetID, e.TargetName,
+		e.IP, e.Method, e.Path, e.Status, nullJSON(e.Before), nullJSON(e.After)).Scan(&e.ID, &e.OccurredAt)
+}
+
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (r *AuditRepository) buildWhere(ctx context.Context, f AuditFilters, startArg int) (string, []interface{}) {
+	clauses := []string{fmt.Sprintf("workspace_id = $%d", startArg)}
+	args := []interface{}{workspace.FromContext(ctx)}
+	n := startArg + 1
+
+	add := func(clause string, v interface{}) {
+		clauses = append(clauses, fmt.Sprintf(clause, n))
+		args = append(args, v)
+		n++
+	}
+	if f.Actor != "" {
+		add("actor_name ILIKE $%d", "%"+f.Actor+"%")
+	}
+	if f.Action != "" {
+		add("action LIKE $%d", f.Action+"%")
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.IP != "" {
+		add("ip = $%d", f.IP)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// List returns the current workspace's events, newest first.
+func (r *AuditRepository) List(ctx context.Context, f AuditFilters, limit, offset int) ([]models.AuditEvent, error) {
+	where, args := r.buildWhere(ctx, f, 3)
+	rows, err := r.Pool.Query(ctx, `SELECT `+auditColumns+` FROM audit_events WHERE `+where+`
+		ORDER BY occurred_at DESC, id DESC LIMIT $1 OFFSET $2`, append([]interface{}{limit, offset}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Count returns the number of events matching the filters.
+func (r *AuditRepository) Count(ctx context.Context, f AuditFilters) (int, error) {
+	where, args := r.buildWhere(ctx, f, 1)
+	var count int
+	err := r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+// Each streams matching events in chronological order, for exports.
+func (r *AuditRepository) Each(ctx context.Context, f AuditFilters, fn func(models.AuditEvent) error) error {
+	where, args := r.buildWhere(ctx, f, 1)
+	rows, err := r.Pool.Query(ctx, `SELECT `+auditColumns+` FROM audit_events WHERE `+where+` ORDER BY occurred_at ASC, id ASC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListActions returns the distinct actions recorded in the current workspace.
+func (r *AuditRepository) ListActions(ctx context.Context) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT DISTINCT action FROM audit_events WHERE workspace_id = $1 ORDER BY action", workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 )
 
 type BookmarkListItem struct {
@@ -27,11 +28,11 @@ func (r *DomainRepository) ListBookmarks(ctx context.Context) ([]BookmarkListIte
 			COALESCE((SELECT AVG(confidence) FROM detections WHERE domain_id = d.id), 0)::INT as avg_conf,
 			d.updated_at
 		FROM domains d
-		WHERE d.is_bookmarked = TRUE
+		WHERE d.is_bookmarked = TRUE AND d.workspace_id = $1
 		ORDER BY d.updated_at DESC
 	`
 
-	rows, err := r.Pool.Query(ctx, query)
+	rows, err := r.Pool.Query(ctx, query, workspace.FromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -2,17 +2,57 @@ package repositories
 
 import (
 	"context"
+	"math"
+	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// categoryDomainLimit caps the domains listed on a category page; the count
+// is always complete.
+const categoryDomainLimit = 100
+
 type CategoryListItem struct {
 	ID             int
 	Name           string
 	TechCount      int
 	DomainCount    int
 	AvgConfidence  float64
+	RiskScore      float64 // 1 (Low) to 4 (Critical), weighted by domains; 0 when undetected
 	RiskLevel      string
+	ExposedDomains int // running a High or Critical member technology
+}
+
+// CategoryDetail is a category with the member technologies and domains
+// of the current workspace.
+type CategoryDetail struct {
+	CategoryListItem
+	Technologies []CategoryTech
+	Domains      []CategoryDomain // first categoryDomainLimit, riskiest first
+}
+
+// CategoryTech is a member technology and its share of the category's risk.
+type CategoryTech struct {
+	ID               int
+	Name             string
+	Icon             string
+	RiskLevel        string
+	CVECount         int
+	ExploitAvailable bool
+	DomainCount      int
+	AvgConfidence    float64
+}
+
+// CategoryDomain is a domain running member technologies of a category.
+type CategoryDomain struct {
+	ID           int
+	Name         string
+	Criticality  string
+	RiskLevel    string // of its riskiest member technology
+	Technologies []string
+	LastSeen     time.Time
 }
 
 type CategoryRepository struct {
@@ -23,22 +63,51 @@ func NewCategoryRepository(pool *pgxpool.Pool) *CategoryRepository {
 	return &CategoryRepository{Pool: pool}
 }
 
+// riskRank is the SQL rank, 1 (Low) to 4 (Critical), of a technology's
+// vulnerability risk; $2 holds models.CriticalityLevels.
+const riskRank = `COALESCE(array_position($2::text[], COALESCE(vp.risk_level, t.risk_level)::text), 1)`
+
+// categoryQuery aggregates categories over the workspace's detections ($1).
+// Every detection is one domain running one technology, so averaging the
+// rank over detections weights each technology by its domain count.
+const categoryQuery = `
+	SELECT
+		c.id, c.name,
+		COUNT(DISTINCT tc.technology_id) as tech_count,
+		COUNT(DISTINCT det.domain_id) as domain_count,
+		COALESCE(AVG(det.confidence), 0) as avg_conf,
+		COALESCE(AVG(` + riskRank + `) FILTER (WHERE det.id IS NOT NULL), 0) as risk_score,
+		COUNT(DISTINCT det.domain_id) FILTER (WHERE ` + riskRank + ` >= 3) as exposed
+	FROM categories c
+	LEFT JOIN technology_categories tc ON c.id = tc.category_id
+	LEFT JOIN technologies t ON tc.technology_id = t.id
+	LEFT JOIN technology_vuln_profile vp ON vp.technology = t.name
+	LEFT JOIN detections det ON t.id = det.technology_id AND det.workspace_id = $1`
+
+func scanCategory(row rowScanner) (CategoryListItem, error) {
+	var item CategoryListItem
+	err := row.Scan(&item.ID, &item.Name, &item.TechCount, &item.DomainCount, &item.AvgConfidence, &item.RiskScore, &item.ExposedDomains)
+	item.RiskLevel = riskLevel(item.RiskScore)
+	return item, err
+}
+
+// riskLevel rounds a weighted risk score to the nearest level.
+func riskLevel(score float64) string {
+	rank := int(math.Round(score))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(models.CriticalityLevels) {
+		rank = len(models.CriticalityLevels)
+	}
+	return models.CriticalityLevels[rank-1]
+}
+
 func (r *CategoryRepository) List(ctx context.Context) ([]CategoryListItem, error) {
-	query := `
-		SELECT 
-			c.id, c.name, 
-			COUNT(DISTINCT tc.technology_id) as tech_count,
-			COUNT(DISTINCT det.domain_id) as domain_count,
-			COALESCE(AVG(det.confidence), 0) as avg_conf
-		FROM categories c
-		LEFT JOIN technology_categories tc ON c.id = tc.category_id
-		LEFT JOIN technologies t ON tc.technology_id = t.id
-		LEFT JOIN detections det ON t.id = det.technology_id
+	rows, err := r.Pool.Query(ctx, categoryQuery+`
 		GROUP BY c.id
 		ORDER BY domain_count DESC, c.name ASC
-	`
-
-	rows, err := r.Pool.Query(ctx, query)
+	`, workspace.FromContext(ctx), models.CriticalityLevels)
 	if err != nil {
 		return nil, err
 	}
@@ -46,18 +115,80 @@ func (r *CategoryRepository) List(ctx context.Context) ([]CategoryListItem, erro
 
 	var items []CategoryListItem
 	for rows.Next() {
-		var item CategoryListItem
-		err := rows.Scan(&item.ID, &item.Name, &item.TechCount, &item.DomainCount, &item.AvgConfidence)
+		item, err := scanCategory(rows)
 		if err != nil {
 			return nil, err
 		}
-		// Mock risk level based on counts or specific categories for now
-		item.RiskLevel = "Low"
-		if item.DomainCount > 1000 {
-			item.RiskLevel = "Medium"
-		}
 		items = append(items, item)
 	}
+	return items, rows.Err()
+}
+
+// GetDetail returns a category with its member technologies, most used
+// first, and the workspace domains running them.
+func (r *CategoryRepository) GetDetail(ctx context.Context, id int) (*CategoryDetail, error) {
+	wsID := workspace.FromContext(ctx)
+	item, err := scanCategory(r.Pool.QueryRow(ctx, categoryQuery+`
+		WHERE c.id = $3
+		GROUP BY c.id
+	`, wsID, models.CriticalityLevels, id))
+	if err != nil {
+		return nil, err
+	}
+	d := &CategoryDetail{CategoryListItem: item}
 
-	return items, nil
+	rows, err := r.Pool.Query(ctx, `
+		SELECT t.id, t.name, COALESCE(t.icon, ''), COALESCE(vp.risk_level, t.risk_level, 'Low'),
+			COALESCE(vp.cve_count, 0), COALESCE(vp.exploit_available, FALSE),
+			COUNT(DISTINCT det.domain_id), COALESCE(AVG(det.confidence), 0)
+		FROM technology_categories tc
+		JOIN technologies t ON t.id = tc.technology_id
+		LEFT JOIN technology_vuln_profile vp ON vp.technology = t.name
+		LEFT JOIN detections det ON det.technology_id = t.id AND det.workspace_id = $2
+		WHERE tc.category_id = $1
+		GROUP BY t.id, vp.risk_level, vp.cve_count, vp.exploit_available
+		ORDER BY COUNT(DISTINCT det.domain_id) DESC, t.name
+	`, id, wsID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t CategoryTech
+		if err := rows.Scan(&t.ID, &t.Name, &t.Icon, &t.RiskLevel, &t.CVECount, &t.ExploitAvailable, &t.DomainCount, &t.AvgConfidence); err != nil {
+			return nil, err
+		}
+		d.Technologies = append(d.Technologies, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.Pool.Query(ctx, `
+		SELECT d.id, d.name, COALESCE(d.criticality, ''), MAX(`+riskRank+`),
+			array_agg(DISTINCT t.name ORDER BY t.name), MAX(det.last_seen)
+		FROM detections det
+		JOIN domains d ON d.id = det.domain_id
+		JOIN technologies t ON t.id = det.technology_id
+		JOIN technology_categories tc ON tc.technology_id = t.id AND tc.category_id = $3
+		LEFT JOIN technology_vuln_profile vp ON vp.technology = t.name
+		WHERE det.workspace_id = $1
+		GROUP BY d.id
+		ORDER BY MAX(`+riskRank+`) DESC, d.name
+		LIMIT $4
+	`, wsID, models.CriticalityLevels, id, categoryDomainLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var dom CategoryDomain
+		var rank int
+		if err := rows.Scan(&dom.ID, &dom.Name, &dom.Criticality, &rank, &dom.Technologies, &dom.LastSeen); err != nil {
+			return nil, err
+		}
+		dom.RiskLevel = riskLevel(float64(rank))
+		d.Domains = append(d.Domains, dom)
+	}
+	return d, rows.Err()
 }
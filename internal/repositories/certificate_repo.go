@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Certificate list filters.
+const (
+	CertFilterExpired    = "expired"
+	CertFilterExpiring   = "expiring"
+	CertFilterSelfSigned = "self-signed"
+	CertFilterWeak       = "weak"
+)
+
+const certColumns = `c.id, c.fingerprint_sha256, c.subject, COALESCE(c.subject_cn, ''), c.issuer, COALESCE(c.issuer_cn, ''), c.serial, c.sans,
+	c.not_before, c.not_after, c.key_type, COALESCE(c.key_bits, 0), c.signature_algorithm, c.is_ca, c.self_signed, c.weak_reasons,
+	c.first_seen, c.last_seen`
+
+type CertificateRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewCertificateRepository(pool *pgxpool.Pool) *CertificateRepository {
+	return &CertificateRepository{Pool: pool}
+}
+
+// CertificateDetail is a certificate with every endpoint serving it.
+type CertificateDetail struct {
+	models.Certificate
+	Endpoints []models.TLSEndpoint
+}
+
+func scanCertificate(row rowScanner, c *models.Certificate, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{&c.ID, &c.Fingerprint, &c.Subject, &c.SubjectCN, &c.Issuer, &c.IssuerCN, &c.Serial, &c.SANs,
+		&c.NotBefore, &c.NotAfter, &c.KeyType, &c.KeyBits, &c.SignatureAlgorithm, &c.IsCA, &c.SelfSigned, &c.WeakReasons,
+		&c.FirstSeen, &c.LastSeen}, extra...)...)
+}
+
+// SaveEndpoint stores the outcome of a handshake with a host: the chain's
+// certificates, the endpoint's state, and which certificates it now serves.
+func (r *CertificateRepository) SaveEndpoint(ctx context.Context, ep models.TLSEndpoint) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int, 0, len(ep.Chain))
+	for _, c := range ep.Chain {
+		id, err := upsertCertificate(ctx, tx, workspace.FromContext(ctx), c)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	var endpointID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO tls_endpoints (domain_id, port, address, tls_version, cipher_suite, verify_error, error, checked_at)
+		VALUES ($1, $2, NULLIF($3, '')::inet, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), CURRENT_TIMESTAMP)
+		ON CONFLICT (domain_id, port) DO UPDATE SET
+			address = EXCLUDED.address,
+			tls_version = EXCLUDED.tls_version,
+			cipher_suite = EXCLUDED.cipher_suite,
+			verify_error = EXCLUDED.verify_error,
+			error = EXCLUDED.error,
+			checked_at = EXCLUDED.checked_at
+		RETURNING id
+	`, ep.DomainID, ep.Port, ep.Address, ep.Version, ep.CipherSuite, ep.VerifyError, ep.Error).Scan(&endpointID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM tls_endpoint_certificates WHERE endpoint_id = $1 AND NOT (certificate_id = ANY($2))", endpointID, ids); err != nil {
+		return err
+	}
+	for pos, id := range ids {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tls_endpoint_certificates (endpoint_id, certificate_id, position)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (endpoint_id, certificate_id) DO UPDATE SET position = EXCLUDED.position, last_seen = CURRENT_TIMESTAMP
+		`, endpointID, id, pos)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func upsertCertificate(ctx context.Context, tx pgx.Tx, workspaceID int, c models.Certificate) (int, error) {
+	sans, weak := c.SANs, c.WeakReasons
+	if sans == nil {
+		sans = []string{}
+	}
+	if weak == nil {
+		weak = []string{}
+	}
+	var id int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO certificates (workspace_id, fingerprint_sha256, subject, subject_cn, issuer, issuer_cn, serial, sans,
+			not_before, not_after, key_type, key_bits, signature_algorithm, is_ca, self_signed, weak_reasons)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9, $10, $11, NULLIF($12, 0), $13, $14, $15, $16)
+		ON CONFLICT (workspace_id, fingerprint_sha256) DO UPDATE SET last_seen = CURRENT_TIMESTAMP
+		RETURNING id
+	`, workspaceID, c.Fingerprint, c.Subject, c.SubjectCN, c.Issuer, c.IssuerCN, c.Serial, sans,
+		c.NotBefore, c.NotAfter, c.KeyType, c.KeyBits, c.SignatureAlgorithm, c.IsCA, c.SelfSigned, weak).Scan(&id)
+	return id, err
+}
+
+func certFilterClause(filter string) (string, error) {
+	switch filter {
+	case "":
+		return "", nil
+	case CertFilterExpired:
+		return " AND c.not_after < CURRENT_TIMESTAMP", nil
+	case CertFilterExpiring:
+		return fmt.Sprintf(" AND c.not_after >= CURRENT_TIMESTAMP AND c.not_after < CURRENT_TIMESTAMP + INTERVAL '%d hours'", int(models.CertExpiryWarning.Hours())), nil
+	case CertFilterSelfSigned:
+		return " AND c.self_signed", nil
+	case CertFilterWeak:
+		return " AND cardinality(c.weak_reasons) > 0", nil
+	}
+	return "", fmt.Errorf("unknown certificate filter %q", filter)
+}
+
+// List returns certificates of the current workspace matching a filter,
+// soonest expiry first, with how many endpoints serve each.
+func (r *CertificateRepository) List(ctx context.Context, filter string, limit, offset int) ([]models.Certificate, error) {
+	clause, err := certFilterClause(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.Pool.Query(ctx, `
+		SELECT `+certColumns+`,
+			(SELECT COUNT(*) FROM tls_endpoint_certificates ec WHERE ec.certificate_id = c.id)
+		FROM certificates c
+		WHERE c.workspace_id = $1`+clause+`
+		ORDER BY c.not_after ASC, c.id ASC
+		LIMIT $2 OFFSET $3
+	`, workspace.FromContext(ctx), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certs []models.Certificate
+	for rows.Next() {
+		var c models.Certificate
+		if err := scanCertificate(rows, &c, &c.Hosts); err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	return certs, rows.Err()
+}
+
+// Count returns how many certificates of the current workspace match a filter.
+func (r *CertificateRepository) Count(ctx context.Context, filter string) (int, error) {
+	clause, err := certFilterClause(filter)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM certificates c WHERE c.workspace_id = $1"+clause, workspace.FromContext(ctx)).Scan(&n)
+	return n, err
+}
+
+// Get returns a certificate of the current workspace with its endpoints.
+func (r *CertificateRepository) Get(ctx context.Context, id int) (CertificateDetail, error) {
+	var d CertificateDetail
+	row := r.Pool.QueryRow(ctx, "SELECT "+certColumns+" FROM certificates c WHERE c.id = $1 AND c.workspace_id = $2", id, workspace.FromContext(ctx))
+	if err := scanCertificate(row, &d.Certificate); err != nil {
+		return d, err
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+		SELECT e.id, e.domain_id, d.name, e.port, COALESCE(host(e.address), ''), COALESCE(e.tls_version, ''), COALESCE(e.cipher_suite, ''),
+			COALESCE(e.verify_error, ''), COALESCE(e.error, ''), e.checked_at
+		FROM tls_endpoint_certificates ec
+		JOIN tls_endpoints e ON e.id = ec.endpoint_id
+		JOIN domains d ON d.id = e.domain_id
+		WHERE ec.certificate_id = $1
+		ORDER BY d.name, e.port
+	`, id)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.TLSEndpoint
+		if err := rows.Scan(&e.ID, &e.DomainID, &e.DomainName, &e.Port, &e.Address, &e.Version, &e.CipherSuite, &e.VerifyError, &e.Error, &e.CheckedAt); err != nil {
+			return d, err
+		}
+		d.Endpoints = append(d.Endpoints, e)
+	}
+	return d, rows.Err()
+}
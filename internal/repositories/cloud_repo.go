@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const cloudColumns = `c.id, c.provider, c.account, COALESCE(c.region, ''), c.resource_type, c.resource_id, c.name, COALESCE(c.record_type, ''),
+	c.hostnames, ARRAY(SELECT host(a) FROM unnest(c.addresses) a), c.targets, c.source, c.first_seen, c.last_seen`
+
+type CloudRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewCloudRepository(pool *pgxpool.Pool) *CloudRepository {
+	return &CloudRepository{Pool: pool}
+}
+
+func scanCloudResource(row rowScanner, c *models.CloudResource, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{&c.ID, &c.Provider, &c.Account, &c.Region, &c.Type, &c.ResourceID, &c.Name, &c.RecordType,
+		&c.Hostnames, &c.Addresses, &c.Targets, &c.Source, &c.FirstSeen, &c.LastSeen}, extra...)...)
+}
+
+// ReplaceSource stores the resources of one export in the current workspace
+// and drops those the previous import of the same source had but this one
+// does not.
+func (r *CloudRepository) ReplaceSource(ctx context.Context, source string, resources []models.CloudResource) (int, error) {
+	wsID := workspace.FromContext(ctx)
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int, 0, len(resources))
+	for _, c := range resources {
+		var id int
+		err := tx.QueryRow(ctx, `
+			INSERT INTO cloud_resources (workspace_id, provider, account, region, resource_type, resource_id, name, record_type,
+				hostnames, addresses, targets, source)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10::inet[], $11, $12)
+			ON CONFLICT (workspace_id, provider, account, resource_type, resource_id) DO UPDATE SET
+				region = EXCLUDED.region,
+				name = EXCLUDED.name,
+				record_type = EXCLUDED.record_type,
+				hostnames = EXCLUDED.hostnames,
+				addresses = EXCLUDED.addresses,
+				targets = EXCLUDED.targets,
+				source = EXCLUDED.source,
+				last_seen = CURRENT_TIMESTAMP
+			RETURNING id
+		`, wsID, c.Provider, c.Account, c.Region, c.Type, c.ResourceID, c.Name, c.RecordType,
+			nonNil(c.Hostnames), nonNil(c.Addresses), nonNil(c.Targets), source).Scan(&id)
+		if err != nil {
+			return len(ids), err
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM cloud_resources WHERE workspace_id = $1 AND source = $2 AND NOT (id = ANY($3))", wsID, source, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), tx.Commit(ctx)
+}
+
+// Reconcile rebuilds the links between the current workspace's hosts and
+// its cloud resources, then records the provider of the instance or load
+// balancer behind each linked host. It returns the number of linked hosts.
+func (r *CloudRepository) Reconcile(ctx context.Context) (int, error) {
+	wsID := workspace.FromContext(ctx)
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	steps := []string{
+		`DELETE FROM domain_cloud_resources
+		WHERE cloud_resource_id IN (SELECT id FROM cloud_resources WHERE workspace_id = $1)`,
+
+		// The host is the record's name or the resource's public DNS name.
+		`INSERT INTO domain_cloud_resources (domain_id, cloud_resource_id, matched_by)
+		SELECT d.id, c.id, 'name'
+		FROM cloud_resources c
+		JOIN domains d ON d.workspace_id = c.workspace_id AND d.name = ANY(c.hostnames)
+		WHERE c.workspace_id = $1
+		ON CONFLICT DO NOTHING`,
+
+		// The host resolves to an address the resource owns.
+		`INSERT INTO domain_cloud_resources (domain_id, cloud_resource_id, matched_by)
+		SELECT di.domain_id, c.id, 'ip'
+		FROM cloud_resources c
+		JOIN ip_addresses i ON i.workspace_id = c.workspace_id AND i.address = ANY(c.addresses)
+		JOIN domain_ips di ON di.ip_id = i.id
+		WHERE c.workspace_id = $1
+		ON CONFLICT DO NOTHING`,
+
+		// The host has a live CNAME to the resource.
+		`INSERT INTO domain_cloud_resources (domain_id, cloud_resource_id, matched_by)
+		SELECT d.id, c.id, 'cname'
+		FROM cloud_resources c
+		JOIN dns_records dr ON dr.record_type = 'CNAME' AND dr.removed_at IS NULL AND dr.value = ANY(c.hostnames)
+		JOIN domains d ON d.id = dr.domain_id AND d.workspace_id = c.workspace_id
+		WHERE c.workspace_id = $1
+		ON CONFLICT DO NOTHING`,
+
+		// The host's imported DNS record points at the resource.
+		`INSERT INTO domain_cloud_resources (domain_id, cloud_resource_id, matched_by)
+		SELECT l.domain_id, c.id, 'record'
+		FROM domain_cloud_resources l
+		JOIN cloud_resources rec ON rec.id = l.cloud_resource_id AND rec.resource_type = 'dns-record'
+		JOIN cloud_resources c ON c.workspace_id = rec.workspace_id AND c.id <> rec.id
+			AND (c.hostnames && rec.targets OR ARRAY(SELECT host(a) FROM unnest(c.addresses) a) && rec.targets)
+		WHERE rec.workspace_id = $1 AND l.matched_by = 'name'
+		ON CONFLICT DO NOTHING`,
+
+		// DNS zones say where a name is published, not where it is hosted,
+		// so only instances and load balancers set the provider.
+		`UPDATE domains d SET cloud_provider = owned.provider
+		FROM (
+			SELECT DISTINCT ON (l.domain_id) l.domain_id, c.provider
+			FROM domain_cloud_resources l
+			JOIN cloud_resources c ON c.id = l.cloud_resource_id
+			WHERE c.workspace_id = $1 AND c.resource_type <> 'dns-record'
+			ORDER BY l.domain_id, c.last_seen DESC
+		) owned
+		WHERE d.id = owned.domain_id`,
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(ctx, step, wsID); err != nil {
+			return 0, err
+		}
+	}
+
+	var linked int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT l.domain_id)
+		FROM domain_cloud_resources l
+		JOIN cloud_resources c ON c.id = l.cloud_resource_id
+		WHERE c.workspace_id = $1
+	`, wsID).Scan(&linked)
+	if err != nil {
+		return 0, err
+	}
+	return linked, tx.Commit(ctx)
+}
+
+// WorkspacesWithInventory lists the workspaces that have imported any cloud
+// resources.
+func (r *CloudRepository) WorkspacesWithInventory(ctx context.Context) ([]int, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT DISTINCT workspace_id FROM cloud_resources ORDER BY workspace_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Accounts summarizes the imported resources per provider account.
+func (r *CloudRepository) Accounts(ctx context.Context) ([]models.CloudAccount, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT c.provider, c.account,
+			COUNT(*) FILTER (WHERE c.resource_type = 'dns-record'),
+			COUNT(*) FILTER (WHERE c.resource_type = 'instance'),
+			COUNT(*) FILTER (WHERE c.resource_type = 'load-balancer'),
+			(SELECT COUNT(DISTINCT l.domain_id) FROM domain_cloud_resources l
+				JOIN cloud_resources lc ON lc.id = l.cloud_resource_id
+				WHERE lc.workspace_id = $1 AND lc.provider = c.provider AND lc.account = c.account),
+			MAX(c.last_seen)
+		FROM cloud_resources c
+		WHERE c.workspace_id = $1
+		GROUP BY c.provider, c.account
+		ORDER BY c.provider, c.account
+	`, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var accounts []models.CloudAccount
+	for rows.Next() {
+		var a models.CloudAccount
+		if err := rows.Scan(&a.Provider, &a.Account, &a.Records, &a.Instances, &a.LoadBalancers, &a.Linked, &a.LastImported); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// UnownedTargets lists A and AAAA records pointing at addresses no imported
+// account owns: records from the zone exports, and live resolutions of
+// tracked hosts into the address space of a provider whose instances or
+// load balancers have been imported (a released IP still in DNS).
+func (r *CloudRepository) UnownedTargets(ctx context.Context, limit int) ([]models.UnownedTarget, error) {
+	rows, err := r.Pool.Query(ctx, `
+		WITH owned AS (
+			SELECT DISTINCT host(unnest(addresses)) AS address FROM cloud_resources WHERE workspace_id = $1
+		)
+		SELECT name, record_type, address, origin, account, cloud_provider, domain_id FROM (
+			SELECT c.name, c.record_type, t.value AS address, c.source AS origin, c.account,
+				COALESCE(i.cloud_provider, '') AS cloud_provider, d.id AS domain_id
+			FROM cloud_resources c
+			CROSS JOIN LATERAL unnest(c.targets) AS t(value)
+			LEFT JOIN ip_addresses i ON i.workspace_id = c.workspace_id AND host(i.address) = t.value
+			LEFT JOIN domains d ON d.workspace_id = c.workspace_id AND d.name = c.name
+			WHERE c.workspace_id = $1 AND c.resource_type = 'dns-record' AND c.record_type IN ('A', 'AAAA')
+				AND t.value NOT IN (SELECT address FROM owned)
+			UNION ALL
+			SELECT d.name, dr.record_type, host(i.address), 'live', '', i.cloud_provider, d.id
+			FROM dns_records dr
+			JOIN domains d ON d.id = dr.domain_id
+			JOIN ip_addresses i ON i.workspace_id = d.workspace_id AND host(i.address) = dr.value
+			WHERE d.workspace_id = $1 AND dr.record_type IN ('A', 'AAAA') AND dr.removed_at IS NULL
+				AND i.cloud_provider IN (
+					SELECT DISTINCT provider FROM cloud_resources WHERE workspace_id = $1 AND resource_type <> 'dns-record'
+				)
+				AND host(i.address) NOT IN (SELECT address FROM owned)
+		) gaps
+		ORDER BY name, address, origin
+		LIMIT $2
+	`, workspace.FromContext(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var targets []models.UnownedTarget
+	for rows.Next() {
+		var t models.UnownedTarget
+		if err := rows.Scan(&t.Name, &t.RecordType, &t.Address, &t.Origin, &t.Account, &t.CloudProvider, &t.DomainID); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// Unscanned lists resources SigMap has never seen: not tied to any host and
+// owning no address that turned up in a scan. Wildcard records are left out
+// as there is no single host to scan.
+func (r *CloudRepository) Unscanned(ctx context.Context, limit int) ([]models.CloudResource, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT `+cloudColumns+`
+		FROM cloud_resources c
+		WHERE c.workspace_id = $1
+			AND c.name NOT LIKE '*.%'
+			AND NOT EXISTS (SELECT 1 FROM domain_cloud_resources l WHERE l.cloud_resource_id = c.id)
+			AND NOT EXISTS (SELECT 1 FROM ip_addresses i WHERE i.workspace_id = c.workspace_id AND i.address = ANY(c.addresses))
+		ORDER BY c.provider, c.account, c.resource_type, c.name
+		LIMIT $2
+	`, workspace.FromContext(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var resources []models.CloudResource
+	for rows.Next() {
+		var c models.CloudResource
+		if err := scanCloudResource(rows, &c); err != nil {
+			return nil, err
+		}
+		resources = append(resources, c)
+	}
+	return resources, rows.Err()
+}
+
+// nonNil keeps empty lists from being stored as NULL.
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -2,21 +2,64 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/timewindow"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Materialized views behind the dashboard widgets.
+const (
+	ViewDashboardStats        = "view_dashboard_stats"
+	ViewDashboardTrends       = "view_dashboard_trends"
+	ViewDashboardDistribution = "view_dashboard_distribution"
+)
+
+// DashboardViews lists them in refresh order.
+var DashboardViews = []string{ViewDashboardStats, ViewDashboardTrends, ViewDashboardDistribution}
+
+// A widget reads its view while the view was refreshed within
+// DashboardMaxAge and its sources have not been changed for longer than
+// DashboardMaxLag without a refresh; otherwise it queries live.
+const (
+	DashboardMaxAge = time.Hour
+	DashboardMaxLag = 5 * time.Minute
+)
+
 type DashboardStats struct {
 	TotalDetections   int
 	HighConfidence    float64
 	RiskyTechnologies int
 	BookmarkedDomains int
 	CriticalTechs     int
+	RefreshedAt       time.Time // of the view, or now when Live
+	Live              bool      // the view was stale and the stats were queried directly
+}
+
+// ViewRefresh is the refresh state of a dashboard view.
+type ViewRefresh struct {
+	Name        string
+	RefreshedAt *time.Time
+	DurationMS  int
+	Trigger     string // schedule, ingest or manual
+	Error       string // of the last attempt
+	DirtySince  *time.Time
+}
+
+// Fresh reports whether the view can be served at now.
+func (v ViewRefresh) Fresh(now time.Time) bool {
+	if v.RefreshedAt == nil || now.Sub(*v.RefreshedAt) > DashboardMaxAge {
+		return false
+	}
+	return v.DirtySince == nil || now.Sub(*v.DirtySince) <= DashboardMaxLag
 }
 
 type TrendPoint struct {
-	Date  string `json:"date"`
-	Count int    `json:"count"`
+	Date  string    `json:"date"`  // label of the bucket
+	Start time.Time `json:"start"` // of the bucket
+	Count int       `json:"count"`
 }
 
 type DistributionPoint struct {
@@ -32,94 +75,194 @@ func NewDashboardRepository(pool *pgxpool.Pool) *DashboardRepository {
 	return &DashboardRepository{Pool: pool}
 }
 
+// criticalTechsQuery counts distinct critical technologies detected in a workspace.
+const criticalTechsQuery = `
+	SELECT COUNT(DISTINCT vp.technology)
+	FROM technology_vuln_profile vp
+	JOIN technologies t ON t.name = vp.technology
+	JOIN detections det ON det.technology_id = t.id
+	WHERE vp.risk_level = 'Critical' AND det.workspace_id = $1`
+
+// GetStats reads the stats view, or queries live when the view is stale or
+// has no row for the workspace yet.
 func (r *DashboardRepository) GetStats(ctx context.Context) (DashboardStats, error) {
 	var stats DashboardStats
+	if !r.fresh(ctx, ViewDashboardStats) {
+		return r.GetStatsRealtime(ctx)
+	}
 
 	err := r.Pool.QueryRow(ctx, `
-		SELECT total_detections, avg_confidence, risky_technologies, bookmarked_domains 
-		FROM view_dashboard_stats
-	`).Scan(&stats.TotalDetections, &stats.HighConfidence, &stats.RiskyTechnologies, &stats.BookmarkedDomains)
-	
-	// Fallback or additional metrics not yet in materialized view
+		SELECT total_detections, avg_confidence, risky_technologies, bookmarked_domains, critical_technologies, last_refreshed
+		FROM view_dashboard_stats WHERE workspace_id = $1
+	`, workspace.FromContext(ctx)).Scan(&stats.TotalDetections, &stats.HighConfidence, &stats.RiskyTechnologies, &stats.BookmarkedDomains,
+		&stats.CriticalTechs, &stats.RefreshedAt)
 	if err != nil {
 		return r.GetStatsRealtime(ctx)
 	}
-
-	// Fetch CriticalTechs realtime for now
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM technology_vuln_profile WHERE risk_level = 'Critical'").Scan(&stats.CriticalTechs)
-
 	return stats, nil
 }
 
 func (r *DashboardRepository) GetStatsRealtime(ctx context.Context) (DashboardStats, error) {
-	var stats DashboardStats
+	stats := DashboardStats{RefreshedAt: time.Now(), Live: true}
+	ws := workspace.FromContext(ctx)
 
-	err := r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections").Scan(&stats.TotalDetections)
+	err := r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections WHERE workspace_id = $1", ws).Scan(&stats.TotalDetections)
 	if err != nil {
 		return stats, err
 	}
 
-	err = r.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(confidence), 0) FROM detections").Scan(&stats.HighConfidence)
+	err = r.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(confidence), 0) FROM detections WHERE workspace_id = $1", ws).Scan(&stats.HighConfidence)
 	if err != nil {
 		return stats, err
 	}
 
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM technology_vuln_profile WHERE risk_level IN ('High', 'Critical')").Scan(&stats.RiskyTechnologies)
+	err = r.Pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT t.id) FROM detections det
+		JOIN technologies t ON det.technology_id = t.id
+		LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
+		WHERE det.workspace_id = $1 AND COALESCE(vp.risk_level, t.risk_level) IN ('High', 'Critical')
+	`, ws).Scan(&stats.RiskyTechnologies)
 	if err != nil {
 		return stats, err
 	}
 
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM domains WHERE is_bookmarked = TRUE").Scan(&stats.BookmarkedDomains)
+	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM domains WHERE is_bookmarked = TRUE AND workspace_id = $1", ws).Scan(&stats.BookmarkedDomains)
 	if err != nil {
 		return stats, err
 	}
 
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM technology_vuln_profile WHERE risk_level = 'Critical'").Scan(&stats.CriticalTechs)
+	err = r.Pool.QueryRow(ctx, criticalTechsQuery, ws).Scan(&stats.CriticalTechs)
 
 	return stats, nil
 }
 
-func (r *DashboardRepository) RefreshStats(ctx context.Context) error {
-	_, err := r.Pool.Exec(ctx, "REFRESH MATERIALIZED VIEW view_dashboard_stats")
+// RefreshView refreshes one dashboard view without blocking readers and
+// records the outcome. Changes made while it runs leave it dirty again.
+func (r *DashboardRepository) RefreshView(ctx context.Context, name, trigger string) error {
+	if !isDashboardView(name) {
+		return fmt.Errorf("%s is not a dashboard view", name)
+	}
+	if _, err := r.Pool.Exec(ctx, `UPDATE materialized_view_refreshes SET dirty_since = NULL WHERE view_name = $1`, name); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	_, err := r.Pool.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+name)
+	if err != nil {
+		r.Pool.Exec(ctx, `
+			INSERT INTO materialized_view_refreshes (view_name, trigger, error, dirty_since) VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+			ON CONFLICT (view_name) DO UPDATE SET trigger = EXCLUDED.trigger, error = EXCLUDED.error,
+				dirty_since = COALESCE(materialized_view_refreshes.dirty_since, EXCLUDED.dirty_since)
+		`, name, trigger, err.Error())
+		return err
+	}
+	_, err = r.Pool.Exec(ctx, `
+		INSERT INTO materialized_view_refreshes (view_name, refreshed_at, duration_ms, trigger) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at, duration_ms = EXCLUDED.duration_ms,
+			trigger = EXCLUDED.trigger, error = NULL
+	`, name, start, time.Since(start).Milliseconds(), trigger)
 	return err
 }
 
-func (r *DashboardRepository) GetTrendData(ctx context.Context) ([]TrendPoint, error) {
-	query := `
-		SELECT TO_CHAR(created_at, 'DD MON') as day, COUNT(*)
-		FROM detections
-		WHERE created_at > NOW() - INTERVAL '7 days'
-		GROUP BY day, DATE_TRUNC('day', created_at)
-		ORDER BY DATE_TRUNC('day', created_at) ASC
-	`
-	rows, err := r.Pool.Query(ctx, query)
+func isDashboardView(name string) bool {
+	for _, v := range DashboardViews {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ViewRefreshes returns the refresh state of the dashboard views, in
+// DashboardViews order. Views never refreshed have no RefreshedAt.
+func (r *DashboardRepository) ViewRefreshes(ctx context.Context) ([]ViewRefresh, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT view_name, refreshed_at, COALESCE(duration_ms, 0), COALESCE(trigger, ''), COALESCE(error, ''), dirty_since
+		FROM materialized_view_refreshes WHERE view_name = ANY($1)
+	`, DashboardViews)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var points []TrendPoint
+	byName := map[string]ViewRefresh{}
 	for rows.Next() {
-		var p TrendPoint
-		err := rows.Scan(&p.Date, &p.Count)
-		if err != nil {
+		var v ViewRefresh
+		if err := rows.Scan(&v.Name, &v.RefreshedAt, &v.DurationMS, &v.Trigger, &v.Error, &v.DirtySince); err != nil {
 			return nil, err
 		}
-		points = append(points, p)
+		byName[v.Name] = v
 	}
-	return points, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]ViewRefresh, len(DashboardViews))
+	for i, name := range DashboardViews {
+		out[i] = byName[name]
+		out[i].Name = name
+	}
+	return out, nil
 }
 
+// fresh reports whether a view can be served; on error it cannot.
+func (r *DashboardRepository) fresh(ctx context.Context, name string) bool {
+	v := ViewRefresh{Name: name}
+	err := r.Pool.QueryRow(ctx, `
+		SELECT refreshed_at, dirty_since FROM materialized_view_refreshes WHERE view_name = $1
+	`, name).Scan(&v.RefreshedAt, &v.DirtySince)
+	return err == nil && v.Fresh(time.Now())
+}
+
+// trendsViewSpan is how far back the trends view reaches, less a day for
+// the time since its last refresh.
+const trendsViewSpan = 89 * 24 * time.Hour
+
+// TrendsViewCovers reports whether the trends view holds the buckets of a
+// window: daily, in UTC and within its span.
+func TrendsViewCovers(w timewindow.Window) bool {
+	return w.Granularity == timewindow.Day && w.Location == time.UTC && time.Since(w.Start) <= trendsViewSpan
+}
+
+// GetTrendData counts new detections per bucket of the window, from the
+// trends view while it is fresh and covers the window.
+func (r *DashboardRepository) GetTrendData(ctx context.Context, w timewindow.Window) ([]TrendPoint, error) {
+	if !TrendsViewCovers(w) || !r.fresh(ctx, ViewDashboardTrends) {
+		return detectionSeries(ctx, r.Pool, w, TrendFilter{})
+	}
+	return scanSeries(ctx, r.Pool, w, `
+		SELECT s.bucket, COALESCE(v.detections, 0)::INT
+		FROM generate_series(
+			DATE_TRUNC('day', $2::TIMESTAMPTZ AT TIME ZONE 'UTC'),
+			$3::TIMESTAMPTZ AT TIME ZONE 'UTC' - INTERVAL '1 microsecond',
+			INTERVAL '1 day'
+		) AS s(bucket)
+		LEFT JOIN view_dashboard_trends v ON v.workspace_id = $1 AND v.day = s.bucket
+		ORDER BY s.bucket
+	`, workspace.FromContext(ctx), w.Start, w.End)
+}
+
+// GetDistributionData returns the five most detected technologies and
+// their share of detections, from the distribution view while it is fresh.
 func (r *DashboardRepository) GetDistributionData(ctx context.Context) ([]DistributionPoint, error) {
 	query := `
-		SELECT t.name, COUNT(*) * 100.0 / NULLIF((SELECT COUNT(*) FROM detections), 0) as pct
+		SELECT technology, detections * 100.0 / NULLIF(SUM(detections) OVER (), 0) as pct
+		FROM view_dashboard_distribution
+		WHERE workspace_id = $1
+		ORDER BY detections DESC, technology
+		LIMIT 5
+	`
+	if !r.fresh(ctx, ViewDashboardDistribution) {
+		query = `
+		SELECT t.name, COUNT(*) * 100.0 / NULLIF((SELECT COUNT(*) FROM detections WHERE workspace_id = $1), 0) as pct
 		FROM detections det
 		JOIN technologies t ON det.technology_id = t.id
+		WHERE det.workspace_id = $1
 		GROUP BY t.name
 		ORDER BY pct DESC
 		LIMIT 5
 	`
-	rows, err := r.Pool.Query(ctx, query)
+	}
+	rows, err := r.Pool.Query(ctx, query, workspace.FromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 )
 
 type DeltaListItem struct {
@@ -22,11 +23,11 @@ func (r *DomainRepository) ListDelta(ctx context.Context) ([]DeltaListItem, erro
 		FROM detections det
 		JOIN domains d ON det.domain_id = d.id
 		JOIN technologies t ON det.technology_id = t.id
-		WHERE det.created_at > NOW() - INTERVAL '24 hours'
+		WHERE det.created_at > NOW() - INTERVAL '24 hours' AND det.workspace_id = $1
 		ORDER BY det.created_at DESC
 	`
 
-	rows, err := r.Pool.Query(ctx, query)
+	rows, err := r.Pool.Query(ctx, query, workspace.FromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// fillCloud loads the cloud resources tied to a host, owned resources
+// before DNS records.
+func (r *DomainRepository) fillCloud(ctx context.Context, d *DomainDetail) {
+	rows, _ := r.Pool.Query(ctx, `
+		SELECT `+cloudColumns+`, l.matched_by
+		FROM domain_cloud_resources l
+		JOIN cloud_resources c ON c.id = l.cloud_resource_id
+		WHERE l.domain_id = $1
+		ORDER BY c.resource_type = 'dns-record', c.provider, c.account, c.name
+	`, d.ID)
+	if rows == nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.CloudResource
+		if err := scanCloudResource(rows, &c, &c.Match); err == nil {
+			d.Cloud = append(d.Cloud, c)
+		}
+	}
+}
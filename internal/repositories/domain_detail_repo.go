@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 )
 
 type DomainDetail struct {
@@ -11,6 +14,9 @@ type DomainDetail struct {
 	IsBookmarked  bool
 	IPAddress     string
 	CloudProvider string
+	Owner         string
+	Criticality   string
+	Tags          []models.Tag
 	ASN           int
 	ASNOrg        string
 	CreatedAt     time.Time
@@ -19,8 +25,24 @@ type DomainDetail struct {
 	CurrentStack []DomainTechDetail
 	History      []DetectionHistory
 	Notes        []NoteListItem
-	Subdomains   []string
 	ActiveVulns  []ActiveVulnerability
+
+	Root      models.RootDomain
+	Ancestors []DomainNode
+	Children  []DomainNode
+	IPs       []models.Resolution
+
+	DNS        *models.DNSStatus
+	DNSRecords []models.DNSRecord
+	DNSChanges []models.DNSChange
+	PassiveDNS []models.PassiveDNSRecord
+
+	TLS     []models.TLSEndpoint
+	Sources []string
+
+	Cloud []models.CloudResource
+
+	Risk *DomainRisk // nil until first scored
 }
 
 type DomainTechDetail struct {
@@ -32,6 +54,9 @@ type DomainTechDetail struct {
 	CVECount         int
 	ExploitAvailable bool
 	LastSeen         time.Time
+	Source           string
+	InferredFrom     string         // set when implied by another detection rather than observed
+	EOL              models.EOLInfo
 	Vulnerabilities  []VulnListItem // Actual CVE records
 }
 
@@ -63,12 +88,17 @@ func (r *DomainRepository) GetDomainDetails(ctx context.Context, id int) (Domain
 
 	// 1. Basic Info
 	err := r.Pool.QueryRow(ctx, `
-		SELECT id, name, is_bookmarked, COALESCE(ip_address, ''), COALESCE(cloud_provider, ''), COALESCE(asn, 0), COALESCE(asn_org, ''), created_at, updated_at
-		FROM domains WHERE id = $1
-	`, id).Scan(&d.ID, &d.Name, &d.IsBookmarked, &d.IPAddress, &d.CloudProvider, &d.ASN, &d.ASNOrg, &d.CreatedAt, &d.UpdatedAt)
+		SELECT id, name, is_bookmarked, COALESCE(ip_address, ''), COALESCE(cloud_provider, ''), COALESCE(asn, 0), COALESCE(asn_org, ''), created_at, updated_at,
+			ARRAY(SELECT source FROM domain_sources WHERE domain_id = domains.id ORDER BY first_seen),
+			COALESCE(owner, ''), COALESCE(criticality, '')
+		FROM domains WHERE id = $1 AND workspace_id = $2
+	`, id, workspace.FromContext(ctx)).Scan(&d.ID, &d.Name, &d.IsBookmarked, &d.IPAddress, &d.CloudProvider, &d.ASN, &d.ASNOrg, &d.CreatedAt, &d.UpdatedAt, &d.Sources,
+		&d.Owner, &d.Criticality)
 	if err != nil {
 		return d, err
 	}
+	d.Tags, _ = r.ListDomainTags(ctx, id)
+	d.Risk, _ = getDomainRisk(ctx, r.Pool, id)
 
 	// 2. Current Stack
 	rows, err := r.Pool.Query(ctx, `
@@ -77,18 +107,22 @@ func (r *DomainRepository) GetDomainDetails(ctx context.Context, id int) (Domain
 			COALESCE(vp.risk_level, t.risk_level) as risk_level,
 			COALESCE(vp.cve_count, 0),
 			COALESCE(vp.exploit_available, FALSE),
-			det.last_seen
+			det.last_seen,
+			COALESCE(det.source, ''), COALESCE(origin.name, ''),
+			COALESCE(det.eol_status, ''), COALESCE(det.eol_cycle, ''), det.eol_date, det.majors_behind, det.minors_behind
 		FROM detections det
 		JOIN technologies t ON det.technology_id = t.id
+		LEFT JOIN technologies origin ON origin.id = det.inferred_from
 		LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
 		WHERE det.domain_id = $1
-		ORDER BY det.last_seen DESC
+		ORDER BY det.inferred_from IS NOT NULL, det.last_seen DESC
 	`, id)
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
 			var t DomainTechDetail
-			err := rows.Scan(&t.Name, &t.Icon, &t.Version, &t.Confidence, &t.RiskLevel, &t.CVECount, &t.ExploitAvailable, &t.LastSeen)
+			err := rows.Scan(&t.Name, &t.Icon, &t.Version, &t.Confidence, &t.RiskLevel, &t.CVECount, &t.ExploitAvailable, &t.LastSeen,
+				&t.Source, &t.InferredFrom, &t.EOL.Status, &t.EOL.Cycle, &t.EOL.Date, &t.EOL.MajorsBehind, &t.EOL.MinorsBehind)
 			if err == nil {
 				// Fetch detailed CVEs for this specific tech
 				t.Vulnerabilities, _ = r.GetVulnsForTech(ctx, t.Name)
@@ -121,8 +155,20 @@ func (r *DomainRepository) GetDomainDetails(ctx context.Context, id int) (Domain
 		}
 	}
 
-	// 4. History & Subdomains (omitted for brevity in write_file but keeping logic)
-	r.fillHistoryAndSubs(ctx, &d)
+	// 4. History
+	r.fillHistory(ctx, &d)
+
+	// 5. Host tree & resolved IPs
+	r.fillTree(ctx, &d)
+
+	// 6. DNS records & history
+	r.fillDNS(ctx, &d)
+
+	// 7. TLS endpoints & certificates
+	r.fillTLS(ctx, &d)
+
+	// 8. Cloud resources from inventory exports
+	r.fillCloud(ctx, &d)
 
 	d.Notes, _ = r.ListNotesForDomain(ctx, id)
 
@@ -148,7 +194,7 @@ func (r *DomainRepository) GetVulnsForTech(ctx context.Context, techName string)
 	return list, nil
 }
 
-func (r *DomainRepository) fillHistoryAndSubs(ctx context.Context, d *DomainDetail) {
+func (r *DomainRepository) fillHistory(ctx context.Context, d *DomainDetail) {
 	rowsHistory, _ := r.Pool.Query(ctx, "SELECT t.name, det.version, det.created_at FROM detections det JOIN technologies t ON det.technology_id = t.id WHERE det.domain_id = $1 ORDER BY det.created_at DESC LIMIT 50", d.ID)
 	if rowsHistory != nil {
 		defer rowsHistory.Close()
@@ -159,16 +205,6 @@ func (r *DomainRepository) fillHistoryAndSubs(ctx context.Context, d *DomainDeta
 			}
 		}
 	}
-	rowsSubs, _ := r.Pool.Query(ctx, "SELECT name FROM domains WHERE name LIKE '%.' || $1 AND id != $2 ORDER BY name ASC", d.Name, d.ID)
-	if rowsSubs != nil {
-		defer rowsSubs.Close()
-		for rowsSubs.Next() {
-			var name string
-			if err := rowsSubs.Scan(&name); err == nil {
-				d.Subdomains = append(d.Subdomains, name)
-			}
-		}
-	}
 }
 
 func (r *DomainRepository) ListNotesForDomain(ctx context.Context, domainID int) ([]NoteListItem, error) {
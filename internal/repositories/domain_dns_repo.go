@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type dnsKey struct {
+	Type, Value string
+}
+
+// SaveDNS stores the records of a resolution, marking records that are no
+// longer returned as removed and logging every change, and replaces the
+// host's DNS status.
+func (r *DomainRepository) SaveDNS(ctx context.Context, domainID int, records []models.DNSRecord, status models.DNSStatus) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current := map[dnsKey]bool{}
+	rows, err := tx.Query(ctx, "SELECT record_type, value FROM dns_records WHERE domain_id = $1 AND removed_at IS NULL", domainID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var k dnsKey
+		if err := rows.Scan(&k.Type, &k.Value); err != nil {
+			rows.Close()
+			return err
+		}
+		current[k] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	seen := map[dnsKey]bool{}
+	for _, rec := range records {
+		k := dnsKey{rec.Type, rec.Value}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO dns_records (domain_id, record_type, value, ttl)
+			VALUES ($1, $2, $3, NULLIF($4, 0))
+			ON CONFLICT (domain_id, record_type, value) DO UPDATE SET
+				ttl = EXCLUDED.ttl, last_seen = CURRENT_TIMESTAMP, removed_at = NULL
+		`, domainID, rec.Type, rec.Value, rec.TTL)
+		if err != nil {
+			return err
+		}
+		if !current[k] {
+			if err := logDNSChange(ctx, tx, domainID, k, "added"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for k := range current {
+		if seen[k] {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE dns_records SET removed_at = CURRENT_TIMESTAMP
+			WHERE domain_id = $1 AND record_type = $2 AND value = $3
+		`, domainID, k.Type, k.Value); err != nil {
+			return err
+		}
+		if err := logDNSChange(ctx, tx, domainID, k, "removed"); err != nil {
+			return err
+		}
+	}
+
+	chain := status.CNAMEChain
+	if chain == nil {
+		chain = []string{}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO dns_status (domain_id, resolver, rcode, cname_chain, dangling, takeover_target, takeover_service, checked_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), CURRENT_TIMESTAMP)
+		ON CONFLICT (domain_id) DO UPDATE SET
+			resolver = EXCLUDED.resolver,
+			rcode = EXCLUDED.rcode,
+			cname_chain = EXCLUDED.cname_chain,
+			dangling = EXCLUDED.dangling,
+			takeover_target = EXCLUDED.takeover_target,
+			takeover_service = EXCLUDED.takeover_service,
+			checked_at = EXCLUDED.checked_at
+	`, domainID, status.Resolver, status.Rcode, chain, status.Dangling, status.TakeoverTarget, status.TakeoverService)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func logDNSChange(ctx context.Context, tx pgx.Tx, domainID int, k dnsKey, change string) error {
+	_, err := tx.Exec(ctx, "INSERT INTO dns_record_changes (domain_id, record_type, value, change) VALUES ($1, $2, $3, $4)", domainID, k.Type, k.Value, change)
+	return err
+}
+
+// fillDNS loads a host's records, recent changes, passive DNS history and
+// latest DNS status.
+func (r *DomainRepository) fillDNS(ctx context.Context, d *DomainDetail) {
+	rows, _ := r.Pool.Query(ctx, `
+		SELECT record_type, value, COALESCE(ttl, 0), first_seen, last_seen, removed_at
+		FROM dns_records
+		WHERE domain_id = $1
+		ORDER BY removed_at IS NOT NULL, array_position(ARRAY['CNAME','A','AAAA','MX','NS','TXT']::varchar[], record_type), value
+	`, d.ID)
+	if rows != nil {
+		defer rows.Close()
+		for rows.Next() {
+			var rec models.DNSRecord
+			if err := rows.Scan(&rec.Type, &rec.Value, &rec.TTL, &rec.FirstSeen, &rec.LastSeen, &rec.RemovedAt); err == nil {
+				d.DNSRecords = append(d.DNSRecords, rec)
+			}
+		}
+	}
+
+	rowsChanges, _ := r.Pool.Query(ctx, `
+		SELECT record_type, value, change, observed_at
+		FROM dns_record_changes
+		WHERE domain_id = $1
+		ORDER BY observed_at DESC, id DESC
+		LIMIT 50
+	`, d.ID)
+	if rowsChanges != nil {
+		defer rowsChanges.Close()
+		for rowsChanges.Next() {
+			var c models.DNSChange
+			if err := rowsChanges.Scan(&c.Type, &c.Value, &c.Change, &c.ObservedAt); err == nil {
+				d.DNSChanges = append(d.DNSChanges, c)
+			}
+		}
+	}
+
+	rowsPassive, _ := r.Pool.Query(ctx, `
+		SELECT record_type, value, ip_id, first_seen, last_seen, count, source
+		FROM passive_dns
+		WHERE domain_id = $1
+		ORDER BY last_seen DESC, record_type, value
+	`, d.ID)
+	if rowsPassive != nil {
+		defer rowsPassive.Close()
+		for rowsPassive.Next() {
+			rec := models.PassiveDNSRecord{DomainID: d.ID, DomainName: d.Name}
+			if err := rowsPassive.Scan(&rec.Type, &rec.Value, &rec.IPID, &rec.FirstSeen, &rec.LastSeen, &rec.Count, &rec.Source); err == nil {
+				d.PassiveDNS = append(d.PassiveDNS, rec)
+			}
+		}
+	}
+
+	var st models.DNSStatus
+	err := r.Pool.QueryRow(ctx, `
+		SELECT COALESCE(resolver, ''), rcode, cname_chain, dangling, COALESCE(takeover_target, ''), COALESCE(takeover_service, ''), checked_at
+		FROM dns_status WHERE domain_id = $1
+	`, d.ID).Scan(&st.Resolver, &st.Rcode, &st.CNAMEChain, &st.Dangling, &st.TakeoverTarget, &st.TakeoverService, &st.CheckedAt)
+	if err == nil {
+		d.DNS = &st
+	}
+}
+
+// DomainRef identifies a domain in any workspace.
+type DomainRef struct {
+	ID          int
+	Name        string
+	WorkspaceID int
+}
+
+// ListStaleDNS returns domains in every workspace whose DNS was never checked
+// or was last checked before the given time, oldest first.
+func (r *DomainRepository) ListStaleDNS(ctx context.Context, before time.Time, limit int) ([]DomainRef, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT d.id, d.name, d.workspace_id
+		FROM domains d
+		LEFT JOIN dns_status ds ON ds.domain_id = d.id
+		WHERE ds.checked_at IS NULL OR ds.checked_at < $1
+		ORDER BY ds.checked_at ASC NULLS FIRST, d.id ASC
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []DomainRef
+	for rows.Next() {
+		var ref DomainRef
+		if err := rows.Scan(&ref.ID, &ref.Name, &ref.WorkspaceID); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// ListDomainRefs pages through the domains of every workspace in ID order,
+// starting after the given ID.
+func (r *DomainRepository) ListDomainRefs(ctx context.Context, afterID, limit int) ([]DomainRef, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT id, name, workspace_id FROM domains WHERE id > $1 ORDER BY id ASC LIMIT $2", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []DomainRef
+	for rows.Next() {
+		var ref DomainRef
+		if err := rows.Scan(&ref.ID, &ref.Name, &ref.WorkspaceID); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
@@ -3,8 +3,13 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/eol"
+	"github.com/Abhaythakor/SigMap/internal/search"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 )
 
 type DomainListItem struct {
@@ -17,6 +22,13 @@ type DomainListItem struct {
 	LastSeen     string
 	HighRisk     int
 	MediumRisk   int
+	EOL          int // technologies running an end-of-life version
+	Outdated     int // technologies running an outdated version
+	RiskScore    int // composite, see internal/risk; 0 until scored
+	RiskLevel    string
+	Owner        string
+	Criticality  string
+	Tags         []string // "key" or "key=value"
 }
 
 type TechTag struct {
@@ -30,12 +42,50 @@ type DomainFilters struct {
 	Category     string
 	Confidence   string // High, Medium, Low
 	IsBookmarked bool
+	Dangling     bool // CNAME chain ends in NXDOMAIN
+	Tags         []string // "key" (any value) or "key=value"; all must match
+	Owner        string
+	Criticality  string
+	EOL          string       // "outdated" (outdated or end of life) or "eol"
+	Query        *search.Node // parsed search.DomainFields query
+	Sort         string       // "risk", "version" or "-version"; default most recently updated first
+}
+
+// DomainFilterKeys are the URL parameters of the domain list filters other
+// than the query; saved views store them alongside it.
+var DomainFilterKeys = []string{"search", "category", "confidence", "bookmarked", "dangling", "tag", "owner", "criticality", "eol", "sort"}
+
+// ParseDomainFilters reads the domain list filters from URL parameters. tag
+// may repeat and hold several tags; q is a search.DomainFields query and the
+// only part that can be invalid.
+func ParseDomainFilters(v url.Values) (DomainFilters, error) {
+	f := DomainFilters{
+		Search:       v.Get("search"),
+		Category:     v.Get("category"),
+		Confidence:   v.Get("confidence"),
+		IsBookmarked: v.Get("bookmarked") == "true",
+		Dangling:     v.Get("dangling") == "true",
+		Owner:        strings.TrimSpace(v.Get("owner")),
+		Criticality:  v.Get("criticality"),
+	}
+	if status := v.Get("eol"); status == string(eol.Outdated) || status == string(eol.EndOfLife) {
+		f.EOL = status
+	}
+	if sort := v.Get("sort"); sort == "risk" || sort == "version" || sort == "-version" {
+		f.Sort = sort
+	}
+	for _, raw := range v["tag"] {
+		f.Tags = append(f.Tags, strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool { return r == ',' || r == ' ' })...)
+	}
+	q, err := search.Parse(v.Get("q"), search.DomainFields)
+	f.Query = q
+	return f, err
 }
 
-func (r *DomainRepository) buildListQuery(filters DomainFilters, startArg int) (string, []interface{}) {
-	whereClauses := []string{"1=1"}
-	args := []interface{}{}
-	argCount := startArg
+func (r *DomainRepository) buildListQuery(ctx context.Context, filters DomainFilters, startArg int) (string, []interface{}) {
+	whereClauses := []string{fmt.Sprintf("d.workspace_id = $%d", startArg)}
+	args := []interface{}{workspace.FromContext(ctx)}
+	argCount := startArg + 1
 
 	if filters.Search != "" {
 		whereClauses = append(whereClauses, fmt.Sprintf("d.name ILIKE $%d", argCount))
@@ -47,6 +97,49 @@ func (r *DomainRepository) buildListQuery(filters DomainFilters, startArg int) (
 		whereClauses = append(whereClauses, "d.is_bookmarked = TRUE")
 	}
 
+	if filters.Dangling {
+		whereClauses = append(whereClauses, "EXISTS (SELECT 1 FROM dns_status ds WHERE ds.domain_id = d.id AND ds.dangling)")
+	}
+
+	for _, raw := range filters.Tags {
+		key, value, hasValue := strings.Cut(raw, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if hasValue {
+			whereClauses = append(whereClauses, fmt.Sprintf("EXISTS (SELECT 1 FROM domain_tags dt2 WHERE dt2.domain_id = d.id AND dt2.key = $%d AND dt2.value = $%d)", argCount, argCount+1))
+			args = append(args, key, strings.TrimSpace(value))
+			argCount += 2
+		} else {
+			whereClauses = append(whereClauses, fmt.Sprintf("EXISTS (SELECT 1 FROM domain_tags dt2 WHERE dt2.domain_id = d.id AND dt2.key = $%d)", argCount))
+			args = append(args, key)
+			argCount++
+		}
+	}
+
+	if filters.Owner != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("LOWER(d.owner) = LOWER($%d)", argCount))
+		args = append(args, filters.Owner)
+		argCount++
+	}
+
+	if filters.Criticality != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.criticality = $%d", argCount))
+		args = append(args, filters.Criticality)
+		argCount++
+	}
+
+	if filters.EOL != "" {
+		statuses := []string{string(eol.EndOfLife)}
+		if filters.EOL == string(eol.Outdated) {
+			statuses = append(statuses, string(eol.Outdated))
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("EXISTS (SELECT 1 FROM detections det2 WHERE det2.domain_id = d.id AND det2.eol_status = ANY($%d))", argCount))
+		args = append(args, statuses)
+		argCount++
+	}
+
 	if filters.Category != "" {
 		whereClauses = append(whereClauses, fmt.Sprintf(`EXISTS (
 			SELECT 1 FROM detections det2
@@ -77,14 +170,31 @@ func (r *DomainRepository) buildListQuery(filters DomainFilters, startArg int) (
 		argCount += 2
 	}
 
+	if filters.Query != nil {
+		c := &queryCompiler{argCount: argCount, term: domainTerm}
+		whereClauses = append(whereClauses, c.compile(filters.Query))
+		args = append(args, c.args...)
+		argCount = c.argCount
+	}
+
 	where := strings.Join(whereClauses, " AND ")
 	return where, args
 }
 
 func (r *DomainRepository) List(ctx context.Context, limit, offset int, filters DomainFilters) ([]DomainListItem, error) {
-	where, whereArgs := r.buildListQuery(filters, 3)
+	where, whereArgs := r.buildListQuery(ctx, filters, 3)
 	
 	fullArgs := append([]interface{}{limit, offset}, whereArgs...)
+
+	order := "d.updated_at DESC"
+	switch filters.Sort {
+	case "risk":
+		order = "MAX(dr.score) DESC NULLS LAST, d.updated_at DESC"
+	case "version", "-version":
+		var sortArgs []interface{}
+		order, sortArgs = versionOrder(filters, 3+len(whereArgs))
+		fullArgs = append(fullArgs, sortArgs...)
+	}
 	
 	// Aggregating Tech:Icon:Version
 	query := fmt.Sprintf(`
@@ -95,18 +205,24 @@ func (r *DomainRepository) List(ctx context.Context, limit, offset int, filters
 			COALESCE(AVG(det.confidence), 0)::INT as avg_conf,
 			MAX(det.last_seen) as last_seen,
 			COUNT(DISTINCT CASE WHEN vp.risk_level IN ('High', 'Critical') THEN t.id END) as high_risk,
-			COUNT(DISTINCT CASE WHEN vp.risk_level = 'Medium' THEN t.id END) as med_risk
+			COUNT(DISTINCT CASE WHEN vp.risk_level = 'Medium' THEN t.id END) as med_risk,
+			COUNT(DISTINCT CASE WHEN det.eol_status = 'eol' THEN t.id END) as eol,
+			COUNT(DISTINCT CASE WHEN det.eol_status = 'outdated' THEN t.id END) as outdated,
+			COALESCE(MAX(dr.score), 0), COALESCE(MAX(dr.level), ''),
+			COALESCE(d.owner, ''), COALESCE(d.criticality, ''),
+			`+tagLabels+`
 		FROM domains d
 		LEFT JOIN detections det ON d.id = det.domain_id
 		LEFT JOIN technologies t ON det.technology_id = t.id
 		LEFT JOIN technology_categories tc ON t.id = tc.technology_id
 		LEFT JOIN categories c ON tc.category_id = c.id
 		LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
+		LEFT JOIN domain_risk dr ON dr.domain_id = d.id
 		WHERE %s
 		GROUP BY d.id
-		ORDER BY d.updated_at DESC
+		ORDER BY %s
 		LIMIT $1 OFFSET $2
-	`, where)
+	`, where, order)
 
 	rows, err := r.Pool.Query(ctx, query, fullArgs...)
 	if err != nil {
@@ -119,7 +235,7 @@ func (r *DomainRepository) List(ctx context.Context, limit, offset int, filters
 		var item DomainListItem
 		var rawTechs []string
 		var lastSeen *time.Time
-		err := rows.Scan(&item.ID, &item.Name, &item.IsBookmarked, &rawTechs, &item.Categories, &item.Confidence, &lastSeen, &item.HighRisk, &item.MediumRisk)
+		err := rows.Scan(&item.ID, &item.Name, &item.IsBookmarked, &rawTechs, &item.Categories, &item.Confidence, &lastSeen, &item.HighRisk, &item.MediumRisk, &item.EOL, &item.Outdated, &item.RiskScore, &item.RiskLevel, &item.Owner, &item.Criticality, &item.Tags)
 		if err != nil {
 			return nil, err
 		}
@@ -147,8 +263,46 @@ func (r *DomainRepository) List(ctx context.Context, limit, offset int, filters
 	return items, nil
 }
 
+// versionOrder sorts domains by the oldest (sort=version) or newest
+// (-version) detected version of the query's tech: terms, or of any
+// technology when the query names none. Domains without a readable version
+// go last.
+func versionOrder(filters DomainFilters, startArg int) (string, []interface{}) {
+	c := &queryCompiler{argCount: startArg}
+	var techs []string
+	for _, t := range positiveTerms(filters.Query) {
+		if t.Field == "tech" {
+			techs = append(techs, c.text("t.name", t.Value, true))
+		}
+	}
+	filter := `det.version_key IS NOT NULL`
+	if len(techs) > 0 {
+		filter += " AND (" + strings.Join(techs, " OR ") + ")"
+	}
+	agg, dir := "MIN", "ASC"
+	if filters.Sort == "-version" {
+		agg, dir = "MAX", "DESC"
+	}
+	return fmt.Sprintf(`%s(det.version_key COLLATE "C") FILTER (WHERE %s) %s NULLS LAST, d.updated_at DESC`, agg, filter, dir), c.args
+}
+
+// positiveTerms returns the terms of a query that are not negated.
+func positiveTerms(n *search.Node) []search.Term {
+	if n == nil || n.Op == search.OpNot {
+		return nil
+	}
+	if n.Op == search.OpTerm {
+		return []search.Term{n.Term}
+	}
+	var terms []search.Term
+	for _, c := range n.Children {
+		terms = append(terms, positiveTerms(c)...)
+	}
+	return terms
+}
+
 func (r *DomainRepository) Count(ctx context.Context, filters DomainFilters) (int, error) {
-	where, args := r.buildListQuery(filters, 1)
+	where, args := r.buildListQuery(ctx, filters, 1)
 	query := fmt.Sprintf("SELECT COUNT(*) FROM domains d WHERE %s", where)
 	
 	var count int
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/Abhaythakor/SigMap/internal/models"
+	ver "github.com/Abhaythakor/SigMap/internal/version"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -17,15 +19,46 @@ func NewDomainRepository(pool *pgxpool.Pool) *DomainRepository {
 	return &DomainRepository{Pool: pool}
 }
 
-// EnsureDomain checks if a domain exists, otherwise creates it.
+// EnsureDomain checks if a domain exists in the current workspace, otherwise
+// creates it, links it into the host tree and applies the tag rules.
 func (r *DomainRepository) EnsureDomain(ctx context.Context, name string) (int, error) {
 	var id int
+	var unlinked bool
 	err := r.Pool.QueryRow(ctx, `
-		INSERT INTO domains (name, updated_at)
-		VALUES ($1, CURRENT_TIMESTAMP)
-		ON CONFLICT (name) DO UPDATE SET updated_at = EXCLUDED.updated_at
-		RETURNING id
-	`, name).Scan(&id)
+		INSERT INTO domains (name, workspace_id, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (workspace_id, name) DO UPDATE SET updated_at = EXCLUDED.updated_at
+		RETURNING id, root_domain_id IS NULL
+	`, name, workspace.FromContext(ctx)).Scan(&id, &unlinked)
+	if err != nil || !unlinked {
+		return id, err
+	}
+	if err := r.linkHierarchy(ctx, id, name); err != nil {
+		return id, err
+	}
+	_, err = r.applyTagRules(ctx, r.Pool, id)
+	return id, err
+}
+
+// EnsureDomainFrom ensures a domain like EnsureDomain and records the source
+// it was discovered through.
+func (r *DomainRepository) EnsureDomainFrom(ctx context.Context, name, source string) (int, error) {
+	id, err := r.EnsureDomain(ctx, name)
+	if err != nil {
+		return id, err
+	}
+	_, err = r.Pool.Exec(ctx, `
+		INSERT INTO domain_sources (domain_id, source)
+		VALUES ($1, $2)
+		ON CONFLICT (domain_id, source) DO UPDATE SET last_seen = CURRENT_TIMESTAMP
+	`, id, source)
+	return id, err
+}
+
+// GetDomainIDByName looks up a domain in the current workspace by name.
+func (r *DomainRepository) GetDomainIDByName(ctx context.Context, name string) (int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, "SELECT id FROM domains WHERE name = $1 AND workspace_id = $2", name, workspace.FromContext(ctx)).Scan(&id)
 	return id, err
 }
 
@@ -54,32 +87,73 @@ func (r *DomainRepository) AddDetection(ctx context.Context, domainID int, techN
 	}
 
 	_, err = r.Pool.Exec(ctx, `
-		INSERT INTO detections (domain_id, technology_id, url, version, confidence, source, last_seen)
-		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		INSERT INTO detections (domain_id, technology_id, url, version, version_key, confidence, source, last_seen, workspace_id)
+		SELECT $1, $2, $3, $4, NULLIF($7, ''), $5, $6, CURRENT_TIMESTAMP, workspace_id FROM domains WHERE id = $1
 		ON CONFLICT ON CONSTRAINT unique_detection DO UPDATE SET 
 			last_seen = EXCLUDED.last_seen,
 			confidence = EXCLUDED.confidence,
-			url = EXCLUDED.url
-	`, domainID, techID, url, version, confidence, source)
+			url = EXCLUDED.url,
+			-- Observing a technology that was only inferred replaces the inference
+			version = CASE WHEN detections.inferred_from IS NULL THEN detections.version ELSE EXCLUDED.version END,
+			version_key = CASE WHEN detections.inferred_from IS NULL THEN detections.version_key ELSE EXCLUDED.version_key END,
+			source = CASE WHEN detections.inferred_from IS NULL THEN detections.source ELSE EXCLUDED.source END,
+			inferred_from = NULL
+	`, domainID, techID, url, version, confidence, source, ver.Key(version))
 	
 	return err
 }
 
+// BackfillVersionKeys fills in the version key of detections stored before
+// it existed, one distinct version at a time.
+func (r *DomainRepository) BackfillVersionKeys(ctx context.Context) (int64, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT DISTINCT version FROM detections WHERE version_key IS NULL AND version <> ''`)
+	if err != nil {
+		return 0, err
+	}
+	var pending []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for _, v := range pending {
+		key := ver.Key(v)
+		if key == "" {
+			continue
+		}
+		tag, err := r.Pool.Exec(ctx, `UPDATE detections SET version_key = $2 WHERE version = $1 AND version_key IS NULL`, v, key)
+		if err != nil {
+			return updated, err
+		}
+		updated += tag.RowsAffected()
+	}
+	return updated, nil
+}
+
 // ToggleBookmark toggles the is_bookmarked status of a domain.
 func (r *DomainRepository) ToggleBookmark(ctx context.Context, id int) (bool, error) {
 	var isBookmarked bool
 	err := r.Pool.QueryRow(ctx, `
 		UPDATE domains 
 		SET is_bookmarked = NOT is_bookmarked, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		WHERE id = $1 AND workspace_id = $2
 		RETURNING is_bookmarked
-	`, id).Scan(&isBookmarked)
+	`, id, workspace.FromContext(ctx)).Scan(&isBookmarked)
 	return isBookmarked, err
 }
 
 // ListAlertChannels returns all configured notification channels.
 func (r *DomainRepository) ListAlertChannels(ctx context.Context) ([]models.AlertChannel, error) {
-	rows, err := r.Pool.Query(ctx, "SELECT id, name, type, url, is_active, created_at FROM alert_channels ORDER BY created_at DESC")
+	rows, err := r.Pool.Query(ctx, "SELECT id, name, type, url, is_active, created_at, route_tags, COALESCE(min_criticality, '') FROM alert_channels WHERE workspace_id = $1 ORDER BY created_at DESC", workspace.FromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -88,31 +162,55 @@ func (r *DomainRepository) ListAlertChannels(ctx context.Context) ([]models.Aler
 	var channels []models.AlertChannel
 	for rows.Next() {
 		var c models.AlertChannel
-		if err := rows.Scan(&c.ID, &c.Name, &c.Type, &c.URL, &c.IsActive, &c.CreatedAt); err == nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Type, &c.URL, &c.IsActive, &c.CreatedAt, &c.RouteTags, &c.MinCriticality); err == nil {
 			channels = append(channels, c)
 		}
 	}
 	return channels, nil
 }
 
-// AddAlertChannel adds a new notification channel.
-func (r *DomainRepository) AddAlertChannel(ctx context.Context, name, cType, url string) error {
-	_, err := r.Pool.Exec(ctx, "INSERT INTO alert_channels (name, type, url) VALUES ($1, $2, $3)", name, cType, url)
+// GetAlertChannel returns a notification channel of the current workspace.
+func (r *DomainRepository) GetAlertChannel(ctx context.Context, id int) (models.AlertChannel, error) {
+	var c models.AlertChannel
+	err := r.Pool.QueryRow(ctx, "SELECT id, name, type, url, is_active, created_at, route_tags, COALESCE(min_criticality, '') FROM alert_channels WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx)).
+		Scan(&c.ID, &c.Name, &c.Type, &c.URL, &c.IsActive, &c.CreatedAt, &c.RouteTags, &c.MinCriticality)
+	return c, err
+}
+
+// AddAlertChannel adds a new notification channel, routed by its tags and
+// minimum criticality.
+func (r *DomainRepository) AddAlertChannel(ctx context.Context, c models.AlertChannel) error {
+	if c.RouteTags == nil {
+		c.RouteTags = []string{}
+	}
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO alert_channels (name, type, url, workspace_id, route_tags, min_criticality)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	`, c.Name, c.Type, c.URL, workspace.FromContext(ctx), c.RouteTags, c.MinCriticality)
 	return err
 }
 
 // DeleteAlertChannel removes a notification channel.
 func (r *DomainRepository) DeleteAlertChannel(ctx context.Context, id int) error {
-	_, err := r.Pool.Exec(ctx, "DELETE FROM alert_channels WHERE id = $1", id)
+	_, err := r.Pool.Exec(ctx, "DELETE FROM alert_channels WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx))
 	return err
 }
 
 // CreateNote adds a note to a domain.
 func (r *DomainRepository) CreateNote(ctx context.Context, domainID int, content string, author string) error {
 	_, err := r.Pool.Exec(ctx, `
-		INSERT INTO notes (domain_id, content, author, updated_at)
-		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
-	`, domainID, content, author)
+		INSERT INTO notes (domain_id, content, author, updated_at, workspace_id)
+		SELECT $1, $2, $3, CURRENT_TIMESTAMP, workspace_id FROM domains WHERE id = $1 AND workspace_id = $4
+	`, domainID, content, author, workspace.FromContext(ctx))
+	return err
+}
+
+// CreateTechnologyNote adds a note to a technology in the current workspace.
+func (r *DomainRepository) CreateTechnologyNote(ctx context.Context, technologyID int, content string, author string) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO notes (technology_id, content, author, updated_at, workspace_id)
+		SELECT $1, $2, $3, CURRENT_TIMESTAMP, $4 FROM technologies WHERE id = $1
+	`, technologyID, content, author, workspace.FromContext(ctx))
 	return err
 }
 
@@ -127,21 +225,21 @@ func (r *DomainRepository) GetNoteByID(ctx context.Context, id int) (NoteListIte
 		FROM notes n
 		LEFT JOIN domains d ON n.domain_id = d.id
 		LEFT JOIN technologies t ON n.technology_id = t.id
-		WHERE n.id = $1
-	`, id).Scan(&item.ID, &item.Target, &item.Type, &item.Content, &item.Author, &item.UpdatedAt)
+		WHERE n.id = $1 AND n.workspace_id = $2
+	`, id, workspace.FromContext(ctx)).Scan(&item.ID, &item.Target, &item.Type, &item.Content, &item.Author, &item.UpdatedAt)
 	return item, err
 }
 
 // UpdateNote updates an existing note.
 func (r *DomainRepository) UpdateNote(ctx context.Context, id int, content string) error {
 	_, err := r.Pool.Exec(ctx, `
-		UPDATE notes SET content = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
-	`, content, id)
+		UPDATE notes SET content = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND workspace_id = $3
+	`, content, id, workspace.FromContext(ctx))
 	return err
 }
 
 // DeleteNote removes a note.
 func (r *DomainRepository) DeleteNote(ctx context.Context, id int) error {
-	_, err := r.Pool.Exec(ctx, "DELETE FROM notes WHERE id = $1", id)
+	_, err := r.Pool.Exec(ctx, "DELETE FROM notes WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx))
 	return err
 }
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// execer is a pool or a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// tagRuleMatch is the SQL condition for a tag rule pattern matching a host
+// name: equal to it, or for "*.example.com" example.com and anything below.
+func tagRuleMatch(name, pattern string) string {
+	return fmt.Sprintf(`(%[1]s = %[2]s OR (%[2]s LIKE '*.%%' AND (%[1]s = substr(%[2]s, 3) OR right(%[1]s, length(%[2]s) - 1) = substr(%[2]s, 2))))`, name, pattern)
+}
+
+// tagLabels lists a domain's tags as "key" or "key=value" strings.
+const tagLabels = `ARRAY(SELECT CASE WHEN dt.value = '' THEN dt.key ELSE dt.key || '=' || dt.value END FROM domain_tags dt WHERE dt.domain_id = d.id ORDER BY dt.key)`
+
+// DomainEdit is a change to the metadata of one or more domains. Nil fields
+// are left alone; an empty Owner or Criticality clears it.
+type DomainEdit struct {
+	AddTags     []models.Tag
+	RemoveKeys  []string
+	Owner       *string
+	Criticality *string
+}
+
+// EditDomains applies an edit to the given domains of the current workspace
+// and returns how many were changed. Tags added by hand replace rule tags of
+// the same key.
+func (r *DomainRepository) EditDomains(ctx context.Context, ids []int, edit DomainEdit) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "SELECT id FROM domains WHERE id = ANY($1) AND workspace_id = $2", ids, workspace.FromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	var scoped []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			scoped = append(scoped, id)
+		}
+	}
+	rows.Close()
+	if len(scoped) == 0 {
+		return 0, nil
+	}
+
+	if edit.Owner != nil {
+		if _, err := tx.Exec(ctx, "UPDATE domains SET owner = NULLIF($2, '') WHERE id = ANY($1)", scoped, *edit.Owner); err != nil {
+			return 0, err
+		}
+	}
+	if edit.Criticality != nil {
+		if _, err := tx.Exec(ctx, "UPDATE domains SET criticality = NULLIF($2, '') WHERE id = ANY($1)", scoped, *edit.Criticality); err != nil {
+			return 0, err
+		}
+	}
+	if len(edit.RemoveKeys) > 0 {
+		if _, err := tx.Exec(ctx, "DELETE FROM domain_tags WHERE domain_id = ANY($1) AND key = ANY($2)", scoped, edit.RemoveKeys); err != nil {
+			return 0, err
+		}
+	}
+	for _, t := range edit.AddTags {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO domain_tags (domain_id, key, value)
+			SELECT unnest($1::int[]), $2, $3
+			ON CONFLICT (domain_id, key) DO UPDATE SET value = EXCLUDED.value, rule_id = NULL
+		`, scoped, t.Key, t.Value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(scoped), tx.Commit(ctx)
+}
+
+// MatchingDomainIDs returns the IDs of every domain matching the list filters.
+func (r *DomainRepository) MatchingDomainIDs(ctx context.Context, filters DomainFilters) ([]int, error) {
+	where, args := r.buildListQuery(ctx, filters, 1)
+	rows, err := r.Pool.Query(ctx, fmt.Sprintf("SELECT d.id FROM domains d WHERE %s", where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MatchingDomains returns the names of every domain matching the list
+// filters, keyed by ID.
+func (r *DomainRepository) MatchingDomains(ctx context.Context, filters DomainFilters) (map[int]string, error) {
+	where, args := r.buildListQuery(ctx, filters, 1)
+	rows, err := r.Pool.Query(ctx, fmt.Sprintf("SELECT d.id, d.name FROM domains d WHERE %s", where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	domains := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		domains[id] = name
+	}
+	return domains, rows.Err()
+}
+
+// ListTagKeys returns the tag keys in use in the current workspace, for
+// filter suggestions.
+func (r *DomainRepository) ListTagKeys(ctx context.Context) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT DISTINCT dt.key FROM domain_tags dt
+		JOIN domains d ON d.id = dt.domain_id
+		WHERE d.workspace_id = $1
+		ORDER BY dt.key
+	`, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// ListTagRules returns the current workspace's tag rules with the number of
+// hosts each has tagged.
+func (r *DomainRepository) ListTagRules(ctx context.Context) ([]models.TagRule, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT tr.id, tr.pattern, tr.key, tr.value, tr.created_at,
+			(SELECT COUNT(*) FROM domain_tags dt WHERE dt.rule_id = tr.id)
+		FROM tag_rules tr
+		WHERE tr.workspace_id = $1
+		ORDER BY tr.pattern, tr.key
+	`, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []models.TagRule
+	for rows.Next() {
+		var tr models.TagRule
+		if err := rows.Scan(&tr.ID, &tr.Pattern, &tr.Tag.Key, &tr.Tag.Value, &tr.CreatedAt, &tr.Matches); err != nil {
+			return nil, err
+		}
+		rules = append(rules, tr)
+	}
+	return rules, rows.Err()
+}
+
+// GetTagRule returns a tag rule of the current workspace.
+func (r *DomainRepository) GetTagRule(ctx context.Context, id int) (models.TagRule, error) {
+	var tr models.TagRule
+	err := r.Pool.QueryRow(ctx, `
+		SELECT id, pattern, key, value, created_at FROM tag_rules WHERE id = $1 AND workspace_id = $2
+	`, id, workspace.FromContext(ctx)).Scan(&tr.ID, &tr.Pattern, &tr.Tag.Key, &tr.Tag.Value, &tr.CreatedAt)
+	return tr, err
+}
+
+// AddTagRule creates or updates a tag rule and applies it to existing hosts.
+// It returns the rule ID and the number of hosts tagged.
+func (r *DomainRepository) AddTagRule(ctx context.Context, pattern string, tag models.Tag) (int, int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO tag_rules (workspace_id, pattern, key, value)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id, pattern, key) DO UPDATE SET value = EXCLUDED.value
+		RETURNING id
+	`, workspace.FromContext(ctx), pattern, tag.Key, tag.Value).Scan(&id)
+	if err != nil {
+		return 0, 0, err
+	}
+	n, err := r.applyTagRules(ctx, r.Pool, 0)
+	return id, n, err
+}
+
+// DeleteTagRule removes a tag rule together with the tags it applied, then
+// re-applies the remaining rules so hosts fall back to the next matching
+// one: deleting *.staging.example.com env=staging leaves its hosts with
+// env=prod from *.example.com.
+func (r *DomainRepository) DeleteTagRule(ctx context.Context, id int) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM tag_rules WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx)); err != nil {
+		return err
+	}
+	if _, err := r.applyTagRules(ctx, tx, 0); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// applyTagRules tags hosts of the current workspace with every matching
+// rule, or only domainID when it is non-zero. When several rules set the same
+// key the most specific pattern wins. Tags set by hand are left alone.
+func (r *DomainRepository) applyTagRules(ctx context.Context, db execer, domainID int) (int, error) {
+	tag, err := db.Exec(ctx, `
+		INSERT INTO domain_tags (domain_id, key, value, rule_id)
+		SELECT DISTINCT ON (d.id, tr.key) d.id, tr.key, tr.value, tr.id
+		FROM domains d
+		JOIN tag_rules tr ON tr.workspace_id = d.workspace_id AND `+tagRuleMatch("d.name", "tr.pattern")+`
+		WHERE d.workspace_id = $1 AND ($2 = 0 OR d.id = $2)
+		ORDER BY d.id, tr.key, length(tr.pattern) DESC, tr.id
+		ON CONFLICT (domain_id, key) DO UPDATE SET value = EXCLUDED.value, rule_id = EXCLUDED.rule_id
+		WHERE domain_tags.rule_id IS NOT NULL
+	`, workspace.FromContext(ctx), domainID)
+	return int(tag.RowsAffected()), err
+}
+
+// ListDomainTags returns the tags of a domain, by key.
+func (r *DomainRepository) ListDomainTags(ctx context.Context, domainID int) ([]models.Tag, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT key, value, rule_id FROM domain_tags WHERE domain_id = $1 ORDER BY key", domainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []models.Tag
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.Key, &t.Value, &t.RuleID); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+)
+
+// fillTLS loads the latest handshake on each TLS port of a host together
+// with the chain it served.
+func (r *DomainRepository) fillTLS(ctx context.Context, d *DomainDetail) {
+	rows, _ := r.Pool.Query(ctx, `
+		SELECT id, port, COALESCE(host(address), ''), COALESCE(tls_version, ''), COALESCE(cipher_suite, ''),
+			COALESCE(verify_error, ''), COALESCE(error, ''), checked_at
+		FROM tls_endpoints
+		WHERE domain_id = $1
+		ORDER BY port
+	`, d.ID)
+	if rows == nil {
+		return
+	}
+	index := map[int]int{}
+	for rows.Next() {
+		e := models.TLSEndpoint{DomainID: d.ID, DomainName: d.Name}
+		if err := rows.Scan(&e.ID, &e.Port, &e.Address, &e.Version, &e.CipherSuite, &e.VerifyError, &e.Error, &e.CheckedAt); err == nil {
+			index[e.ID] = len(d.TLS)
+			d.TLS = append(d.TLS, e)
+		}
+	}
+	rows.Close()
+	if len(d.TLS) == 0 {
+		return
+	}
+
+	rowsChain, _ := r.Pool.Query(ctx, `
+		SELECT `+certColumns+`, ec.endpoint_id
+		FROM tls_endpoint_certificates ec
+		JOIN certificates c ON c.id = ec.certificate_id
+		JOIN tls_endpoints e ON e.id = ec.endpoint_id
+		WHERE e.domain_id = $1
+		ORDER BY ec.endpoint_id, ec.position
+	`, d.ID)
+	if rowsChain == nil {
+		return
+	}
+	defer rowsChain.Close()
+	for rowsChain.Next() {
+		var c models.Certificate
+		var endpointID int
+		if err := scanCertificate(rowsChain, &c, &endpointID); err != nil {
+			continue
+		}
+		if i, ok := index[endpointID]; ok {
+			d.TLS[i].Chain = append(d.TLS[i].Chain, c)
+		}
+	}
+}
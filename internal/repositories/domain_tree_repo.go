@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/publicsuffix"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5"
+)
+
+// DomainNode is a host in the parent/child tree.
+type DomainNode struct {
+	ID       int
+	Name     string
+	Children int
+}
+
+// ancestorNames lists the names between a host and its root domain, nearest
+// first: "a.b.example.com" under "example.com" gives b.example.com, example.com.
+func ancestorNames(name, root string) []string {
+	var names []string
+	for name != root {
+		i := strings.Index(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+		names = append(names, name)
+	}
+	return names
+}
+
+// linkHierarchy attaches a domain to its root domain and nearest known
+// ancestor, then adopts descendants that pointed past it. Names without a
+// registrable domain (bare suffixes, single labels) are left unlinked.
+func (r *DomainRepository) linkHierarchy(ctx context.Context, id int, name string) error {
+	name = strings.ToLower(name)
+	root, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return nil
+	}
+	wsID := workspace.FromContext(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var rootID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO root_domains (workspace_id, name, public_suffix)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, name) DO UPDATE SET public_suffix = EXCLUDED.public_suffix
+		RETURNING id
+	`, wsID, root, publicsuffix.PublicSuffix(root)).Scan(&rootID)
+	if err != nil {
+		return err
+	}
+
+	var parentID *int
+	if ancestors := ancestorNames(name, root); len(ancestors) > 0 {
+		var pid int
+		err = tx.QueryRow(ctx, `
+			SELECT id FROM domains
+			WHERE workspace_id = $1 AND name = ANY($2)
+			ORDER BY length(name) DESC LIMIT 1
+		`, wsID, ancestors).Scan(&pid)
+		if err == nil {
+			parentID = &pid
+		} else if err != pgx.ErrNoRows {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE domains SET root_domain_id = $2, parent_id = $3 WHERE id = $1", id, rootID, parentID); err != nil {
+		return err
+	}
+
+	// Descendants whose nearest ancestor was above this host now hang below it.
+	_, err = tx.Exec(ctx, `
+		UPDATE domains SET parent_id = $1
+		WHERE root_domain_id = $2 AND id <> $1
+		  AND parent_id IS NOT DISTINCT FROM $3
+		  AND right(name, length($4::text) + 1) = '.' || $4::text
+	`, id, rootID, parentID, name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LinkUnlinkedDomains links every domain that has no root domain yet, in all
+// workspaces. It backfills domains created before the asset graph existed.
+func (r *DomainRepository) LinkUnlinkedDomains(ctx context.Context) (int, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT id, name, workspace_id FROM domains WHERE root_domain_id IS NULL ORDER BY length(name), id")
+	if err != nil {
+		return 0, err
+	}
+	type unlinked struct {
+		id, workspaceID int
+		name            string
+	}
+	var pending []unlinked
+	for rows.Next() {
+		var u unlinked
+		if err := rows.Scan(&u.id, &u.name, &u.workspaceID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, u := range pending {
+		if err := r.linkHierarchy(workspace.WithID(ctx, u.workspaceID), u.id, u.name); err != nil {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// HasRootDomain reports whether a root domain is already tracked in the
+// current workspace.
+func (r *DomainRepository) HasRootDomain(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := r.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM root_domains WHERE workspace_id = $1 AND name = $2)", workspace.FromContext(ctx), name).Scan(&exists)
+	return exists, err
+}
+
+// RootDomainNames returns the root domains tracked in the current workspace.
+func (r *DomainRepository) RootDomainNames(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT name FROM root_domains WHERE workspace_id = $1", workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// ListDomainsUnder returns a domain and every known host below it in the
+// current workspace.
+func (r *DomainRepository) ListDomainsUnder(ctx context.Context, name string) ([]string, error) {
+	name = strings.ToLower(name)
+	root, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return []string{name}, nil
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+		SELECT d.name FROM domains d
+		JOIN root_domains rd ON rd.id = d.root_domain_id
+		WHERE rd.workspace_id = $1 AND rd.name = $2
+		  AND (d.name = $3 OR right(d.name, length($3::text) + 1) = '.' || $3::text)
+		ORDER BY d.name ASC
+	`, workspace.FromContext(ctx), root, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// fillTree loads a domain's root domain, ancestor chain, direct children
+// and resolved IPs.
+func (r *DomainRepository) fillTree(ctx context.Context, d *DomainDetail) {
+	r.Pool.QueryRow(ctx, `
+		SELECT rd.id, rd.name, rd.public_suffix, rd.created_at
+		FROM domains d JOIN root_domains rd ON rd.id = d.root_domain_id
+		WHERE d.id = $1
+	`, d.ID).Scan(&d.Root.ID, &d.Root.Name, &d.Root.PublicSuffix, &d.Root.CreatedAt)
+
+	rowsUp, _ := r.Pool.Query(ctx, `
+		WITH RECURSIVE up AS (
+			SELECT p.id, p.name, p.parent_id, 1 AS depth
+			FROM domains c JOIN domains p ON p.id = c.parent_id
+			WHERE c.id = $1
+			UNION ALL
+			SELECT p.id, p.name, p.parent_id, up.depth + 1
+			FROM up JOIN domains p ON p.id = up.parent_id
+			WHERE up.depth < 64
+		)
+		SELECT id, name FROM up ORDER BY depth DESC
+	`, d.ID)
+	if rowsUp != nil {
+		defer rowsUp.Close()
+		for rowsUp.Next() {
+			var n DomainNode
+			if err := rowsUp.Scan(&n.ID, &n.Name); err == nil {
+				d.Ancestors = append(d.Ancestors, n)
+			}
+		}
+	}
+
+	rowsDown, _ := r.Pool.Query(ctx, `
+		SELECT c.id, c.name, (SELECT COUNT(*) FROM domains g WHERE g.parent_id = c.id)
+		FROM domains c
+		WHERE c.parent_id = $1
+		ORDER BY c.name ASC
+		LIMIT 500
+	`, d.ID)
+	if rowsDown != nil {
+		defer rowsDown.Close()
+		for rowsDown.Next() {
+			var n DomainNode
+			if err := rowsDown.Scan(&n.ID, &n.Name, &n.Children); err == nil {
+				d.Children = append(d.Children, n)
+			}
+		}
+	}
+
+	rowsIPs, _ := r.Pool.Query(ctx, `
+		SELECT di.domain_id, i.id, host(i.address), di.record_type, di.first_seen, di.last_seen
+		FROM domain_ips di
+		JOIN ip_addresses i ON i.id = di.ip_id
+		WHERE di.domain_id = $1
+		ORDER BY di.last_seen DESC, i.address ASC
+	`, d.ID)
+	if rowsIPs != nil {
+		defer rowsIPs.Close()
+		for rowsIPs.Next() {
+			res := models.Resolution{DomainName: d.Name}
+			if err := rowsIPs.Scan(&res.DomainID, &res.IPID, &res.Address, &res.RecordType, &res.FirstSeen, &res.LastSeen); err == nil {
+				d.IPs = append(d.IPs, res)
+			}
+		}
+	}
+}
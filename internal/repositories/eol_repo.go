@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/eol"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EOLRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewEOLRepository(pool *pgxpool.Pool) *EOLRepository {
+	return &EOLRepository{Pool: pool}
+}
+
+// ReplaceProduct stores the release cycles of a product in place of the
+// ones imported before.
+func (r *EOLRepository) ReplaceProduct(ctx context.Context, product, source string, cycles []eol.Cycle) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO eol_products (product, source, imported_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (product) DO UPDATE SET source = EXCLUDED.source, imported_at = EXCLUDED.imported_at
+	`, product, source)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM eol_cycles WHERE product = $1`, product); err != nil {
+		return err
+	}
+	for _, c := range cycles {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO eol_cycles (product, cycle, release_date, eol_date, eol, latest, lts)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+			ON CONFLICT (product, cycle) DO NOTHING
+		`, product, c.Cycle, c.ReleaseDate, c.EOLDate, c.EOL, c.Latest, c.LTS)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Products lists the imported products with the technologies mapped to
+// them.
+func (r *EOLRepository) Products(ctx context.Context) ([]models.EOLProduct, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT p.product, p.source, p.imported_at,
+			(SELECT COUNT(*) FROM eol_cycles c WHERE c.product = p.product),
+			COALESCE((SELECT array_agg(m.technology ORDER BY m.technology) FROM eol_mappings m WHERE m.product = p.product), '{}')
+		FROM eol_products p
+		ORDER BY p.product
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.EOLProduct
+	for rows.Next() {
+		var p models.EOLProduct
+		if err := rows.Scan(&p.Product, &p.Source, &p.ImportedAt, &p.CycleCount, &p.Technologies); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// Cycles returns a product's release cycles, newest release first.
+func (r *EOLRepository) Cycles(ctx context.Context, product string) ([]eol.Cycle, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT cycle, release_date, eol_date, eol, COALESCE(latest, ''), lts
+		FROM eol_cycles WHERE product = $1
+		ORDER BY release_date DESC NULLS LAST, cycle DESC
+	`, product)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []eol.Cycle
+	for rows.Next() {
+		var c eol.Cycle
+		if err := rows.Scan(&c.Cycle, &c.ReleaseDate, &c.EOLDate, &c.EOL, &c.Latest, &c.LTS); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Mappings lists technology to product mappings by technology.
+func (r *EOLRepository) Mappings(ctx context.Context) ([]models.EOLMapping, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT m.technology, m.product, EXISTS (SELECT 1 FROM eol_products p WHERE p.product = m.product)
+		FROM eol_mappings m
+		ORDER BY m.technology
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.EOLMapping
+	for rows.Next() {
+		var m models.EOLMapping
+		if err := rows.Scan(&m.Technology, &m.Product, &m.Imported); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// MappedProducts lists the distinct products technologies are mapped to.
+func (r *EOLRepository) MappedProducts(ctx context.Context) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT DISTINCT product FROM eol_mappings ORDER BY product`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetMapping maps a technology to a product, replacing its mapping.
+func (r *EOLRepository) SetMapping(ctx context.Context, technology, product string) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO eol_mappings (technology, product) VALUES ($1, $2)
+		ON CONFLICT (technology) DO UPDATE SET product = EXCLUDED.product
+	`, technology, product)
+	return err
+}
+
+func (r *EOLRepository) DeleteMapping(ctx context.Context, technology string) error {
+	_, err := r.Pool.Exec(ctx, `DELETE FROM eol_mappings WHERE technology = $1`, technology)
+	return err
+}
+
+// EOLTarget is a distinct detected version of a technology mapped to an
+// imported product.
+type EOLTarget struct {
+	TechnologyID int
+	Product      string
+	Version      string
+}
+
+// Targets lists the versions to judge, across workspaces.
+func (r *EOLRepository) Targets(ctx context.Context) ([]EOLTarget, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT DISTINCT det.technology_id, m.product, det.version
+		FROM detections det
+		JOIN technologies t ON t.id = det.technology_id
+		JOIN eol_mappings m ON m.technology = t.name
+		JOIN eol_products p ON p.product = m.product
+		WHERE COALESCE(det.version, '') <> ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []EOLTarget
+	for rows.Next() {
+		var t EOLTarget
+		if err := rows.Scan(&t.TechnologyID, &t.Product, &t.Version); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// SetStatus records the verdict on every detection of a technology at a
+// version; a nil result clears it.
+func (r *EOLRepository) SetStatus(ctx context.Context, techID int, version string, res *eol.Result) error {
+	info := models.EOLInfo{}
+	var status *string
+	if res != nil {
+		s := string(res.Status)
+		status = &s
+		info = models.EOLInfo{Cycle: res.Cycle, Date: res.EOLDate, MajorsBehind: res.MajorsBehind, MinorsBehind: res.MinorsBehind}
+	}
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE detections SET eol_status = $3, eol_cycle = NULLIF($4, ''), eol_date = $5, majors_behind = $6, minors_behind = $7
+		WHERE technology_id = $1 AND version = $2
+			AND (eol_status IS DISTINCT FROM $3 OR eol_cycle IS DISTINCT FROM NULLIF($4, '') OR eol_date IS DISTINCT FROM $5
+				OR majors_behind <> $6 OR minors_behind <> $7)
+	`, techID, version, status, info.Cycle, info.Date, info.MajorsBehind, info.MinorsBehind)
+	return err
+}
+
+// ClearStale removes verdicts from detections that no longer have a
+// version, or whose technology is no longer mapped to an imported product.
+func (r *EOLRepository) ClearStale(ctx context.Context) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE detections det SET eol_status = NULL, eol_cycle = NULL, eol_date = NULL, majors_behind = 0, minors_behind = 0
+		WHERE det.eol_status IS NOT NULL AND (
+			COALESCE(det.version, '') = ''
+			OR NOT EXISTS (
+				SELECT 1 FROM technologies t
+				JOIN eol_mappings m ON m.technology = t.name
+				JOIN eol_products p ON p.product = m.product
+				WHERE t.id = det.technology_id
+			)
+		)
+	`)
+	return err
+}
@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FingerprintRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewFingerprintRepository(pool *pgxpool.Pool) *FingerprintRepository {
+	return &FingerprintRepository{Pool: pool}
+}
+
+// LoadAll returns the stored matchers of every technology, keyed by name.
+func (r *FingerprintRepository) LoadAll(ctx context.Context) (map[string]fingerprint.Fingerprint, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT t.name, f.matchers
+		FROM technology_fingerprints f
+		JOIN technologies t ON t.id = f.technology_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fps := map[string]fingerprint.Fingerprint{}
+	for rows.Next() {
+		var name string
+		var raw []byte
+		if err := rows.Scan(&name, &raw); err != nil {
+			return nil, err
+		}
+		var fp fingerprint.Fingerprint
+		if err := json.Unmarshal(raw, &fp); err != nil {
+			return nil, fmt.Errorf("fingerprint for %s: %w", name, err)
+		}
+		fps[name] = fp
+	}
+	return fps, rows.Err()
+}
+
+// Stamp identifies the current set of stored fingerprints, so a cached
+// engine can tell when a sync has replaced it.
+func (r *FingerprintRepository) Stamp(ctx context.Context) (string, error) {
+	var count int
+	var latest *time.Time
+	err := r.Pool.QueryRow(ctx, `SELECT COUNT(*), MAX(updated_at) FROM technology_fingerprints`).Scan(&count, &latest)
+	if err != nil {
+		return "", err
+	}
+	if latest == nil {
+		return fmt.Sprint(count), nil
+	}
+	return fmt.Sprintf("%d@%d", count, latest.UnixNano()), nil
+}
+
+// CustomFingerprint is a technology whose signature is maintained in SigMap
+// rather than synced. ID is the technology's.
+type CustomFingerprint struct {
+	ID          int                     `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Website     string                  `json:"website"`
+	Fingerprint fingerprint.Fingerprint `json:"fingerprint"`
+	Revision    int                     `json:"revision"`
+	UpdatedBy   string                  `json:"updated_by"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	DomainCount int                     `json:"domain_count"` // in the current workspace
+}
+
+// FingerprintRevision is one saved state of a custom fingerprint.
+type FingerprintRevision struct {
+	Revision    int                     `json:"revision"`
+	Description string                  `json:"description"`
+	Website     string                  `json:"website"`
+	Fingerprint fingerprint.Fingerprint `json:"fingerprint"`
+	CreatedBy   string                  `json:"created_by"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+const customFingerprintQuery = `
+	SELECT t.id, t.name, COALESCE(t.description, ''), COALESCE(t.website, ''), f.matchers,
+		r.revision, r.created_by, r.created_at,
+		(SELECT COUNT(DISTINCT domain_id) FROM detections WHERE technology_id = t.id AND workspace_id = $1)
+	FROM technologies t
+	JOIN technology_fingerprints f ON f.technology_id = t.id
+	JOIN LATERAL (
+		SELECT revision, created_by, created_at FROM technology_fingerprint_revisions
+		WHERE technology_id = t.id ORDER BY revision DESC LIMIT 1
+	) r ON TRUE
+	WHERE t.source = 'custom'`
+
+func scanCustomFingerprint(row rowScanner) (*CustomFingerprint, error) {
+	var c CustomFingerprint
+	var raw []byte
+	if err := row.Scan(&c.ID, &c.Name, &c.Description, &c.Website, &raw, &c.Revision, &c.UpdatedBy, &c.UpdatedAt, &c.DomainCount); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &c.Fingerprint); err != nil {
+		return nil, fmt.Errorf("fingerprint for %s: %w", c.Name, err)
+	}
+	return &c, nil
+}
+
+// ListCustom returns the custom fingerprints by name.
+func (r *FingerprintRepository) ListCustom(ctx context.Context) ([]CustomFingerprint, error) {
+	rows, err := r.Pool.Query(ctx, customFingerprintQuery+` ORDER BY t.name`, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CustomFingerprint
+	for rows.Next() {
+		c, err := scanCustomFingerprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *FingerprintRepository) GetCustom(ctx context.Context, id int) (*CustomFingerprint, error) {
+	return scanCustomFingerprint(r.Pool.QueryRow(ctx, customFingerprintQuery+` AND t.id = $2`, workspace.FromContext(ctx), id))
+}
+
+// SaveCustom stores a custom fingerprint as a new revision. With id 0 it
+// creates one by name; a synced technology of that name becomes custom and
+// keeps its detections. It returns the technology ID and the revision.
+func (r *FingerprintRepository) SaveCustom(ctx context.Context, id int, name, description, website string, fp fingerprint.Fingerprint, createdBy string) (int, int, error) {
+	matchers, err := json.Marshal(fp.Matchers())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if id == 0 {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO technologies (name, description, website, source)
+			VALUES ($1, $2, $3, 'custom')
+			ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, website = EXCLUDED.website, source = 'custom', updated_at = NOW()
+			RETURNING id
+		`, name, description, website).Scan(&id)
+	} else {
+		err = tx.QueryRow(ctx, `
+			UPDATE technologies SET name = $2, description = $3, website = $4, updated_at = NOW()
+			WHERE id = $1 AND source = 'custom'
+			RETURNING id
+		`, id, name, description, website).Scan(&id)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO technology_fingerprints (technology_id, matchers)
+		VALUES ($1, $2)
+		ON CONFLICT (technology_id) DO UPDATE SET matchers = EXCLUDED.matchers, updated_at = NOW()
+	`, id, matchers)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM technology_categories WHERE technology_id = $1`, id); err != nil {
+		return 0, 0, err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO technology_categories (technology_id, category_id)
+		SELECT $1, id FROM categories WHERE id = ANY($2)
+	`, id, fp.Cats)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := ReplaceRelations(ctx, tx, id, fp); err != nil {
+		return 0, 0, err
+	}
+
+	var revision int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO technology_fingerprint_revisions (technology_id, revision, matchers, description, website, created_by)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+		FROM technology_fingerprint_revisions WHERE technology_id = $1
+		RETURNING revision
+	`, id, matchers, description, website, createdBy).Scan(&revision)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, revision, tx.Commit(ctx)
+}
+
+// Revisions returns a custom fingerprint's history, newest first.
+func (r *FingerprintRepository) Revisions(ctx context.Context, id int) ([]FingerprintRevision, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT revision, description, website, matchers, created_by, created_at
+		FROM technology_fingerprint_revisions
+		WHERE technology_id = $1
+		ORDER BY revision DESC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FingerprintRevision
+	for rows.Next() {
+		var rev FingerprintRevision
+		var raw []byte
+		if err := rows.Scan(&rev.Revision, &rev.Description, &rev.Website, &raw, &rev.CreatedBy, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &rev.Fingerprint); err != nil {
+			return nil, err
+		}
+		out = append(out, rev)
+	}
+	return out, rows.Err()
+}
+
+// DeleteCustom drops a custom fingerprint and its history. The technology
+// and its detections stay; it reverts to a synced one, so an upstream
+// entry of the same name returns with the next sync.
+func (r *FingerprintRepository) DeleteCustom(ctx context.Context, id int) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE technologies SET source = 'wappalyzer', updated_at = NOW() WHERE id = $1 AND source = 'custom'`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM technology_fingerprints WHERE technology_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM technology_fingerprint_revisions WHERE technology_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM technology_relations WHERE technology_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 )
 
 type NoteListItem struct {
@@ -25,10 +26,11 @@ func (r *DomainRepository) ListNotes(ctx context.Context) ([]NoteListItem, error
 		FROM notes n
 		LEFT JOIN domains d ON n.domain_id = d.id
 		LEFT JOIN technologies t ON n.technology_id = t.id
+		WHERE n.workspace_id = $1
 		ORDER BY n.updated_at DESC
 	`
 
-	rows, err := r.Pool.Query(ctx, query)
+	rows, err := r.Pool.Query(ctx, query, workspace.FromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReplaceRelations stores a fingerprint's implies, requires and excludes
+// for a technology. Relations to technologies that do not exist are
+// skipped; the signatures define nearly all of them.
+func ReplaceRelations(ctx context.Context, tx pgx.Tx, techID int, fp fingerprint.Fingerprint) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM technology_relations WHERE technology_id = $1`, techID); err != nil {
+		return err
+	}
+	for kind, rels := range fp.Relations() {
+		for _, rel := range rels {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO technology_relations (technology_id, related_id, kind, confidence)
+				SELECT $1, id, $3, $4 FROM technologies WHERE name = $2 AND id <> $1
+				ON CONFLICT (technology_id, kind, related_id) DO UPDATE SET confidence = EXCLUDED.confidence
+			`, techID, rel.Name, kind, rel.Confidence)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListRelations returns a technology's relations by kind, then name.
+func (r *TechRepository) ListRelations(ctx context.Context, id int) ([]models.TechnologyRelation, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT rel.kind, rel.related_id, t.name, rel.confidence
+		FROM technology_relations rel
+		JOIN technologies t ON t.id = rel.related_id
+		WHERE rel.technology_id = $1
+		ORDER BY rel.kind, t.name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TechnologyRelation
+	for rows.Next() {
+		var rel models.TechnologyRelation
+		if err := rows.Scan(&rel.Kind, &rel.RelatedID, &rel.Related, &rel.Confidence); err != nil {
+			return nil, err
+		}
+		out = append(out, rel)
+	}
+	return out, rows.Err()
+}
+
+// Relation passes over a domain's detections. Each takes the domain ID.
+const (
+	// An observed technology suppresses those it excludes.
+	relationExcludes = `
+		DELETE FROM detections b
+		USING detections a, technology_relations rel
+		WHERE a.domain_id = $1 AND b.domain_id = $1 AND a.inferred_from IS NULL
+			AND rel.kind = 'excludes' AND rel.technology_id = a.technology_id AND rel.related_id = b.technology_id`
+
+	// A technology is only kept alongside everything it requires.
+	relationRequires = `
+		DELETE FROM detections t
+		USING technology_relations rel
+		WHERE t.domain_id = $1 AND rel.kind = 'requires' AND rel.technology_id = t.technology_id
+			AND NOT EXISTS (SELECT 1 FROM detections d WHERE d.domain_id = $1 AND d.technology_id = rel.related_id)`
+
+	// Inferences whose origin is no longer observed go with it.
+	relationStale = `
+		DELETE FROM detections i
+		WHERE i.domain_id = $1 AND i.inferred_from IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM detections o
+				WHERE o.domain_id = $1 AND o.technology_id = i.inferred_from AND o.inferred_from IS NULL
+			)`
+
+	// Observed technologies imply others, transitively, with confidence
+	// scaled at each step and chains cut at five steps, since they may loop.
+	// The strongest inference wins; observed detections are never
+	// overwritten.
+	relationImplies = `
+		WITH RECURSIVE implied (technology_id, confidence, origin, url, workspace_id, depth) AS (
+			SELECT rel.related_id, det.confidence * rel.confidence / 100, det.technology_id, det.url, det.workspace_id, 1
+			FROM detections det
+			JOIN technology_relations rel ON rel.technology_id = det.technology_id AND rel.kind = 'implies'
+			WHERE det.domain_id = $1 AND det.inferred_from IS NULL
+			UNION ALL
+			SELECT rel.related_id, i.confidence * rel.confidence / 100, i.origin, i.url, i.workspace_id, i.depth + 1
+			FROM implied i
+			JOIN technology_relations rel ON rel.technology_id = i.technology_id AND rel.kind = 'implies'
+			WHERE i.depth < 5
+		)
+		INSERT INTO detections (domain_id, technology_id, url, version, confidence, source, last_seen, workspace_id, inferred_from)
+		SELECT DISTINCT ON (technology_id) $1, technology_id, url, '', confidence, 'implied', CURRENT_TIMESTAMP, workspace_id, origin
+		FROM implied
+		WHERE confidence > 0 AND technology_id <> origin
+		ORDER BY technology_id, confidence DESC
+		ON CONFLICT ON CONSTRAINT unique_detection DO UPDATE SET
+			confidence = EXCLUDED.confidence,
+			inferred_from = EXCLUDED.inferred_from,
+			url = EXCLUDED.url,
+			last_seen = EXCLUDED.last_seen
+		WHERE detections.inferred_from IS NOT NULL`
+)
+
+// ApplyRelations brings a domain's detections in line with the technology
+// relations: what the observed technologies exclude, or detections missing
+// a requirement, are dropped, and what they imply is recorded as inferred.
+// Ingestion calls it once per domain after storing detections.
+func (r *DomainRepository) ApplyRelations(ctx context.Context, domainID int) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Exclusions go first so excluded technologies imply nothing, and again
+	// once inferences exist; requirements may be met by an inference, and
+	// dropping what misses one can leave stale inferences behind.
+	passes := []string{relationExcludes, relationStale, relationImplies, relationExcludes, relationRequires, relationStale}
+	for _, q := range passes {
+		if _, err := tx.Exec(ctx, q, domainID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
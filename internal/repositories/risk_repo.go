@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/risk"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RiskRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewRiskRepository(pool *pgxpool.Pool) *RiskRepository {
+	return &RiskRepository{Pool: pool}
+}
+
+// DomainRisk is a domain's stored score.
+type DomainRisk struct {
+	risk.Result
+	ComputedAt time.Time
+}
+
+// QueuedDomain is a domain waiting to be scored, since QueuedAt.
+type QueuedDomain struct {
+	ID       int
+	QueuedAt time.Time
+}
+
+// Queued returns up to limit domains waiting to be scored, longest waiting
+// first.
+func (r *RiskRepository) Queued(ctx context.Context, limit int) ([]QueuedDomain, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT domain_id, queued_at FROM domain_risk_queue ORDER BY queued_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []QueuedDomain
+	for rows.Next() {
+		var q QueuedDomain
+		if err := rows.Scan(&q.ID, &q.QueuedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// Inputs gathers what a domain's score is made of. It returns
+// pgx.ErrNoRows when the domain no longer exists.
+func (r *RiskRepository) Inputs(ctx context.Context, domainID int) (risk.Inputs, error) {
+	var in risk.Inputs
+	err := r.Pool.QueryRow(ctx, `
+		SELECT COALESCE(d.criticality, ''), COALESCE(ds.dangling, FALSE), COALESCE(ds.takeover_service, '')
+		FROM domains d LEFT JOIN dns_status ds ON ds.domain_id = d.id
+		WHERE d.id = $1
+	`, domainID).Scan(&in.Criticality, &in.Dangling, &in.Takeover)
+	if err != nil {
+		return in, err
+	}
+	if !in.Dangling {
+		in.Takeover = ""
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+		SELECT t.name, COALESCE(det.version, ''), COALESCE(vp.risk_level, t.risk_level, ''),
+			COALESCE(vp.cve_count, 0), COALESCE(vp.exploit_available, FALSE),
+			COALESCE(det.eol_status, ''), det.majors_behind, det.minors_behind,
+			EXISTS (SELECT 1 FROM technology_categories tc JOIN categories c ON c.id = tc.category_id
+				WHERE tc.technology_id = t.id AND c.name = ANY($2))
+		FROM detections det
+		JOIN technologies t ON t.id = det.technology_id
+		LEFT JOIN technology_vuln_profile vp ON vp.technology = t.name
+		WHERE det.domain_id = $1
+		ORDER BY t.name
+	`, domainID, risk.AdminCategories)
+	if err != nil {
+		return in, err
+	}
+	for rows.Next() {
+		var t risk.Tech
+		var eol models.EOLInfo
+		var panel bool
+		if err := rows.Scan(&t.Name, &t.Version, &t.RiskLevel, &t.CVECount, &t.Exploit, &t.EOL, &eol.MajorsBehind, &eol.MinorsBehind, &panel); err != nil {
+			rows.Close()
+			return in, err
+		}
+		t.Behind = eol.Behind()
+		in.Technologies = append(in.Technologies, t)
+		if panel {
+			in.AdminPanels = append(in.AdminPanels, t.Name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return in, err
+	}
+
+	rows, err = r.Pool.Query(ctx, `
+		SELECT COALESCE(template_id, ''), name, COALESCE(severity, '')
+		FROM active_vulnerabilities WHERE domain_id = $1
+		ORDER BY found_at
+	`, domainID)
+	if err != nil {
+		return in, err
+	}
+	for rows.Next() {
+		var f risk.Finding
+		if err := rows.Scan(&f.TemplateID, &f.Name, &f.Severity); err != nil {
+			rows.Close()
+			return in, err
+		}
+		in.Findings = append(in.Findings, f)
+		if risk.PanelTemplate(f.TemplateID) {
+			in.AdminPanels = append(in.AdminPanels, f.Name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return in, err
+	}
+
+	rows, err = r.Pool.Query(ctx, `
+		SELECT DISTINCT 'port ' || e.port
+		FROM tls_endpoints e
+		JOIN tls_endpoint_certificates ec ON ec.endpoint_id = e.id AND ec.position = 0
+		JOIN certificates c ON c.id = ec.certificate_id
+		WHERE e.domain_id = $1 AND c.not_after < CURRENT_TIMESTAMP
+	`, domainID)
+	if err != nil {
+		return in, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var port string
+		if err := rows.Scan(&port); err != nil {
+			return in, err
+		}
+		in.ExpiredCerts = append(in.ExpiredCerts, port)
+	}
+	return in, rows.Err()
+}
+
+// Save stores a domain's score and takes it off the queue, unless it was
+// queued again after since.
+func (r *RiskRepository) Save(ctx context.Context, domainID int, res risk.Result, since time.Time) error {
+	factors, err := json.Marshal(res.Factors)
+	if err != nil {
+		return err
+	}
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO domain_risk (domain_id, score, level, factors, computed_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (domain_id) DO UPDATE SET score = EXCLUDED.score, level = EXCLUDED.level,
+			factors = EXCLUDED.factors, computed_at = EXCLUDED.computed_at
+	`, domainID, res.Score, res.Level, factors)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, dequeueRisk, domainID, since); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+const dequeueRisk = `DELETE FROM domain_risk_queue WHERE domain_id = $1 AND queued_at <= $2`
+
+// Drop takes a deleted domain off the queue.
+func (r *RiskRepository) Drop(ctx context.Context, domainID int, since time.Time) error {
+	_, err := r.Pool.Exec(ctx, dequeueRisk, domainID, since)
+	return err
+}
+
+// EnqueueStale queues domains last scored before the cutoff, so inputs
+// that change with time alone, such as certificate expiry, are picked up.
+func (r *RiskRepository) EnqueueStale(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.Pool.Exec(ctx, `
+		INSERT INTO domain_risk_queue (domain_id)
+		SELECT d.id FROM domains d
+		LEFT JOIN domain_risk dr ON dr.domain_id = d.id
+		WHERE dr.computed_at IS NULL OR dr.computed_at < $1
+		ON CONFLICT (domain_id) DO NOTHING
+	`, before)
+	return tag.RowsAffected(), err
+}
+
+// Get returns a domain's stored score, or nil when it has none yet.
+func (r *RiskRepository) Get(ctx context.Context, domainID int) (*DomainRisk, error) {
+	return getDomainRisk(ctx, r.Pool, domainID)
+}
+
+func getDomainRisk(ctx context.Context, pool *pgxpool.Pool, domainID int) (*DomainRisk, error) {
+	d := &DomainRisk{}
+	var factors []byte
+	err := pool.QueryRow(ctx, `
+		SELECT score, level, factors, computed_at FROM domain_risk WHERE domain_id = $1
+	`, domainID).Scan(&d.Score, &d.Level, &factors, &d.ComputedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, json.Unmarshal(factors, &d.Factors)
+}
@@ -0,0 +1,295 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SavedQueryRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewSavedQueryRepository(pool *pgxpool.Pool) *SavedQueryRepository {
+	return &SavedQueryRepository{Pool: pool}
+}
+
+const savedQueryColumns = `id, name, target, query, filters, pinned,
+	ARRAY(SELECT channel_id FROM saved_query_subscriptions s WHERE s.saved_query_id = saved_queries.id ORDER BY channel_id),
+	(SELECT COUNT(*) FROM saved_query_members m WHERE m.saved_query_id = saved_queries.id),
+	evaluated_at, COALESCE(created_by, ''), created_at, updated_at`
+
+func scanSavedQuery(row rowScanner) (models.SavedQuery, error) {
+	var q models.SavedQuery
+	err := row.Scan(&q.ID, &q.Name, &q.Target, &q.Query, &q.Filters, &q.Pinned, &q.Channels, &q.Members,
+		&q.EvaluatedAt, &q.CreatedBy, &q.CreatedAt, &q.UpdatedAt)
+	return q, err
+}
+
+func (r *SavedQueryRepository) list(ctx context.Context, where string, args ...interface{}) ([]models.SavedQuery, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+savedQueryColumns+` FROM saved_queries WHERE `+where+` ORDER BY target, name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queries []models.SavedQuery
+	for rows.Next() {
+		q, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// List returns the saved queries of the current workspace, for one target
+// or, when target is empty, all of them.
+func (r *SavedQueryRepository) List(ctx context.Context, target string) ([]models.SavedQuery, error) {
+	return r.list(ctx, "workspace_id = $1 AND ($2 = '' OR target = $2)", workspace.FromContext(ctx), target)
+}
+
+// ListPinned returns the saved queries of the current workspace pinned to
+// the sidebar.
+func (r *SavedQueryRepository) ListPinned(ctx context.Context) ([]models.SavedQuery, error) {
+	return r.list(ctx, "workspace_id = $1 AND pinned", workspace.FromContext(ctx))
+}
+
+// ListSubscribed returns the domain queries of the current workspace with
+// at least one subscribed channel.
+func (r *SavedQueryRepository) ListSubscribed(ctx context.Context) ([]models.SavedQuery, error) {
+	return r.list(ctx, `workspace_id = $1 AND target = $2
+		AND EXISTS (SELECT 1 FROM saved_query_subscriptions s WHERE s.saved_query_id = saved_queries.id)`,
+		workspace.FromContext(ctx), models.QueryTargetDomains)
+}
+
+// WorkspacesWithSubscriptions lists the workspaces with a subscribed saved
+// query.
+func (r *SavedQueryRepository) WorkspacesWithSubscriptions(ctx context.Context) ([]int, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT DISTINCT q.workspace_id FROM saved_queries q
+		JOIN saved_query_subscriptions s ON s.saved_query_id = q.id
+		ORDER BY q.workspace_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Get returns a saved query of the current workspace.
+func (r *SavedQueryRepository) Get(ctx context.Context, id int) (models.SavedQuery, error) {
+	return scanSavedQuery(r.Pool.QueryRow(ctx, `
+		SELECT `+savedQueryColumns+` FROM saved_queries WHERE id = $1 AND workspace_id = $2
+	`, id, workspace.FromContext(ctx)))
+}
+
+// GetByName returns a saved query of the current workspace by target and name.
+func (r *SavedQueryRepository) GetByName(ctx context.Context, target, name string) (models.SavedQuery, error) {
+	return scanSavedQuery(r.Pool.QueryRow(ctx, `
+		SELECT `+savedQueryColumns+` FROM saved_queries WHERE workspace_id = $1 AND target = $2 AND name = $3
+	`, workspace.FromContext(ctx), target, name))
+}
+
+// Save stores a query under its name, replacing the query and filters of an
+// existing one with the same name and target. A changed view starts its
+// membership over, so subscribers are not told about the edit as a change.
+func (r *SavedQueryRepository) Save(ctx context.Context, q models.SavedQuery) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	var changed bool
+	err = tx.QueryRow(ctx, `
+		WITH old AS (
+			SELECT query, filters FROM saved_queries WHERE workspace_id = $1 AND target = $3 AND name = $2
+		)
+		INSERT INTO saved_queries (workspace_id, name, target, query, filters, pinned, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (workspace_id, target, name) DO UPDATE SET query = EXCLUDED.query, filters = EXCLUDED.filters,
+			pinned = saved_queries.pinned OR EXCLUDED.pinned, updated_at = NOW()
+		RETURNING id, NOT EXISTS (SELECT 1 FROM old WHERE old.query = $4 AND old.filters = $5)
+	`, workspace.FromContext(ctx), q.Name, q.Target, q.Query, q.Filters, q.Pinned, q.CreatedBy).Scan(&id, &changed)
+	if err != nil {
+		return 0, err
+	}
+	if changed {
+		if err := resetMembers(ctx, tx, id); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+// resetMembers forgets the membership of a view; the next evaluation takes a
+// new baseline without notifying.
+func resetMembers(ctx context.Context, tx pgx.Tx, id int) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM saved_query_members WHERE saved_query_id = $1", id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, "UPDATE saved_queries SET evaluated_at = NULL WHERE id = $1", id)
+	return err
+}
+
+// Delete removes a saved query of the current workspace.
+func (r *SavedQueryRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM saved_queries WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx))
+	return err
+}
+
+// SetPinned pins a saved query of the current workspace to the sidebar or
+// unpins it.
+func (r *SavedQueryRepository) SetPinned(ctx context.Context, id int, pinned bool) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE saved_queries SET pinned = $3 WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx), pinned)
+	return err
+}
+
+// SetSubscriptions replaces the alert channels subscribed to a saved query
+// of the current workspace. Channels of other workspaces are ignored. A view
+// left without subscribers forgets its membership, so subscribing again
+// starts from a fresh baseline.
+func (r *SavedQueryRepository) SetSubscriptions(ctx context.Context, id int, channelIDs []int, createdBy string) error {
+	wsID := workspace.FromContext(ctx)
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM saved_query_subscriptions
+		WHERE saved_query_id = (SELECT id FROM saved_queries WHERE id = $1 AND workspace_id = $2)
+			AND NOT (channel_id = ANY($3::int[]))
+	`, id, wsID, channelIDs)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO saved_query_subscriptions (saved_query_id, channel_id, created_by)
+		SELECT q.id, c.id, NULLIF($4, '')
+		FROM saved_queries q JOIN alert_channels c ON c.workspace_id = q.workspace_id
+		WHERE q.id = $1 AND q.workspace_id = $2 AND c.id = ANY($3::int[])
+		ON CONFLICT DO NOTHING
+	`, id, wsID, channelIDs, createdBy)
+	if err != nil {
+		return err
+	}
+	if len(channelIDs) == 0 {
+		if err := resetMembers(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Members returns the result set of a saved query at its last evaluation,
+// names keyed by domain ID.
+func (r *SavedQueryRepository) Members(ctx context.Context, id int) (map[int]string, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT domain_id, name FROM saved_query_members WHERE saved_query_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make(map[int]string)
+	for rows.Next() {
+		var domainID int
+		var name string
+		if err := rows.Scan(&domainID, &name); err != nil {
+			return nil, err
+		}
+		members[domainID] = name
+	}
+	return members, rows.Err()
+}
+
+// RecordEvaluation stores the result set of an evaluation: entered domains
+// are added, left ones removed, and a non-empty change is kept in the
+// history.
+func (r *SavedQueryRepository) RecordEvaluation(ctx context.Context, id int, entered, left map[int]string, change *models.SavedQueryChange, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if len(left) > 0 {
+		ids := make([]int, 0, len(left))
+		for domainID := range left {
+			ids = append(ids, domainID)
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM saved_query_members WHERE saved_query_id = $1 AND domain_id = ANY($2::int[])", id, ids); err != nil {
+			return err
+		}
+	}
+	if len(entered) > 0 {
+		ids := make([]int, 0, len(entered))
+		names := make([]string, 0, len(entered))
+		for domainID, name := range entered {
+			ids = append(ids, domainID)
+			names = append(names, name)
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO saved_query_members (saved_query_id, domain_id, name, since)
+			SELECT $1, m.domain_id, m.name, $4 FROM unnest($2::int[], $3::text[]) AS m(domain_id, name)
+			ON CONFLICT DO NOTHING
+		`, id, ids, names, at)
+		if err != nil {
+			return err
+		}
+	}
+	if change != nil {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO saved_query_changes (saved_query_id, entered, left_names, entered_count, left_count, changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, id, change.Entered, change.Left, change.EnteredCount, change.LeftCount, at)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, "UPDATE saved_queries SET evaluated_at = $2 WHERE id = $1", id, at); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListChanges returns the latest membership changes of the saved queries of
+// the current workspace, newest first; id limits them to one query when
+// non-zero.
+func (r *SavedQueryRepository) ListChanges(ctx context.Context, id, limit int) ([]models.SavedQueryChange, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT c.id, c.saved_query_id, c.entered, c.left_names, c.entered_count, c.left_count, c.changed_at
+		FROM saved_query_changes c JOIN saved_queries q ON q.id = c.saved_query_id
+		WHERE q.workspace_id = $1 AND ($2 = 0 OR q.id = $2)
+		ORDER BY c.changed_at DESC, c.id DESC
+		LIMIT $3
+	`, workspace.FromContext(ctx), id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var changes []models.SavedQueryChange
+	for rows.Next() {
+		var c models.SavedQueryChange
+		if err := rows.Scan(&c.ID, &c.SavedQueryID, &c.Entered, &c.Left, &c.EnteredCount, &c.LeftCount, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DueTarget is a target whose next run has come, with its schedule.
+type DueTarget struct {
+	Schedule models.ScanSchedule
+	Target   models.ScheduleTarget
+}
+
+type ScheduleRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewScheduleRepository(pool *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{Pool: pool}
+}
+
+const scheduleColumns = `
+	s.id, s.workspace_id, s.name, s.cron_expr, s.timezone, s.stages, s.jitter_seconds, s.max_concurrency,
+	s.is_active, COALESCE(s.created_by, ''), s.created_at,
+	(SELECT MAX(last_run_at) FROM scan_schedule_targets WHERE schedule_id = s.id),
+	(SELECT MIN(next_run_at) FROM scan_schedule_targets WHERE schedule_id = s.id)`
+
+func scanSchedule(row rowScanner) (models.ScanSchedule, error) {
+	var s models.ScanSchedule
+	err := row.Scan(&s.ID, &s.WorkspaceID, &s.Name, &s.CronExpr, &s.Timezone, &s.Stages, &s.JitterSeconds, &s.MaxConcurrency,
+		&s.IsActive, &s.CreatedBy, &s.CreatedAt, &s.LastRunAt, &s.NextRunAt)
+	return s, err
+}
+
+const targetColumns = `
+	t.id, t.schedule_id, t.target, t.kind, t.next_run_at, t.last_run_at, t.last_finished_at,
+	COALESCE(t.last_status, ''), COALESCE(t.last_error, '')`
+
+func scanScheduleTarget(row rowScanner) (models.ScheduleTarget, error) {
+	var t models.ScheduleTarget
+	err := row.Scan(&t.ID, &t.ScheduleID, &t.Target, &t.Kind, &t.NextRunAt, &t.LastRunAt, &t.LastFinishedAt, &t.LastStatus, &t.LastError)
+	return t, err
+}
+
+// List returns the current workspace's schedules with their targets.
+func (r *ScheduleRepository) List(ctx context.Context) ([]models.ScanSchedule, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+scheduleColumns+` FROM scan_schedules s WHERE s.workspace_id = $1 ORDER BY s.name ASC`, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ScanSchedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		if list[i].Targets, err = r.ListTargets(ctx, list[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Get returns a schedule of the current workspace with its targets.
+func (r *ScheduleRepository) Get(ctx context.Context, id int) (models.ScanSchedule, error) {
+	s, err := scanSchedule(r.Pool.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM scan_schedules s WHERE s.id = $1 AND s.workspace_id = $2`, id, workspace.FromContext(ctx)))
+	if err != nil {
+		return s, err
+	}
+	s.Targets, err = r.ListTargets(ctx, id)
+	return s, err
+}
+
+// ListTargets returns the targets of a schedule.
+func (r *ScheduleRepository) ListTargets(ctx context.Context, scheduleID int) ([]models.ScheduleTarget, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+targetColumns+` FROM scan_schedule_targets t WHERE t.schedule_id = $1 ORDER BY t.target ASC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.ScheduleTarget
+	for rows.Next() {
+		t, err := scanScheduleTarget(rows)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// Create stores a schedule in the current workspace and returns its ID.
+func (r *ScheduleRepository) Create(ctx context.Context, s models.ScanSchedule) (int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO scan_schedules (workspace_id, name, cron_expr, timezone, stages, jitter_seconds, max_concurrency, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, workspace.FromContext(ctx), s.Name, s.CronExpr, s.Timezone, s.Stages, s.JitterSeconds, s.MaxConcurrency, s.CreatedBy).Scan(&id)
+	return id, err
+}
+
+// SetActive pauses or resumes a schedule.
+func (r *ScheduleRepository) SetActive(ctx context.Context, id int, active bool) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE scan_schedules SET is_active = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND workspace_id = $3
+	`, id, active, workspace.FromContext(ctx))
+	return err
+}
+
+// Delete removes a schedule and its targets.
+func (r *ScheduleRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM scan_schedules WHERE id = $1 AND workspace_id = $2", id, workspace.FromContext(ctx))
+	return err
+}
+
+// AddTarget adds a target to a schedule of the current workspace. Existing
+// targets are left untouched.
+func (r *ScheduleRepository) AddTarget(ctx context.Context, scheduleID int, target, kind string, nextRun time.Time) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO scan_schedule_targets (schedule_id, target, kind, next_run_at)
+		SELECT id, $2, $3, $4 FROM scan_schedules WHERE id = $1 AND workspace_id = $5
+		ON CONFLICT (schedule_id, target) DO NOTHING
+	`, scheduleID, target, kind, nextRun, workspace.FromContext(ctx))
+	return err
+}
+
+// RemoveTarget deletes a target from a schedule of the current workspace.
+func (r *ScheduleRepository) RemoveTarget(ctx context.Context, scheduleID, targetID int) error {
+	_, err := r.Pool.Exec(ctx, `
+		DELETE FROM scan_schedule_targets t USING scan_schedules s
+		WHERE t.id = $1 AND t.schedule_id = $2 AND s.id = t.schedule_id AND s.workspace_id = $3
+	`, targetID, scheduleID, workspace.FromContext(ctx))
+	return err
+}
+
+// SetNextRun moves a target's next run, e.g. "run now" or after resuming.
+func (r *ScheduleRepository) SetNextRun(ctx context.Context, scheduleID, targetID int, next time.Time) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE scan_schedule_targets t SET next_run_at = $3
+		FROM scan_schedules s
+		WHERE t.id = $1 AND t.schedule_id = $2 AND s.id = t.schedule_id AND s.workspace_id = $4
+	`, targetID, scheduleID, next, workspace.FromContext(ctx))
+	return err
+}
+
+// Due returns targets of active schedules in every workspace whose next run
+// has passed, oldest first.
+func (r *ScheduleRepository) Due(ctx context.Context, now time.Time, limit int) ([]DueTarget, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT `+scheduleColumns+`, `+targetColumns+`
+		FROM scan_schedule_targets t
+		JOIN scan_schedules s ON s.id = t.schedule_id
+		WHERE s.is_active = TRUE AND t.next_run_at <= $1
+		ORDER BY t.next_run_at ASC
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []DueTarget
+	for rows.Next() {
+		var d DueTarget
+		s, t := &d.Schedule, &d.Target
+		if err := rows.Scan(&s.ID, &s.WorkspaceID, &s.Name, &s.CronExpr, &s.Timezone, &s.Stages, &s.JitterSeconds, &s.MaxConcurrency,
+			&s.IsActive, &s.CreatedBy, &s.CreatedAt, &s.LastRunAt, &s.NextRunAt,
+			&t.ID, &t.ScheduleID, &t.Target, &t.Kind, &t.NextRunAt, &t.LastRunAt, &t.LastFinishedAt, &t.LastStatus, &t.LastError); err != nil {
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// Claim marks a due target as running and sets its following run. It fails
+// when another scheduler instance claimed the target first.
+func (r *ScheduleRepository) Claim(ctx context.Context, targetID int, now, next time.Time) (bool, error) {
+	tag, err := r.Pool.Exec(ctx, `
+		UPDATE scan_schedule_targets
+		SET next_run_at = $3, last_run_at = $2, last_status = 'running', last_error = NULL
+		WHERE id = $1 AND next_run_at <= $2
+	`, targetID, now, next)
+	return tag.RowsAffected() > 0, err
+}
+
+// Finish records the outcome of a target run.
+func (r *ScheduleRepository) Finish(ctx context.Context, targetID int, status, errMsg string) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE scan_schedule_targets
+		SET last_status = $2, last_error = NULLIF($3, ''), last_finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, targetID, status, errMsg)
+	return err
+}
+
+// ResetStale marks runs left "running" by a previous process as failed.
+func (r *ScheduleRepository) ResetStale(ctx context.Context) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE scan_schedule_targets
+		SET last_status = 'failed', last_error = 'interrupted by restart', last_finished_at = CURRENT_TIMESTAMP
+		WHERE last_status = 'running'
+	`)
+	return err
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/eol"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/search"
+)
+
+// queryCompiler turns a parsed search query into a SQL condition. Values
+// only ever reach the statement as parameters, numbered from argCount.
+type queryCompiler struct {
+	args     []interface{}
+	argCount int
+	term     func(c *queryCompiler, t search.Term, detection []search.Term) string
+}
+
+// detectionField reports whether a field describes a detection rather than
+// its technology; such terms narrow the tech: terms of their group.
+func detectionField(name string) bool {
+	return name == "version" || name == "eol"
+}
+
+func (c *queryCompiler) arg(v interface{}) string {
+	c.args = append(c.args, v)
+	c.argCount++
+	return fmt.Sprintf("$%d", c.argCount-1)
+}
+
+func (c *queryCompiler) compile(n *search.Node) string {
+	switch n.Op {
+	case search.OpNot:
+		// Comparisons against NULL columns are NULL; -owner:x should still
+		// match hosts without an owner.
+		return "NOT COALESCE(" + c.compile(n.Children[0]) + ", FALSE)"
+	case search.OpOr:
+		parts := make([]string, len(n.Children))
+		for i, child := range n.Children {
+			parts[i] = c.compile(child)
+		}
+		return "(" + strings.Join(parts, " OR ") + ")"
+	case search.OpAnd:
+		// version: and eol: narrow the tech: terms of their group to the
+		// same detection, so tech:nginx version:<1.20 means an old nginx
+		// rather than nginx plus anything old.
+		var detection []search.Term
+		hasTech := false
+		for _, child := range n.Children {
+			if child.Op == search.OpTerm && detectionField(child.Term.Field) {
+				detection = append(detection, child.Term)
+			}
+			if child.Op == search.OpTerm && child.Term.Field == "tech" {
+				hasTech = true
+			}
+		}
+		var parts []string
+		for _, child := range n.Children {
+			switch {
+			case child.Op != search.OpTerm:
+				parts = append(parts, c.compile(child))
+			case detectionField(child.Term.Field) && hasTech:
+			case child.Term.Field == "tech":
+				parts = append(parts, c.term(c, child.Term, detection))
+			default:
+				parts = append(parts, c.term(c, child.Term, nil))
+			}
+		}
+		return "(" + strings.Join(parts, " AND ") + ")"
+	}
+	if detectionField(n.Term.Field) {
+		return c.term(c, n.Term, []search.Term{n.Term})
+	}
+	return c.term(c, n.Term, nil)
+}
+
+// text matches a column case-insensitively: exactly when exact is set, as a
+// * pattern when the value has one, otherwise as a substring.
+func (c *queryCompiler) text(col, v string, exact bool) string {
+	v = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(v)
+	switch {
+	case strings.Contains(v, "*"):
+		v = strings.ReplaceAll(v, "*", "%")
+	case !exact:
+		v = "%" + v + "%"
+	}
+	return fmt.Sprintf("%s ILIKE %s", col, c.arg(v))
+}
+
+func (c *queryCompiler) compare(expr string, t search.Term, v interface{}) string {
+	return fmt.Sprintf("%s %s %s", expr, t.Compare, c.arg(v))
+}
+
+// detection compiles version: and eol: terms against detections dq. A
+// version compares the version key (see internal/version), whose byte order
+// is version order; detections without a readable version have no key, and
+// unmapped ones no status, so they never match.
+func (c *queryCompiler) detection(terms []search.Term) string {
+	var conds []string
+	for _, t := range terms {
+		switch t.Field {
+		case "version":
+			conds = append(conds, fmt.Sprintf(`dq.version_key COLLATE "C" %s %s`, t.Compare, c.arg(t.Version.Key())))
+		case "eol":
+			conds = append(conds, fmt.Sprintf("array_position(%s::text[], dq.eol_status::text) %s %s", c.arg(eol.Statuses), t.Compare, c.arg(t.Number)))
+		}
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// level compares a Low/Medium/High/Critical column with the term's rank.
+// Unset values never match.
+func (c *queryCompiler) level(col string, t search.Term) string {
+	return fmt.Sprintf("array_position(%s::text[], %s::text) %s %s",
+		c.arg(models.CriticalityLevels), col, t.Compare, c.arg(t.Number))
+}
+
+// domainTerm compiles a DomainFields term against domains d.
+func domainTerm(c *queryCompiler, t search.Term, detection []search.Term) string {
+	switch t.Field {
+	case "name":
+		return c.text("d.name", t.Value, false)
+	case "tech", "version", "eol":
+		cond := "TRUE"
+		if t.Field == "tech" {
+			cond = c.text("tq.name", t.Value, true)
+		}
+		if len(detection) > 0 {
+			cond += " AND " + c.detection(detection)
+		}
+		return `EXISTS (SELECT 1 FROM detections dq JOIN technologies tq ON tq.id = dq.technology_id WHERE dq.domain_id = d.id AND ` + cond + `)`
+	case "category":
+		return `EXISTS (SELECT 1 FROM detections dq JOIN technology_categories tcq ON tcq.technology_id = dq.technology_id
+			JOIN categories cq ON cq.id = tcq.category_id WHERE dq.domain_id = d.id AND ` + c.text("cq.name", t.Value, true) + `)`
+	case "risk":
+		return `EXISTS (SELECT 1 FROM detections dq JOIN technologies tq ON tq.id = dq.technology_id
+			LEFT JOIN technology_vuln_profile vq ON vq.technology = tq.name
+			WHERE dq.domain_id = d.id AND ` + c.level("COALESCE(vq.risk_level, tq.risk_level)", t) + `)`
+	case "cloud":
+		return c.text("d.cloud_provider", t.Value, true)
+	case "asn":
+		return c.compare("d.asn", t, t.Number)
+	case "ip":
+		if _, network, err := net.ParseCIDR(t.Value); err == nil {
+			return `EXISTS (SELECT 1 FROM domain_ips diq JOIN ip_addresses iq ON iq.id = diq.ip_id
+				WHERE diq.domain_id = d.id AND iq.address <<= ` + c.arg(network.String()) + `::inet)`
+		}
+		return fmt.Sprintf(`(d.ip_address = %[1]s OR EXISTS (SELECT 1 FROM domain_ips diq JOIN ip_addresses iq ON iq.id = diq.ip_id
+			WHERE diq.domain_id = d.id AND host(iq.address) = %[1]s))`, c.arg(t.Value))
+	case "tag":
+		key, value, hasValue := strings.Cut(t.Value, "=")
+		cond := "dtq.key = " + c.arg(strings.ToLower(strings.TrimSpace(key)))
+		if hasValue {
+			cond += " AND " + c.text("dtq.value", strings.TrimSpace(value), true)
+		}
+		return "EXISTS (SELECT 1 FROM domain_tags dtq WHERE dtq.domain_id = d.id AND " + cond + ")"
+	case "owner":
+		return c.text("d.owner", t.Value, true)
+	case "criticality":
+		return c.level("d.criticality", t)
+	case "score":
+		return c.compare("COALESCE((SELECT score FROM domain_risk WHERE domain_id = d.id), 0)", t, t.Number)
+	case "source":
+		return "EXISTS (SELECT 1 FROM domain_sources dsq WHERE dsq.domain_id = d.id AND " + c.text("dsq.source", t.Value, true) + ")"
+	case "confidence":
+		return c.compare("(SELECT AVG(confidence) FROM detections WHERE domain_id = d.id)", t, t.Number)
+	case "seen":
+		return c.compare("COALESCE((SELECT MAX(last_seen) FROM detections WHERE domain_id = d.id), d.updated_at)", t, t.Time)
+	case "created":
+		return c.compare("d.created_at", t, t.Time)
+	case "is":
+		switch t.Value {
+		case "bookmarked":
+			return "d.is_bookmarked = TRUE"
+		case "dangling":
+			return "EXISTS (SELECT 1 FROM dns_status ds WHERE ds.domain_id = d.id AND ds.dangling)"
+		case "live":
+			return "EXISTS (SELECT 1 FROM detections WHERE domain_id = d.id)"
+		}
+	}
+	return "FALSE"
+}
+
+// technologyTerm compiles a TechnologyFields term against technologies t
+// and technology_vuln_profile vp; wsArg is the workspace parameter.
+func technologyTerm(wsArg string) func(c *queryCompiler, t search.Term, detection []search.Term) string {
+	return func(c *queryCompiler, t search.Term, _ []search.Term) string {
+		switch t.Field {
+		case "name":
+			return c.text("t.name", t.Value, false)
+		case "category":
+			return `EXISTS (SELECT 1 FROM technology_categories tcq JOIN categories cq ON cq.id = tcq.category_id
+				WHERE tcq.technology_id = t.id AND ` + c.text("cq.name", t.Value, true) + `)`
+		case "risk":
+			return c.level("COALESCE(vp.risk_level, t.risk_level)", t)
+		case "cve":
+			return c.compare("COALESCE(vp.cve_count, 0)", t, t.Number)
+		case "exploit":
+			return fmt.Sprintf("COALESCE(vp.exploit_available, FALSE) = %s", c.arg(t.Value == "true"))
+		case "domains":
+			return c.compare("(SELECT COUNT(DISTINCT domain_id) FROM detections WHERE technology_id = t.id AND workspace_id = "+wsArg+")", t, t.Number)
+		case "version", "eol":
+			return "EXISTS (SELECT 1 FROM detections dq WHERE dq.technology_id = t.id AND dq.workspace_id = " + wsArg + " AND " + c.detection([]search.Term{t}) + ")"
+		case "seen":
+			return c.compare("(SELECT MAX(last_seen) FROM detections WHERE technology_id = t.id AND workspace_id = "+wsArg+")", t, t.Time)
+		}
+		return "FALSE"
+	}
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+// suggestQueries list the known values of the text fields of the query
+// language, for autocomplete. $1 is a LIKE prefix, $2 the limit and $3,
+// where used, the workspace.
+var suggestQueries = map[string]string{
+	"tech": `SELECT DISTINCT t.name FROM technologies t JOIN detections det ON det.technology_id = t.id
+		WHERE det.workspace_id = $3 AND t.name ILIKE $1 ORDER BY t.name LIMIT $2`,
+	"category": `SELECT name FROM categories WHERE name ILIKE $1 ORDER BY name LIMIT $2`,
+	"cloud": `SELECT DISTINCT cloud_provider FROM domains
+		WHERE workspace_id = $3 AND cloud_provider ILIKE $1 ORDER BY cloud_provider LIMIT $2`,
+	"owner": `SELECT DISTINCT owner FROM domains
+		WHERE workspace_id = $3 AND owner ILIKE $1 ORDER BY owner LIMIT $2`,
+	"source": `SELECT DISTINCT ds.source FROM domain_sources ds JOIN domains d ON d.id = ds.domain_id
+		WHERE d.workspace_id = $3 AND ds.source ILIKE $1 ORDER BY ds.source LIMIT $2`,
+	"tag": `SELECT DISTINCT ` + tagLabel + ` AS label
+		FROM domain_tags dt JOIN domains d ON d.id = dt.domain_id
+		WHERE d.workspace_id = $3 AND ` + tagLabel + ` ILIKE $1
+		ORDER BY label LIMIT $2`,
+	"techname": `SELECT name FROM technologies WHERE name ILIKE $1 ORDER BY name LIMIT $2`,
+}
+
+const tagLabel = `CASE WHEN dt.value = '' THEN dt.key ELSE dt.key || '=' || dt.value END`
+
+// SuggestValues returns known values of a text field of the domain query
+// language starting with prefix. Technology queries use "techname" for
+// their name field.
+func (r *DomainRepository) SuggestValues(ctx context.Context, field, prefix string, limit int) ([]string, error) {
+	query, ok := suggestQueries[field]
+	if !ok {
+		return nil, nil
+	}
+	like := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+	args := []interface{}{like, limit}
+	if strings.Contains(query, "$3") {
+		args = append(args, workspace.FromContext(ctx))
+	}
+	rows, err := r.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err == nil {
+			values = append(values, v)
+		}
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SignatureRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewSignatureRepository(pool *pgxpool.Pool) *SignatureRepository {
+	return &SignatureRepository{Pool: pool}
+}
+
+const signatureColumns = `
+	id, source, sha256, fingerprints_etag, categories_etag, technology_count, category_count,
+	added_count, removed_count, changed_count, active, pinned, synced_at, checked_at, applied_at`
+
+func scanSignatureVersion(row rowScanner) (*models.SignatureVersion, error) {
+	var v models.SignatureVersion
+	err := row.Scan(&v.ID, &v.Source, &v.SHA256, &v.FingerprintsETag, &v.CategoriesETag, &v.TechnologyCount, &v.CategoryCount,
+		&v.Added, &v.Removed, &v.Changed, &v.Active, &v.Pinned, &v.SyncedAt, &v.CheckedAt, &v.AppliedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// List returns every recorded version, newest first.
+func (r *SignatureRepository) List(ctx context.Context) ([]models.SignatureVersion, error) {
+	rows, err := r.Pool.Query(ctx, `SELECT `+signatureColumns+` FROM signature_versions ORDER BY synced_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []models.SignatureVersion
+	for rows.Next() {
+		v, err := scanSignatureVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *SignatureRepository) Get(ctx context.Context, id int) (*models.SignatureVersion, error) {
+	return scanSignatureVersion(r.Pool.QueryRow(ctx, `SELECT `+signatureColumns+` FROM signature_versions WHERE id = $1`, id))
+}
+
+// find returns the version matching a condition, or nil when none does.
+func (r *SignatureRepository) find(ctx context.Context, where string, args ...interface{}) (*models.SignatureVersion, error) {
+	v, err := scanSignatureVersion(r.Pool.QueryRow(ctx, `SELECT `+signatureColumns+` FROM signature_versions WHERE `+where+` ORDER BY synced_at DESC, id DESC LIMIT 1`, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// Latest returns the newest version fetched from a source, whose ETags
+// make the next fetch conditional.
+func (r *SignatureRepository) Latest(ctx context.Context, source string) (*models.SignatureVersion, error) {
+	return r.find(ctx, `source = $1`, source)
+}
+
+func (r *SignatureRepository) FindByHash(ctx context.Context, sha string) (*models.SignatureVersion, error) {
+	return r.find(ctx, `sha256 = $1`, sha)
+}
+
+func (r *SignatureRepository) Active(ctx context.Context) (*models.SignatureVersion, error) {
+	return r.find(ctx, `active`)
+}
+
+func (r *SignatureRepository) Pinned(ctx context.Context) (*models.SignatureVersion, error) {
+	return r.find(ctx, `pinned`)
+}
+
+// Data returns the raw fingerprints and categories documents of a version.
+func (r *SignatureRepository) Data(ctx context.Context, id int) (fingerprints, categories []byte, err error) {
+	err = r.Pool.QueryRow(ctx, `SELECT fingerprints, categories FROM signature_versions WHERE id = $1`, id).Scan(&fingerprints, &categories)
+	return fingerprints, categories, err
+}
+
+// Create records a new version with its documents.
+func (r *SignatureRepository) Create(ctx context.Context, v *models.SignatureVersion, fingerprints, categories []byte) error {
+	return r.Pool.QueryRow(ctx, `
+		INSERT INTO signature_versions (source, sha256, fingerprints_etag, categories_etag, fingerprints, categories,
+			technology_count, category_count, added_count, removed_count, changed_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, synced_at, checked_at
+	`, v.Source, v.SHA256, v.FingerprintsETag, v.CategoriesETag, fingerprints, categories,
+		v.TechnologyCount, v.CategoryCount, v.Added, v.Removed, v.Changed).Scan(&v.ID, &v.SyncedAt, &v.CheckedAt)
+}
+
+// Touch records that a version was seen again, with the source's ETags.
+func (r *SignatureRepository) Touch(ctx context.Context, id int, source, fingerprintsETag, categoriesETag string) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE signature_versions
+		SET source = $2, fingerprints_etag = $3, categories_etag = $4, checked_at = NOW()
+		WHERE id = $1
+	`, id, source, fingerprintsETag, categoriesETag)
+	return err
+}
+
+// SetActive marks the version just applied, inside the applying transaction.
+func (r *SignatureRepository) SetActive(ctx context.Context, tx pgx.Tx, id int) error {
+	if _, err := tx.Exec(ctx, `UPDATE signature_versions SET active = FALSE WHERE active AND id <> $1`, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE signature_versions SET active = TRUE, applied_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// SetPinned pins a version, unpinning any other, or unpins it.
+func (r *SignatureRepository) SetPinned(ctx context.Context, id int, pinned bool) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if pinned {
+		if _, err := tx.Exec(ctx, `UPDATE signature_versions SET pinned = FALSE WHERE pinned AND id <> $1`, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE signature_versions SET pinned = $2 WHERE id = $1`, id, pinned); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/version"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+// versionDomainLimit caps the domains listed under each version; the count
+// is always complete.
+const versionDomainLimit = 25
+
+type TechDetail struct {
+	ID          int
+	Name        string
+	Description string
+	Website     string
+	Icon        string
+	RiskLevel   string
+	Categories  []string
+	Relations   []models.TechnologyRelation
+	DomainCount int
+	FirstSeen   *time.Time // earliest detection in the workspace
+	LastSeen    *time.Time
+	Versions    []TechVersion // newest first, undetected version last
+	Notes       []NoteListItem
+}
+
+// TechVersion is one detected version of a technology across the workspace.
+// Version is empty for detections without one.
+type TechVersion struct {
+	Version     string
+	DomainCount int
+	Domains     []DomainNode // first versionDomainLimit by name
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	EOL         models.EOLInfo
+}
+
+// GetDetail returns a technology with its synced metadata and how the
+// current workspace runs it.
+func (r *TechRepository) GetDetail(ctx context.Context, id int) (*TechDetail, error) {
+	wsID := workspace.FromContext(ctx)
+	d := &TechDetail{ID: id}
+	err := r.Pool.QueryRow(ctx, `
+		SELECT t.name, COALESCE(t.description, ''), COALESCE(t.website, ''), COALESCE(t.icon, ''),
+			COALESCE(vp.risk_level, t.risk_level, 'Low'),
+			ARRAY(SELECT c.name FROM technology_categories tc JOIN categories c ON c.id = tc.category_id
+				WHERE tc.technology_id = t.id ORDER BY c.name),
+			(SELECT COUNT(DISTINCT domain_id) FROM detections WHERE technology_id = t.id AND workspace_id = $2),
+			(SELECT MIN(created_at) FROM detections WHERE technology_id = t.id AND workspace_id = $2),
+			(SELECT MAX(last_seen) FROM detections WHERE technology_id = t.id AND workspace_id = $2)
+		FROM technologies t
+		LEFT JOIN technology_vuln_profile vp ON vp.technology = t.name
+		WHERE t.id = $1
+	`, id, wsID).Scan(&d.Name, &d.Description, &d.Website, &d.Icon, &d.RiskLevel, &d.Categories,
+		&d.DomainCount, &d.FirstSeen, &d.LastSeen)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Versions, err = r.listVersions(ctx, id); err != nil {
+		return nil, err
+	}
+	if d.Relations, err = r.ListRelations(ctx, id); err != nil {
+		return nil, err
+	}
+	if d.Notes, err = r.ListNotes(ctx, id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// listVersions groups the workspace's detections of a technology by version.
+func (r *TechRepository) listVersions(ctx context.Context, id int) ([]TechVersion, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT version, COUNT(*), (array_agg(domain_id ORDER BY name))[1:$3], (array_agg(name ORDER BY name))[1:$3],
+			MIN(first_seen), MAX(last_seen),
+			COALESCE(MAX(eol_status), ''), COALESCE(MAX(eol_cycle), ''), MAX(eol_date), MAX(majors_behind), MAX(minors_behind)
+		FROM (
+			SELECT COALESCE(TRIM(det.version), '') AS version, d.id AS domain_id, d.name,
+				MIN(det.created_at) AS first_seen, MAX(det.last_seen) AS last_seen,
+				MAX(det.eol_status) AS eol_status, MAX(det.eol_cycle) AS eol_cycle, MAX(det.eol_date) AS eol_date,
+				MAX(det.majors_behind) AS majors_behind, MAX(det.minors_behind) AS minors_behind
+			FROM detections det JOIN domains d ON d.id = det.domain_id
+			WHERE det.technology_id = $1 AND det.workspace_id = $2
+			GROUP BY 1, d.id, d.name
+		) v
+		GROUP BY version
+	`, id, workspace.FromContext(ctx), versionDomainLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []TechVersion
+	for rows.Next() {
+		var v TechVersion
+		var ids []int
+		var names []string
+		if err := rows.Scan(&v.Version, &v.DomainCount, &ids, &names, &v.FirstSeen, &v.LastSeen,
+			&v.EOL.Status, &v.EOL.Cycle, &v.EOL.Date, &v.EOL.MajorsBehind, &v.EOL.MinorsBehind); err != nil {
+			return nil, err
+		}
+		for i := range ids {
+			v.Domains = append(v.Domains, DomainNode{ID: ids[i], Name: names[i]})
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return newerVersion(versions[i].Version, versions[j].Version)
+	})
+	return versions, nil
+}
+
+// newerVersion orders versions newest first, with versions that cannot be
+// read (including none) after them.
+func newerVersion(a, b string) bool {
+	ka, kb := version.Key(a), version.Key(b)
+	if (ka == "") != (kb == "") {
+		return ka != ""
+	}
+	if ka != kb {
+		return ka > kb
+	}
+	return a > b
+}
+
+// ListNotes returns the current workspace's notes on a technology.
+func (r *TechRepository) ListNotes(ctx context.Context, id int) ([]NoteListItem, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT n.id, t.name, 'Technology', n.content, COALESCE(n.author, ''), n.updated_at
+		FROM notes n JOIN technologies t ON t.id = n.technology_id
+		WHERE n.technology_id = $1 AND n.workspace_id = $2
+		ORDER BY n.updated_at DESC
+	`, id, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteListItem
+	for rows.Next() {
+		var item NoteListItem
+		if err := rows.Scan(&item.ID, &item.Target, &item.Type, &item.Content, &item.Author, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
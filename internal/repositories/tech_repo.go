@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/Abhaythakor/SigMap/internal/search"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,6 +19,8 @@ type TechListItem struct {
 	Icon             string
 	CVECount         int
 	ExploitAvailable bool
+	EOLDomains       int // domains running an end-of-life version
+	OutdatedDomains  int // domains running an outdated one
 }
 
 type TechRepository struct {
@@ -26,8 +31,18 @@ func NewTechRepository(pool *pgxpool.Pool) *TechRepository {
 	return &TechRepository{Pool: pool}
 }
 
-func (r *TechRepository) List(ctx context.Context, limit, offset int) ([]TechListItem, error) {
-	query := `
+// List returns technologies by how many domains run them. q, a parsed
+// search.TechnologyFields query, narrows the list when set.
+func (r *TechRepository) List(ctx context.Context, limit, offset int, q *search.Node) ([]TechListItem, error) {
+	args := []interface{}{limit, offset, workspace.FromContext(ctx)}
+	where := "TRUE"
+	if q != nil {
+		c := &queryCompiler{argCount: 4, term: technologyTerm("$3")}
+		where = c.compile(q)
+		args = append(args, c.args...)
+	}
+
+	query := fmt.Sprintf(`
 		SELECT 
 			t.id, t.name, 
 			COALESCE(c.name, 'Uncategorized') as category,
@@ -36,18 +51,21 @@ func (r *TechRepository) List(ctx context.Context, limit, offset int) ([]TechLis
 			COALESCE(vp.risk_level, t.risk_level) as risk_level,
 			COALESCE(t.icon, ''),
 			COALESCE(vp.cve_count, 0) as cve_count,
-			COALESCE(vp.exploit_available, FALSE) as exploit_available
+			COALESCE(vp.exploit_available, FALSE) as exploit_available,
+			COUNT(DISTINCT det.domain_id) FILTER (WHERE det.eol_status = 'eol') as eol_domains,
+			COUNT(DISTINCT det.domain_id) FILTER (WHERE det.eol_status = 'outdated') as outdated_domains
 		FROM technologies t
 		LEFT JOIN technology_categories tc ON t.id = tc.technology_id
 		LEFT JOIN categories c ON tc.category_id = c.id
-		LEFT JOIN detections det ON t.id = det.technology_id
+		LEFT JOIN detections det ON t.id = det.technology_id AND det.workspace_id = $3
 		LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
+		WHERE %s
 		GROUP BY t.id, c.name, vp.risk_level, vp.cve_count, vp.exploit_available
 		ORDER BY domain_count DESC, t.name ASC
 		LIMIT $1 OFFSET $2
-	`
+	`, where)
 
-	rows, err := r.Pool.Query(ctx, query, limit, offset)
+	rows, err := r.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +74,7 @@ func (r *TechRepository) List(ctx context.Context, limit, offset int) ([]TechLis
 	var items []TechListItem
 	for rows.Next() {
 		var item TechListItem
-		err := rows.Scan(&item.ID, &item.Name, &item.Category, &item.DomainCount, &item.Confidence, &item.RiskLevel, &item.Icon, &item.CVECount, &item.ExploitAvailable)
+		err := rows.Scan(&item.ID, &item.Name, &item.Category, &item.DomainCount, &item.Confidence, &item.RiskLevel, &item.Icon, &item.CVECount, &item.ExploitAvailable, &item.EOLDomains, &item.OutdatedDomains)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TokenRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewTokenRepository(pool *pgxpool.Pool) *TokenRepository {
+	return &TokenRepository{Pool: pool}
+}
+
+const tokenColumns = `
+	t.id, t.workspace_id, t.name, t.kind, t.token_prefix, t.user_id, COALESCE(u.name, u.email, ''), COALESCE(t.created_by, ''),
+	t.scopes, t.expires_at, t.last_used_at, COALESCE(t.last_used_ip, ''), t.revoked_at, t.created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanToken(row rowScanner) (models.APIToken, error) {
+	var t models.APIToken
+	err := row.Scan(&t.ID, &t.WorkspaceID, &t.Name, &t.Kind, &t.Prefix, &t.UserID, &t.Owner, &t.CreatedBy,
+		&t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.LastUsedIP, &t.RevokedAt, &t.CreatedAt)
+	return t, err
+}
+
+// Create stores a new token by hash in the current workspace and returns its ID.
+func (r *TokenRepository) Create(ctx context.Context, t models.APIToken, tokenHash string) (int, error) {
+	var id int
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO api_tokens (name, kind, token_prefix, token_hash, user_id, created_by, scopes, expires_at, workspace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, t.Name, t.Kind, t.Prefix, tokenHash, t.UserID, t.CreatedBy, t.Scopes, t.ExpiresAt, workspace.FromContext(ctx)).Scan(&id)
+	return id, err
+}
+
+// List returns the current workspace's tokens, newest first. A non-nil userID restricts the list to
+// that user's personal tokens.
+func (r *TokenRepository) List(ctx context.Context, userID *int) ([]models.APIToken, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT `+tokenColumns+`
+		FROM api_tokens t
+		LEFT JOIN users u ON t.user_id = u.id
+		WHERE t.workspace_id = $2 AND ($1::INT IS NULL OR t.user_id = $1)
+		ORDER BY t.revoked_at IS NOT NULL, t.created_at DESC
+	`, userID, workspace.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// GetByHash looks up a token by the hash of its secret.
+func (r *TokenRepository) GetByHash(ctx context.Context, tokenHash string) (models.APIToken, error) {
+	return scanToken(r.Pool.QueryRow(ctx, `
+		SELECT `+tokenColumns+`
+		FROM api_tokens t
+		LEFT JOIN users u ON t.user_id = u.id
+		WHERE t.token_hash = $1
+	`, tokenHash))
+}
+
+// Revoke marks a token as revoked. A non-nil userID only matches that user's tokens.
+func (r *TokenRepository) Revoke(ctx context.Context, id int, userID *int) (bool, error) {
+	tag, err := r.Pool.Exec(ctx, `
+		UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND workspace_id = $3 AND revoked_at IS NULL AND ($2::INT IS NULL OR user_id = $2)
+	`, id, userID, workspace.FromContext(ctx))
+	return tag.RowsAffected() > 0, err
+}
+
+// TouchLastUsed records token usage, at most once a minute per token.
+func (r *TokenRepository) TouchLastUsed(ctx context.Context, id int, ip string) error {
+	_, err := r.Pool.Exec(ctx, `
+		UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3 OR last_used_ip IS DISTINCT FROM $2)
+	`, id, ip, time.Now().Add(-time.Minute))
+	return err
+}
@@ -2,8 +2,14 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/Abhaythakor/SigMap/internal/timewindow"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,6 +19,53 @@ type TrendStat struct {
 	Trend float64
 }
 
+// TrendFilter narrows trends to the detections of one technology, of the
+// technologies in one category, or on domains carrying a tag ("key" or
+// "key=value"). Empty fields match everything.
+type TrendFilter struct {
+	Technology string `json:"technology,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+func ParseTrendFilter(v url.Values) TrendFilter {
+	return TrendFilter{
+		Technology: strings.TrimSpace(v.Get("technology")),
+		Category:   strings.TrimSpace(v.Get("category")),
+		Tag:        strings.ToLower(strings.TrimSpace(v.Get("tag"))),
+	}
+}
+
+// where returns the conditions on detections aliased det, with arguments
+// numbered from next.
+func (f TrendFilter) where(next int) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", next+len(args)-1)
+	}
+	if f.Technology != "" {
+		clauses = append(clauses, "det.technology_id IN (SELECT id FROM technologies WHERE LOWER(name) = LOWER("+arg(f.Technology)+"))")
+	}
+	if f.Category != "" {
+		clauses = append(clauses, `EXISTS (SELECT 1 FROM technology_categories tc2 JOIN categories c2 ON c2.id = tc2.category_id
+			WHERE tc2.technology_id = det.technology_id AND LOWER(c2.name) = LOWER(`+arg(f.Category)+`))`)
+	}
+	if f.Tag != "" {
+		key, value, hasValue := strings.Cut(f.Tag, "=")
+		cond := "dt.key = " + arg(strings.TrimSpace(key))
+		if hasValue {
+			cond += " AND dt.value = " + arg(strings.TrimSpace(value))
+		}
+		clauses = append(clauses, "EXISTS (SELECT 1 FROM domain_tags dt WHERE dt.domain_id = det.domain_id AND "+cond+")")
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(clauses, " AND "), args
+}
+
 type TrendRepo struct {
 	Pool *pgxpool.Pool
 }
@@ -21,20 +74,22 @@ func NewTrendRepo(pool *pgxpool.Pool) *TrendRepo {
 	return &TrendRepo{Pool: pool}
 }
 
-func (r *TrendRepo) GetTrends(ctx context.Context) ([]TrendStat, error) {
+// GetTrends summarizes detections in the window against the window of the
+// same length before it.
+func (r *TrendRepo) GetTrends(ctx context.Context, w timewindow.Window, f TrendFilter) ([]TrendStat, error) {
 	var stats []TrendStat
 
-	volume, trend, err := r.calculateVolumeTrend(ctx)
+	volume, trend, err := r.compare(ctx, "SELECT COUNT(*) FROM detections det WHERE %s", w, f)
 	if err == nil {
-		stats = append(stats, TrendStat{Label: "New Detections (30d)", Value: volume, Trend: trend})
+		stats = append(stats, TrendStat{Label: "New Detections (" + w.Name() + ")", Value: volume, Trend: trend})
 	}
 
-	risky, rTrend, err := r.calculateRiskTrend(ctx)
+	risky, rTrend, err := r.compare(ctx, "SELECT COUNT(*) FROM detections det JOIN technologies t ON det.technology_id = t.id WHERE t.risk_level IN ('High', 'Critical') AND %s", w, f)
 	if err == nil {
 		stats = append(stats, TrendStat{Label: "Risky Assets Found", Value: risky, Trend: rTrend})
 	}
 
-	cats, cTrend, err := r.calculateCategoryTrend(ctx)
+	cats, cTrend, err := r.compare(ctx, "SELECT COUNT(DISTINCT tc.category_id) FROM detections det JOIN technology_categories tc ON det.technology_id = tc.technology_id WHERE %s", w, f)
 	if err == nil {
 		stats = append(stats, TrendStat{Label: "Active Categories", Value: cats, Trend: cTrend})
 	}
@@ -42,39 +97,20 @@ func (r *TrendRepo) GetTrends(ctx context.Context) ([]TrendStat, error) {
 	return stats, nil
 }
 
-func (r *TrendRepo) GetVelocityData(ctx context.Context) ([]TrendPoint, error) {
-	query := `
-		SELECT TO_CHAR(created_at, 'DD MON') as day, COUNT(*)
-		FROM detections
-		WHERE created_at > NOW() - INTERVAL '30 days'
-		GROUP BY day, DATE_TRUNC('day', created_at)
-		ORDER BY DATE_TRUNC('day', created_at) ASC
-	`
-	rows, err := r.Pool.Query(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var points []TrendPoint
-	for rows.Next() {
-		var p TrendPoint
-		err := rows.Scan(&p.Date, &p.Count)
-		if err != nil {
-			return nil, err
-		}
-		points = append(points, p)
-	}
-	return points, nil
-}
+// compare runs a count over the detections created in the window and in
+// the one before it; query has a %s for the conditions on det.
+func (r *TrendRepo) compare(ctx context.Context, query string, w timewindow.Window, f TrendFilter) (int, float64, error) {
+	cond, args := f.where(4)
+	query = fmt.Sprintf(query, "det.workspace_id = $1 AND det.created_at >= $2 AND det.created_at < $3"+cond)
+	ws := workspace.FromContext(ctx)
 
-func (r *TrendRepo) calculateVolumeTrend(ctx context.Context) (int, float64, error) {
 	var current, previous int
-	err := r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections WHERE created_at > NOW() - INTERVAL '30 days'").Scan(&current)
+	err := r.Pool.QueryRow(ctx, query, append([]interface{}{ws, w.Start, w.End}, args...)...).Scan(&current)
 	if err != nil {
 		return 0, 0, err
 	}
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections WHERE created_at BETWEEN NOW() - INTERVAL '60 days' AND NOW() - INTERVAL '30 days'").Scan(&previous)
+	prev := w.Previous()
+	err = r.Pool.QueryRow(ctx, query, append([]interface{}{ws, prev.Start, prev.End}, args...)...).Scan(&previous)
 	if err != nil {
 		return current, 0, nil
 	}
@@ -82,32 +118,57 @@ func (r *TrendRepo) calculateVolumeTrend(ctx context.Context) (int, float64, err
 	return current, calculatePercentageChange(current, previous), nil
 }
 
-func (r *TrendRepo) calculateRiskTrend(ctx context.Context) (int, float64, error) {
-	var current, previous int
-	err := r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections d JOIN technologies t ON d.technology_id = t.id WHERE t.risk_level IN ('High', 'Critical') AND d.created_at > NOW() - INTERVAL '30 days'").Scan(&current)
-	if err != nil {
-		return 0, 0, err
-	}
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM detections d JOIN technologies t ON d.technology_id = t.id WHERE t.risk_level IN ('High', 'Critical') AND d.created_at BETWEEN NOW() - INTERVAL '60 days' AND NOW() - INTERVAL '30 days'").Scan(&previous)
-	if err != nil {
-		return current, 0, nil
-	}
+// GetVelocityData counts new detections per bucket of the window.
+func (r *TrendRepo) GetVelocityData(ctx context.Context, w timewindow.Window, f TrendFilter) ([]TrendPoint, error) {
+	return detectionSeries(ctx, r.Pool, w, f)
+}
 
-	return current, calculatePercentageChange(current, previous), nil
+// detectionSeries counts detections created in each bucket of the window.
+// Buckets are aligned in the window's timezone, and those without
+// detections are zero.
+func detectionSeries(ctx context.Context, pool *pgxpool.Pool, w timewindow.Window, f TrendFilter) ([]TrendPoint, error) {
+	cond, args := f.where(6)
+	query := `
+		WITH counts AS (
+			SELECT DATE_TRUNC($1, det.created_at AT TIME ZONE $2) AS bucket, COUNT(*) AS n
+			FROM detections det
+			WHERE det.workspace_id = $3 AND det.created_at >= $4 AND det.created_at < $5` + cond + `
+			GROUP BY 1
+		)
+		SELECT s.bucket, COALESCE(c.n, 0)::INT
+		FROM generate_series(
+			DATE_TRUNC($1, $4::TIMESTAMPTZ AT TIME ZONE $2),
+			$5::TIMESTAMPTZ AT TIME ZONE $2 - INTERVAL '1 microsecond',
+			('1 ' || $1)::INTERVAL
+		) AS s(bucket)
+		LEFT JOIN counts c ON c.bucket = s.bucket
+		ORDER BY s.bucket
+	`
+	args = append([]interface{}{w.Granularity, w.Location.String(), workspace.FromContext(ctx), w.Start, w.End}, args...)
+	return scanSeries(ctx, pool, w, query, args...)
 }
 
-func (r *TrendRepo) calculateCategoryTrend(ctx context.Context) (int, float64, error) {
-	var current, previous int
-	err := r.Pool.QueryRow(ctx, "SELECT COUNT(DISTINCT tc.category_id) FROM detections d JOIN technology_categories tc ON d.technology_id = tc.technology_id WHERE d.created_at > NOW() - INTERVAL '30 days'").Scan(&current)
+// scanSeries reads (bucket, count) rows, buckets being wall clock times in
+// the window's timezone.
+func scanSeries(ctx context.Context, pool *pgxpool.Pool, w timewindow.Window, query string, args ...interface{}) ([]TrendPoint, error) {
+	rows, err := pool.Query(ctx, query, args...)
 	if err != nil {
-		return 0, 0, err
-	}
-	err = r.Pool.QueryRow(ctx, "SELECT COUNT(DISTINCT tc.category_id) FROM detections d JOIN technology_categories tc ON d.technology_id = tc.technology_id WHERE d.created_at BETWEEN NOW() - INTERVAL '60 days' AND NOW() - INTERVAL '30 days'").Scan(&previous)
-	if err != nil {
-		return current, 0, nil
+		return nil, err
 	}
+	defer rows.Close()
 
-	return current, calculatePercentageChange(current, previous), nil
+	var points []TrendPoint
+	for rows.Next() {
+		var bucket time.Time
+		var p TrendPoint
+		if err := rows.Scan(&bucket, &p.Count); err != nil {
+			return nil, err
+		}
+		p.Date = w.Label(bucket)
+		p.Start = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), 0, 0, 0, w.Location)
+		points = append(points, p)
+	}
+	return points, rows.Err()
 }
 
 func calculatePercentageChange(current, previous int) float64 {
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{Pool: pool}
+}
+
+// UpsertFromIdentity creates or refreshes a user from identity provider claims.
+func (r *UserRepository) UpsertFromIdentity(ctx context.Context, issuer, subject, email, name, role string) (models.User, error) {
+	var u models.User
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO users (issuer, subject, email, name, role, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (issuer, subject) DO UPDATE SET
+			email = EXCLUDED.email,
+			name = EXCLUDED.name,
+			role = EXCLUDED.role,
+			last_login_at = EXCLUDED.last_login_at
+		RETURNING id, issuer, subject, COALESCE(email, ''), COALESCE(name, ''), role, is_active, created_at, last_login_at
+	`, issuer, subject, email, name, role).Scan(&u.ID, &u.Issuer, &u.Subject, &u.Email, &u.Name, &u.Role, &u.IsActive, &u.CreatedAt, &u.LastLoginAt)
+	return u, err
+}
+
+// EnsureDefaultMembership adds a user to the default workspace with the given
+// role unless they already belong to a workspace.
+func (r *UserRepository) EnsureDefaultMembership(ctx context.Context, userID int, role string) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		SELECT 1, $1, $2
+		WHERE NOT EXISTS (SELECT 1 FROM workspace_members WHERE user_id = $1)
+	`, userID, role)
+	return err
+}
+
+// CreateSession stores a hashed session token for a user.
+func (r *UserRepository) CreateSession(ctx context.Context, tokenHash string, userID int, expiresAt time.Time) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO sessions (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`, tokenHash, userID, expiresAt)
+	return err
+}
+
+// GetUserBySession returns the active user owning an unexpired session.
+func (r *UserRepository) GetUserBySession(ctx context.Context, tokenHash string) (models.User, error) {
+	var u models.User
+	err := r.Pool.QueryRow(ctx, `
+		SELECT u.id, u.issuer, u.subject, COALESCE(u.email, ''), COALESCE(u.name, ''), u.role, u.is_active, u.created_at, u.last_login_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.token_hash = $1 AND s.expires_at > CURRENT_TIMESTAMP AND u.is_active = TRUE
+	`, tokenHash).Scan(&u.ID, &u.Issuer, &u.Subject, &u.Email, &u.Name, &u.Role, &u.IsActive, &u.CreatedAt, &u.LastLoginAt)
+	return u, err
+}
+
+// GetUserByID returns a user by ID.
+func (r *UserRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	var u models.User
+	err := r.Pool.QueryRow(ctx, `
+		SELECT id, issuer, subject, COALESCE(email, ''), COALESCE(name, ''), role, is_active, created_at, last_login_at
+		FROM users WHERE id = $1
+	`, id).Scan(&u.ID, &u.Issuer, &u.Subject, &u.Email, &u.Name, &u.Role, &u.IsActive, &u.CreatedAt, &u.LastLoginAt)
+	return u, err
+}
+
+// DeleteSession removes a session (logout).
+func (r *UserRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM sessions WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+// PurgeExpiredSessions deletes sessions past their expiry.
+func (r *UserRepository) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	tag, err := r.Pool.Exec(ctx, "DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP")
+	return tag.RowsAffected(), err
+}
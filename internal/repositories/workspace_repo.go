@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WorkspaceRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewWorkspaceRepository(pool *pgxpool.Pool) *WorkspaceRepository {
+	return &WorkspaceRepository{Pool: pool}
+}
+
+// List returns every workspace.
+func (r *WorkspaceRepository) List(ctx context.Context) ([]models.Workspace, error) {
+	return r.query(ctx, "SELECT id, name, slug, created_at FROM workspaces ORDER BY id ASC")
+}
+
+// ListForUser returns the workspaces a user is a member of.
+func (r *WorkspaceRepository) ListForUser(ctx context.Context, userID int) ([]models.Workspace, error) {
+	return r.query(ctx, `
+		SELECT w.id, w.name, w.slug, w.created_at
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = $1
+		ORDER BY w.id ASC
+	`, userID)
+}
+
+func (r *WorkspaceRepository) query(ctx context.Context, sql string, args ...interface{}) ([]models.Workspace, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Workspace
+	for rows.Next() {
+		var w models.Workspace
+		if err := rows.Scan(&w.ID, &w.Name, &w.Slug, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+// Get returns a workspace by ID.
+func (r *WorkspaceRepository) Get(ctx context.Context, id int) (models.Workspace, error) {
+	var w models.Workspace
+	err := r.Pool.QueryRow(ctx, "SELECT id, name, slug, created_at FROM workspaces WHERE id = $1", id).
+		Scan(&w.ID, &w.Name, &w.Slug, &w.CreatedAt)
+	return w, err
+}
+
+// GetBySlug returns a workspace by slug.
+func (r *WorkspaceRepository) GetBySlug(ctx context.Context, slug string) (models.Workspace, error) {
+	var w models.Workspace
+	err := r.Pool.QueryRow(ctx, "SELECT id, name, slug, created_at FROM workspaces WHERE slug = $1", slug).
+		Scan(&w.ID, &w.Name, &w.Slug, &w.CreatedAt)
+	return w, err
+}
+
+// Create adds a workspace.
+func (r *WorkspaceRepository) Create(ctx context.Context, name, slug string) (models.Workspace, error) {
+	var w models.Workspace
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO workspaces (name, slug) VALUES ($1, $2)
+		RETURNING id, name, slug, created_at
+	`, name, slug).Scan(&w.ID, &w.Name, &w.Slug, &w.CreatedAt)
+	return w, err
+}
+
+// MemberRole returns a user's role in a workspace, or "" if not a member.
+func (r *WorkspaceRepository) MemberRole(ctx context.Context, workspaceID, userID int) (string, error) {
+	var role string
+	err := r.Pool.QueryRow(ctx, "SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID).Scan(&role)
+	if err != nil && err.Error() == "no rows in result set" {
+		return "", nil
+	}
+	return role, err
+}
+
+// ListMembers returns the members of a workspace.
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT u.id, COALESCE(u.name, ''), COALESCE(u.email, ''), m.role, m.created_at
+		FROM workspace_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.workspace_id = $1
+		ORDER BY m.created_at ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.WorkspaceMember
+	for rows.Next() {
+		var m models.WorkspaceMember
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetMemberByEmail adds a user (looked up by email) to a workspace or updates their role.
+func (r *WorkspaceRepository) SetMemberByEmail(ctx context.Context, workspaceID int, email, role string) (bool, error) {
+	tag, err := r.Pool.Exec(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		SELECT $1, id, $3 FROM users WHERE LOWER(email) = LOWER($2)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, workspaceID, email, role)
+	return tag.RowsAffected() > 0, err
+}
+
+// RemoveMember removes a user from a workspace.
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)
+	return err
+}
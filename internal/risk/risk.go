@@ -0,0 +1,236 @@
+// Package risk scores how exposed a domain is, from 0 to 100, out of what
+// it runs and what was found on it: vulnerable technologies, nuclei
+// findings, end-of-life software and exposure such as admin panels or
+// expired certificates, weighted by the domain's criticality.
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Kinds of factors, one per input.
+const (
+	KindCVE         = "cve"
+	KindNuclei      = "nuclei"
+	KindEOL         = "eol"
+	KindExposure    = "exposure"
+	KindCriticality = "criticality"
+)
+
+// MaxScore is the highest score.
+const MaxScore = 100
+
+// Points per technology by vulnerability risk level, and on top when a
+// public exploit exists.
+var techPoints = map[string]int{"Medium": 5, "High": 12, "Critical": 20}
+
+const exploitPoints = 5
+
+// Points per distinct nuclei finding by severity.
+var findingPoints = map[string]int{"low": 2, "medium": 6, "high": 15, "critical": 25}
+
+// Points per technology past end of life, or outdated.
+const (
+	eolPoints      = 10
+	outdatedPoints = 3
+)
+
+// Points per exposure.
+const (
+	panelPoints    = 10
+	expiredPoints  = 10
+	danglingPoints = 15
+	takeoverPoints = 25
+)
+
+// Caps keep one kind of input from drowning the others: forty vulnerable
+// libraries do not make a host ten times worse than four.
+var caps = map[string]int{KindCVE: 40, KindNuclei: 50, KindEOL: 25, KindExposure: 30}
+
+// criticalityWeight scales the score by what the host is worth. Unset
+// counts as Medium.
+var criticalityWeight = map[string]float64{"Low": 0.75, "Medium": 1, "High": 1.25, "Critical": 1.5}
+
+// AdminCategories are the technology categories whose presence means a
+// login or admin UI is reachable.
+var AdminCategories = []string{"Control panels", "Database managers"}
+
+// PanelTemplate reports whether a nuclei template finds an exposed login or
+// admin panel, as those under http/exposed-panels are named.
+func PanelTemplate(id string) bool {
+	id = strings.ToLower(id)
+	return strings.HasSuffix(id, "-panel") || strings.Contains(id, "-panel-") || strings.HasSuffix(id, "-login")
+}
+
+// Tech is a technology detected on the domain.
+type Tech struct {
+	Name      string
+	Version   string
+	RiskLevel string // of its vulnerability profile
+	CVECount  int
+	Exploit   bool
+	EOL       string // eol.Status, or empty
+	Behind    string // e.g. "2 majors behind", for outdated versions
+}
+
+// Finding is an active vulnerability found by nuclei.
+type Finding struct {
+	TemplateID string
+	Name       string
+	Severity   string
+}
+
+// Inputs is everything a domain's score is made of.
+type Inputs struct {
+	Criticality  string
+	Technologies []Tech
+	Findings     []Finding
+	AdminPanels  []string // technologies or findings that expose a login or admin UI
+	ExpiredCerts []string // ports serving a leaf certificate past its expiry, e.g. "port 443"
+	Dangling     bool     // the CNAME chain ends in NXDOMAIN
+	Takeover     string   // the service that can be claimed, when known
+}
+
+// Factor is one contribution to the score. Points of the factors add up to
+// the score; the criticality factor is negative for Low hosts.
+type Factor struct {
+	Kind   string `json:"kind"`
+	Label  string `json:"label"`
+	Points int    `json:"points"`
+}
+
+// Result is a domain's score, its level and why.
+type Result struct {
+	Score   int      `json:"score"`
+	Level   string   `json:"level"`
+	Factors []Factor `json:"factors"` // largest first
+}
+
+// Top returns the n largest factors.
+func (r Result) Top(n int) []Factor {
+	if len(r.Factors) <= n {
+		return r.Factors
+	}
+	return r.Factors[:n]
+}
+
+// Level names a score with one of models.CriticalityLevels.
+func Level(score int) string {
+	switch {
+	case score >= 70:
+		return "Critical"
+	case score >= 40:
+		return "High"
+	case score >= 15:
+		return "Medium"
+	}
+	return "Low"
+}
+
+// Compute scores a domain.
+func Compute(in Inputs) Result {
+	var factors []Factor
+	add := func(kind string, group []Factor) {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Points > group[j].Points })
+		left := caps[kind]
+		for _, f := range group {
+			if left <= 0 {
+				break
+			}
+			f.Points = min(f.Points, left)
+			left -= f.Points
+			factors = append(factors, f)
+		}
+	}
+
+	var cves, eols []Factor
+	for _, t := range in.Technologies {
+		if p := techPoints[t.RiskLevel]; p > 0 {
+			label := fmt.Sprintf("%s: %s risk", t.Name, t.RiskLevel)
+			if t.CVECount > 0 {
+				label += fmt.Sprintf(", %d CVEs", t.CVECount)
+			}
+			if t.Exploit {
+				p += exploitPoints
+				label += ", public exploit"
+			}
+			cves = append(cves, Factor{KindCVE, label, p})
+		}
+		switch t.EOL {
+		case "eol":
+			eols = append(eols, Factor{KindEOL, named(t) + " is end of life", eolPoints})
+		case "outdated":
+			label := named(t) + " is outdated"
+			if t.Behind != "" {
+				label += ", " + t.Behind
+			}
+			eols = append(eols, Factor{KindEOL, label, outdatedPoints})
+		}
+	}
+	add(KindCVE, cves)
+
+	var findings []Factor
+	seen := map[string]bool{}
+	for _, f := range in.Findings {
+		sev := strings.ToLower(f.Severity)
+		key := f.TemplateID
+		if key == "" {
+			key = f.Name
+		}
+		if p := findingPoints[sev]; p > 0 && !seen[key] {
+			seen[key] = true
+			findings = append(findings, Factor{KindNuclei, fmt.Sprintf("%s (%s)", f.Name, sev), p})
+		}
+	}
+	add(KindNuclei, findings)
+	add(KindEOL, eols)
+
+	var exposure []Factor
+	for _, p := range in.AdminPanels {
+		exposure = append(exposure, Factor{KindExposure, "Admin panel: " + p, panelPoints})
+	}
+	for _, c := range in.ExpiredCerts {
+		exposure = append(exposure, Factor{KindExposure, "Expired certificate on " + c, expiredPoints})
+	}
+	switch {
+	case in.Takeover != "":
+		exposure = append(exposure, Factor{KindExposure, "Dangling CNAME, claimable on " + in.Takeover, takeoverPoints})
+	case in.Dangling:
+		exposure = append(exposure, Factor{KindExposure, "Dangling CNAME", danglingPoints})
+	}
+	add(KindExposure, exposure)
+
+	// Past MaxScore, the smallest factors give way so the breakdown still
+	// adds up.
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].Points > factors[j].Points })
+	base := 0
+	for i := range factors {
+		factors[i].Points = min(factors[i].Points, MaxScore-base)
+		base += factors[i].Points
+	}
+	for len(factors) > 0 && factors[len(factors)-1].Points == 0 {
+		factors = factors[:len(factors)-1]
+	}
+
+	score := base
+	if w, ok := criticalityWeight[in.Criticality]; ok && base > 0 && w != 1 {
+		score = min(int(math.Round(float64(base)*w)), MaxScore)
+		if score != base {
+			factors = append(factors, Factor{KindCriticality, fmt.Sprintf("%s criticality (x%g)", in.Criticality, w), score - base})
+		}
+	}
+
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].Points > factors[j].Points })
+	return Result{Score: score, Level: Level(score), Factors: factors}
+}
+
+// named is a technology with its version, e.g. "nginx 1.18.0".
+func named(t Tech) string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return t.Name + " " + t.Version
+}
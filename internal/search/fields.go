@@ -0,0 +1,118 @@
+package search
+
+import "strings"
+
+// Kind is the type of a field's value.
+type Kind int
+
+const (
+	KindText    Kind = iota // matched case-insensitively; '*' is a wildcard
+	KindNumber              // integer, comparable
+	KindVersion             // dotted version, comparable
+	KindAge                 // age (7d) or date (2006-01-02), comparable
+	KindLevel               // one of Values, ordered lowest first, comparable
+	KindFlag                // one of Values
+)
+
+func (k Kind) comparable() bool {
+	return k == KindNumber || k == KindVersion || k == KindAge || k == KindLevel
+}
+
+// Field is a query field and the help shown by autocomplete.
+type Field struct {
+	Name    string   `json:"name"`
+	Kind    Kind     `json:"-"`
+	Values  []string `json:"values,omitempty"` // Level and Flag fields
+	Help    string   `json:"help"`
+	Example string   `json:"example"`
+}
+
+var riskLevels = []string{"low", "medium", "high", "critical"}
+
+// eolStatuses match eol.Statuses.
+var eolStatuses = []string{"supported", "outdated", "eol"}
+
+// DomainFields are the fields of the domain list query.
+var DomainFields = []Field{
+	{Name: "name", Kind: KindText, Help: "host name contains, or matches a * pattern", Example: "name:*.staging.example.com"},
+	{Name: "tech", Kind: KindText, Help: "runs a technology; combine with version: or eol: for the same detection", Example: "tech:nginx"},
+	{Name: "version", Kind: KindVersion, Help: "detected version, applies to tech: in the same group", Example: "version:<1.20"},
+	{Name: "eol", Kind: KindLevel, Values: eolStatuses, Help: "runs a supported, outdated or end-of-life version, applies to tech: in the same group", Example: "eol:>=outdated"},
+	{Name: "category", Kind: KindText, Help: "runs a technology of the category", Example: `category:"Web servers"`},
+	{Name: "risk", Kind: KindLevel, Values: riskLevels, Help: "runs a technology with this vulnerability risk", Example: "risk:>=high"},
+	{Name: "cloud", Kind: KindText, Help: "cloud provider", Example: "cloud:AWS"},
+	{Name: "asn", Kind: KindNumber, Help: "autonomous system number", Example: "asn:13335"},
+	{Name: "ip", Kind: KindText, Help: "resolves to an address or CIDR range", Example: "ip:203.0.113.0/24"},
+	{Name: "tag", Kind: KindText, Help: "has a tag key, or key=value", Example: "tag:env=prod"},
+	{Name: "owner", Kind: KindText, Help: "owner", Example: "owner:payments"},
+	{Name: "criticality", Kind: KindLevel, Values: riskLevels, Help: "domain criticality", Example: "criticality:>=high"},
+	{Name: "score", Kind: KindNumber, Help: "composite risk score, 0 to 100", Example: "score:>=40"},
+	{Name: "source", Kind: KindText, Help: "discovered by a source", Example: "source:crtsh"},
+	{Name: "confidence", Kind: KindNumber, Help: "average detection confidence", Example: "confidence:<60"},
+	{Name: "seen", Kind: KindAge, Help: "last detection, as an age or a date", Example: "seen:>7d"},
+	{Name: "created", Kind: KindAge, Help: "first scanned, as an age or a date", Example: "created:<24h"},
+	{Name: "is", Kind: KindFlag, Values: []string{"bookmarked", "dangling", "live"}, Help: "bookmarked, dangling CNAME or currently detected", Example: "is:dangling"},
+}
+
+// TechnologyFields are the fields of the technology list query.
+var TechnologyFields = []Field{
+	{Name: "name", Kind: KindText, Help: "technology name contains, or matches a * pattern", Example: "name:nginx"},
+	{Name: "category", Kind: KindText, Help: "category", Example: `category:"CDN"`},
+	{Name: "risk", Kind: KindLevel, Values: riskLevels, Help: "vulnerability risk", Example: "risk:>=high"},
+	{Name: "cve", Kind: KindNumber, Help: "known CVEs", Example: "cve:>10"},
+	{Name: "exploit", Kind: KindFlag, Values: []string{"true", "false"}, Help: "a public exploit is available", Example: "exploit:true"},
+	{Name: "domains", Kind: KindNumber, Help: "domains running it", Example: "domains:>=5"},
+	{Name: "version", Kind: KindVersion, Help: "detected on some domain at this version", Example: "version:<2"},
+	{Name: "eol", Kind: KindLevel, Values: eolStatuses, Help: "detected on some domain at a supported, outdated or end-of-life version", Example: "eol:eol"},
+	{Name: "seen", Kind: KindAge, Help: "last detection, as an age or a date", Example: "seen:<30d"},
+}
+
+// Lookup finds a field by name.
+func Lookup(fields []Field, name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Completion describes the word under the cursor at the end of a query.
+type Completion struct {
+	Prefix  string // the query before the word
+	Negated bool   // the word starts with '-'
+	Field   string // set once the word has a ':'; the field to suggest values for
+	Compare string // comparison typed before the value, e.g. ">="
+	Partial string // what was typed of the field name or value
+}
+
+// Complete splits the last word off a query for autocomplete.
+func Complete(q string) Completion {
+	start := strings.LastIndexAny(q, " \t\n(") + 1
+	if strings.Count(q, `"`)%2 == 1 {
+		start = strings.LastIndex(q, `"`)
+		start = strings.LastIndexAny(q[:start], " \t\n(") + 1
+	}
+	c := Completion{Prefix: q[:start]}
+	word := q[start:]
+	if strings.HasPrefix(word, "-") {
+		c.Negated, word = true, word[1:]
+	}
+	if field, value, ok := strings.Cut(word, ":"); ok {
+		c.Field = strings.ToLower(field)
+		value = strings.Trim(value, `"`)
+		c.Partial = strings.TrimLeft(value, "<>=")
+		c.Compare = value[:len(value)-len(c.Partial)]
+	} else {
+		c.Partial = word
+	}
+	return c
+}
+
+// Quote renders a value for a query, quoting it when needed.
+func Quote(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n()\":") {
+		return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return v
+}
@@ -0,0 +1,379 @@
+// Package search parses the query language of the domain and technology
+// lists, e.g.
+//
+//	tech:nginx version:<1.20 cloud:AWS asn:13335 risk:high tag:env=prod -category:"CDN" seen:>7d
+//
+// Terms are field:value pairs; a bare word matches the name. Terms next to
+// each other must all match; AND, OR, NOT (or a leading '-') and parentheses
+// combine them, NOT binding tightest and OR loosest. Numeric, version, level
+// and age fields take a comparison: field:<x, field:<=x, field:>x, field:>=x.
+//
+// Parse validates every value against the field catalog, so a parsed query
+// can be compiled to SQL without further checks. Compilation lives with the
+// repositories.
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/version"
+)
+
+// Limits keep a query from turning into an expensive statement.
+const (
+	maxTerms = 50
+	maxDepth = 20
+)
+
+// Op is how a Node combines its children.
+type Op string
+
+const (
+	OpTerm Op = "term"
+	OpAnd  Op = "and"
+	OpOr   Op = "or"
+	OpNot  Op = "not"
+)
+
+// Node is a parsed query: a term or a boolean combination of nodes.
+type Node struct {
+	Op       Op
+	Children []*Node // And and Or: two or more; Not: one
+	Term     Term    // Op == OpTerm
+}
+
+// Term is one validated field:value condition. Compare is "=", "<", "<=",
+// ">" or ">=". The parsed value is in the member matching the field kind.
+type Term struct {
+	Field   string
+	Compare string
+	Value   string          // text as written, lower case for Level and Flag fields
+	Number  int64           // Number fields; 1-based rank for Level fields
+	Version version.Version // Version fields
+	Time    time.Time
+}
+
+// Terms returns every term of the query, in order.
+func (n *Node) Terms() []Term {
+	if n == nil {
+		return nil
+	}
+	if n.Op == OpTerm {
+		return []Term{n.Term}
+	}
+	var terms []Term
+	for _, c := range n.Children {
+		terms = append(terms, c.Terms()...)
+	}
+	return terms
+}
+
+// Parse reads a query against a field catalog (DomainFields or
+// TechnologyFields). An empty query parses to nil.
+func Parse(q string, fields []Field) (*Node, error) {
+	return parseAt(q, fields, time.Now())
+}
+
+func parseAt(q string, fields []Field, now time.Time) (*Node, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, nil
+	}
+	p := &parser{toks: toks, fields: fields, now: now}
+	n, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	return n, nil
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokOpen
+	tokClose
+	tokNeg // leading '-'
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // as written
+	field  string // tokWord: before the first ':' outside quotes, if any
+	value  string // tokWord: after it (or the whole word), unquoted
+	quoted bool   // tokWord: the value was quoted, so it is never a keyword
+}
+
+// lex splits a query into words, parentheses and negations. Double quotes
+// group spaces into a word, e.g. category:"Web servers"; \" escapes a quote.
+func lex(q string) ([]token, error) {
+	var toks []token
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		switch c := rs[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokOpen, text: "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokClose, text: ")"})
+			i++
+		case c == '-' && i+1 < len(rs) && !strings.ContainsRune(" \t\n\r)", rs[i+1]):
+			toks = append(toks, token{kind: tokNeg, text: "-"})
+			i++
+		default:
+			start := i
+			var field, value strings.Builder
+			cur := &value
+			hasField, quoted := false, false
+			for i < len(rs) && !strings.ContainsRune(" \t\n\r()", rs[i]) {
+				switch {
+				case rs[i] == '"':
+					quoted = true
+					i++
+					for ; i < len(rs) && rs[i] != '"'; i++ {
+						if rs[i] == '\\' && i+1 < len(rs) {
+							i++
+						}
+						cur.WriteRune(rs[i])
+					}
+					if i == len(rs) {
+						return nil, fmt.Errorf("unterminated quote in %q", string(rs[start:]))
+					}
+					i++
+				case rs[i] == ':' && !hasField && !quoted:
+					hasField = true
+					field.WriteString(value.String())
+					value.Reset()
+					i++
+				default:
+					cur.WriteRune(rs[i])
+					i++
+				}
+			}
+			toks = append(toks, token{kind: tokWord, text: string(rs[start:i]), field: strings.ToLower(field.String()), value: value.String(), quoted: quoted})
+		}
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks   []token
+	pos    int
+	fields []Field
+	now    time.Time
+	terms  int
+}
+
+func (p *parser) peekKeyword(kw string) bool {
+	if p.pos >= len(p.toks) {
+		return false
+	}
+	t := p.toks[p.pos]
+	return t.kind == tokWord && !t.quoted && t.field == "" && t.value == kw
+}
+
+// or := and ("OR" and)*
+func (p *parser) or(depth int) (*Node, error) {
+	n, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		n = combine(OpOr, n, right)
+	}
+	return n, nil
+}
+
+// and := unary (["AND"] unary)*
+func (p *parser) and(depth int) (*Node, error) {
+	n, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.toks) && p.toks[p.pos].kind != tokClose && !p.peekKeyword("OR") {
+		if p.peekKeyword("AND") {
+			p.pos++
+		}
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		n = combine(OpAnd, n, right)
+	}
+	return n, nil
+}
+
+// unary := ("NOT" | "-") unary | "(" or ")" | term
+func (p *parser) unary(depth int) (*Node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("query is nested too deeply")
+	}
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("query ends early")
+	}
+	t := p.toks[p.pos]
+	switch {
+	case t.kind == tokNeg || p.peekKeyword("NOT"):
+		p.pos++
+		n, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Op: OpNot, Children: []*Node{n}}, nil
+	case t.kind == tokOpen:
+		p.pos++
+		n, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.toks) || p.toks[p.pos].kind != tokClose {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	case t.kind == tokClose:
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	case !t.quoted && t.field == "" && (t.value == "AND" || t.value == "OR"):
+		return nil, fmt.Errorf("%s needs a term on both sides", t.value)
+	}
+	p.pos++
+	p.terms++
+	if p.terms > maxTerms {
+		return nil, fmt.Errorf("query has more than %d terms", maxTerms)
+	}
+	term, err := p.term(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Op: OpTerm, Term: term}, nil
+}
+
+// combine joins two nodes, flattening runs of the same operator.
+func combine(op Op, left, right *Node) *Node {
+	n := &Node{Op: op}
+	for _, c := range []*Node{left, right} {
+		if c.Op == op {
+			n.Children = append(n.Children, c.Children...)
+		} else {
+			n.Children = append(n.Children, c)
+		}
+	}
+	return n
+}
+
+func (p *parser) term(t token) (Term, error) {
+	name := t.field
+	if name == "" {
+		name = "name"
+	}
+	f, ok := Lookup(p.fields, name)
+	if !ok {
+		return Term{}, fmt.Errorf("unknown field %q in %q", t.field, t.text)
+	}
+
+	term := Term{Field: f.Name, Compare: "=", Value: t.value}
+	if f.Kind.comparable() && !t.quoted {
+		for _, c := range []string{"<=", ">=", "<", ">", "="} {
+			if strings.HasPrefix(term.Value, c) {
+				term.Compare, term.Value = c, term.Value[len(c):]
+				break
+			}
+		}
+	}
+	term.Value = strings.TrimSpace(term.Value)
+	if term.Value == "" {
+		return term, fmt.Errorf("%s: needs a value", f.Name)
+	}
+
+	switch f.Kind {
+	case KindText:
+		if len(term.Value) > 255 {
+			return term, fmt.Errorf("%s: value is longer than 255 characters", f.Name)
+		}
+	case KindNumber:
+		v := strings.TrimPrefix(strings.ToUpper(term.Value), "AS")
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return term, fmt.Errorf("%s: %q is not a number", f.Name, term.Value)
+		}
+		term.Number = n
+	case KindVersion:
+		v, err := version.Parse(term.Value)
+		if err != nil {
+			return term, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		term.Version = v
+	case KindAge:
+		at, compare, err := parseAge(term.Value, term.Compare, p.now)
+		if err != nil {
+			return term, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		term.Time, term.Compare = at, compare
+	case KindLevel, KindFlag:
+		term.Value = strings.ToLower(term.Value)
+		rank := 0
+		for i, v := range f.Values {
+			if strings.EqualFold(v, term.Value) {
+				rank = i + 1
+			}
+		}
+		if rank == 0 {
+			return term, fmt.Errorf("%s: %q is not one of %s", f.Name, term.Value, strings.Join(f.Values, ", "))
+		}
+		term.Number = int64(rank)
+	}
+	return term, nil
+}
+
+// parseAge reads an age (30m, 12h, 7d, 2w, 1y) or a date (2006-01-02) and
+// returns the instant to compare timestamps against. Ages read as "how long
+// ago", so seen:>7d means last seen before now-7d and the comparison flips;
+// dates compare directly, so seen:>2024-01-01 means after that day.
+func parseAge(v, compare string, now time.Time) (time.Time, string, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		if compare == "=" {
+			compare = ">="
+		}
+		return t, compare, nil
+	}
+	if len(v) < 2 {
+		return time.Time{}, "", fmt.Errorf("%q is not an age like 7d or a date like 2006-01-02", v)
+	}
+	n, err := strconv.Atoi(v[:len(v)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, "", fmt.Errorf("%q is not an age like 7d or a date like 2006-01-02", v)
+	}
+	var d time.Duration
+	switch v[len(v)-1] {
+	case 'm':
+		d = time.Duration(n) * time.Minute
+	case 'h':
+		d = time.Duration(n) * time.Hour
+	case 'd':
+		d = time.Duration(n) * 24 * time.Hour
+	case 'w':
+		d = time.Duration(n) * 7 * 24 * time.Hour
+	case 'y':
+		d = time.Duration(n) * 365 * 24 * time.Hour
+	default:
+		return time.Time{}, "", fmt.Errorf("%q: unit must be m, h, d, w or y", v)
+	}
+	flipped := map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "=": ">="}[compare]
+	return now.Add(-d), flipped, nil
+}
@@ -10,20 +10,23 @@ import (
 	"time"
 
 	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AlertService struct {
-	Pool *pgxpool.Pool
+	Pool  *pgxpool.Pool
+	Audit *AuditService
 }
 
-func NewAlertService(pool *pgxpool.Pool) *AlertService {
-	return &AlertService{Pool: pool}
+func NewAlertService(pool *pgxpool.Pool, auditSvc *AuditService) *AlertService {
+	return &AlertService{Pool: pool, Audit: auditSvc}
 }
 
-// DispatchAlert sends an alert to all active channels.
+// DispatchAlert sends an alert to the active channels of the context's
+// workspace whose routing matches the domain's tags and criticality.
 func (s *AlertService) DispatchAlert(ctx context.Context, domainName, techName, riskLevel string) error {
-	channels, err := s.getActiveChannels(ctx)
+	channels, err := s.getActiveChannels(ctx, domainName)
 	if err != nil {
 		return err
 	}
@@ -35,6 +38,11 @@ func (s *AlertService) DispatchAlert(ctx context.Context, domainName, techName,
 		Message:   fmt.Sprintf("Critical Security Alert: %s detected on %s", techName, domainName),
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
+	s.Pool.QueryRow(ctx, `
+		SELECT COALESCE(d.owner, ''), COALESCE(d.criticality, ''),
+			ARRAY(SELECT CASE WHEN dt.value = '' THEN dt.key ELSE dt.key || '=' || dt.value END FROM domain_tags dt WHERE dt.domain_id = d.id ORDER BY dt.key)
+		FROM domains d WHERE d.name = $1 AND d.workspace_id = $2
+	`, domainName, workspace.FromContext(ctx)).Scan(&payload.Owner, &payload.Criticality, &payload.Tags)
 
 	jsonPayload, _ := json.Marshal(payload)
 
@@ -44,7 +52,9 @@ func (s *AlertService) DispatchAlert(ctx context.Context, domainName, techName,
 			if err != nil {
 				log.Printf("Failed to send alert to %s: %v", c.Name, err)
 			} else {
-				s.logAlertHistory(context.Background(), c.ID, domainName, techName, riskLevel)
+				s.logAlertHistory(context.WithoutCancel(ctx), c.ID, domainName, techName, riskLevel)
+				s.Audit.Record(context.WithoutCancel(ctx), "alert.dispatch", "alert_channel", c.ID, c.Name, nil,
+					map[string]string{"domain": domainName, "technology": techName, "risk": riskLevel})
 			}
 		}(ch)
 	}
@@ -52,8 +62,74 @@ func (s *AlertService) DispatchAlert(ctx context.Context, domainName, techName,
 	return nil
 }
 
-func (s *AlertService) getActiveChannels(ctx context.Context) ([]models.AlertChannel, error) {
-	rows, err := s.Pool.Query(ctx, "SELECT id, name, type, url FROM alert_channels WHERE is_active = TRUE")
+// DispatchQueryChange tells the active channels subscribed to a saved view
+// which domains entered or left it.
+func (s *AlertService) DispatchQueryChange(ctx context.Context, q models.SavedQuery, change models.SavedQueryChange) error {
+	rows, err := s.Pool.Query(ctx, `
+		SELECT id, name, type, url FROM alert_channels
+		WHERE is_active = TRUE AND workspace_id = $1 AND id = ANY($2::int[])
+	`, workspace.FromContext(ctx), q.Channels)
+	if err != nil {
+		return err
+	}
+	var channels []models.AlertChannel
+	for rows.Next() {
+		var c models.AlertChannel
+		if err := rows.Scan(&c.ID, &c.Name, &c.Type, &c.URL); err == nil {
+			channels = append(channels, c)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	payload := models.SavedQueryAlert{
+		View:         q.Name,
+		Query:        q.Query,
+		URL:          q.URL(),
+		Entered:      change.Entered,
+		Left:         change.Left,
+		EnteredCount: change.EnteredCount,
+		LeftCount:    change.LeftCount,
+		Message:      fmt.Sprintf("Saved view %q changed: %d domain(s) entered, %d left", q.Name, change.EnteredCount, change.LeftCount),
+		Timestamp:    change.ChangedAt.Format(time.RFC3339),
+	}
+	jsonPayload, _ := json.Marshal(payload)
+
+	for _, ch := range channels {
+		go func(c models.AlertChannel) {
+			err := s.sendToChannel(c, jsonPayload)
+			if err != nil {
+				log.Printf("Failed to send saved view change to %s: %v", c.Name, err)
+			} else {
+				s.Audit.Record(context.WithoutCancel(ctx), "alert.dispatch", "alert_channel", c.ID, c.Name, nil,
+					map[string]interface{}{"saved_query": q.Name, "entered": change.EnteredCount, "left": change.LeftCount})
+			}
+		}(ch)
+	}
+	return nil
+}
+
+// getActiveChannels returns the active channels routed to a domain: it
+// carries every route tag ("key" or "key=value") and is at least as critical
+// as the channel's minimum.
+func (s *AlertService) getActiveChannels(ctx context.Context, domainName string) ([]models.AlertChannel, error) {
+	rows, err := s.Pool.Query(ctx, `
+		SELECT c.id, c.name, c.type, c.url
+		FROM alert_channels c
+		LEFT JOIN domains d ON d.name = $2 AND d.workspace_id = c.workspace_id
+		WHERE c.is_active = TRUE AND c.workspace_id = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM unnest(c.route_tags) rt
+				WHERE NOT EXISTS (
+					SELECT 1 FROM domain_tags dt
+					WHERE dt.domain_id = d.id AND (dt.key = rt OR dt.key || '=' || dt.value = rt)
+				)
+			)
+			AND (c.min_criticality IS NULL OR
+				array_position($3::text[], d.criticality) >= array_position($3::text[], c.min_criticality))
+	`, workspace.FromContext(ctx), domainName, models.CriticalityLevels)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +161,8 @@ func (s *AlertService) sendToChannel(ch models.AlertChannel, payload []byte) err
 func (s *AlertService) logAlertHistory(ctx context.Context, channelID int, domainName, techName, riskLevel string) {
 	_, err := s.Pool.Exec(ctx, `
 		INSERT INTO alert_history (channel_id, domain_id, tech_name, risk_level)
-		VALUES ($1, (SELECT id FROM domains WHERE name = $2), $3, $4)
-	`, channelID, domainName, techName, riskLevel)
+		VALUES ($1, (SELECT id FROM domains WHERE name = $2 AND workspace_id = $5), $3, $4)
+	`, channelID, domainName, techName, riskLevel, workspace.FromContext(ctx))
 	if err != nil {
 		log.Printf("Error logging alert history: %v", err)
 	}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+type AuditService struct {
+	Repo *repositories.AuditRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{Repo: repo}
+}
+
+// Record describes the current request's action, or, outside a request
+// (background jobs, CLI, work that outlived its request), appends an event
+// right away attributed to whoever started it.
+func (s *AuditService) Record(ctx context.Context, action, targetType string, targetID int, targetName string, before, after interface{}) {
+	if audit.Describe(ctx, action, targetType, targetID, targetName, before, after) {
+		return
+	}
+
+	e := audit.Origin(ctx)
+	e.WorkspaceID = workspace.FromContext(ctx)
+	audit.Fill(e, action, targetType, targetID, targetName, before, after)
+	if err := s.Persist(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("Audit: failed to record %s: %v", action, err)
+	}
+}
+
+// Persist appends a finished event to the log.
+func (s *AuditService) Persist(ctx context.Context, e *models.AuditEvent) error {
+	return s.Repo.Insert(ctx, e)
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/integrations/oidc"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+const defaultSessionTTL = 12 * time.Hour
+
+type AuthService struct {
+	Users      *repositories.UserRepository
+	OIDC       *oidc.Client
+	SessionTTL time.Duration
+}
+
+func NewAuthService(users *repositories.UserRepository, oidcClient *oidc.Client) *AuthService {
+	return &AuthService{Users: users, OIDC: oidcClient, SessionTTL: defaultSessionTTL}
+}
+
+// LoginState is the per-attempt secret material kept by the browser between
+// the redirect to the provider and the callback.
+type LoginState struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// Enabled reports whether SSO is configured. When it is not, SigMap runs
+// without authentication as before.
+func (s *AuthService) Enabled() bool {
+	return s.OIDC != nil && s.OIDC.Config.Enabled()
+}
+
+// BeginLogin generates state, nonce and PKCE verifier and returns the
+// provider authorization URL.
+func (s *AuthService) BeginLogin(ctx context.Context) (string, LoginState, error) {
+	var ls LoginState
+	var err error
+	if ls.State, err = oidc.RandomString(24); err != nil {
+		return "", ls, err
+	}
+	if ls.Nonce, err = oidc.RandomString(24); err != nil {
+		return "", ls, err
+	}
+	if ls.Verifier, err = oidc.RandomString(48); err != nil {
+		return "", ls, err
+	}
+
+	authURL, err := s.OIDC.AuthCodeURL(ctx, ls.State, ls.Nonce, ls.Verifier)
+	return authURL, ls, err
+}
+
+// CompleteLogin redeems the authorization code, verifies the ID token,
+// provisions the user and opens a session. It returns the raw session token.
+func (s *AuthService) CompleteLogin(ctx context.Context, code string, ls LoginState) (models.User, string, time.Time, error) {
+	tok, err := s.OIDC.Exchange(ctx, code, ls.Verifier)
+	if err != nil {
+		return models.User{}, "", time.Time{}, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	claims, err := s.OIDC.VerifyIDToken(ctx, tok.IDToken, ls.Nonce)
+	if err != nil {
+		return models.User{}, "", time.Time{}, fmt.Errorf("id_token verification failed: %w", err)
+	}
+
+	// Groups are often only released through the userinfo endpoint.
+	if len(claims.Strings(s.OIDC.Config.RoleClaim)) == 0 {
+		if info, err := s.OIDC.Userinfo(ctx, tok.AccessToken); err != nil {
+			log.Printf("OIDC: userinfo lookup failed: %v", err)
+		} else if info != nil && info["sub"] == claims["sub"] {
+			for k, v := range info {
+				if _, ok := claims[k]; !ok {
+					claims[k] = v
+				}
+			}
+		}
+	}
+
+	name := claims.String("name")
+	if name == "" {
+		name = claims.String("preferred_username")
+	}
+
+	user, err := s.Users.UpsertFromIdentity(ctx, s.OIDC.Config.IssuerURL, claims.String("sub"), claims.String("email"), name, s.MapRole(claims))
+	if err != nil {
+		return models.User{}, "", time.Time{}, fmt.Errorf("failed to provision user: %w", err)
+	}
+	if !user.IsActive {
+		return models.User{}, "", time.Time{}, fmt.Errorf("user %s is disabled", user.DisplayName())
+	}
+	if err := s.Users.EnsureDefaultMembership(ctx, user.ID, user.Role); err != nil {
+		return models.User{}, "", time.Time{}, fmt.Errorf("failed to provision workspace membership: %w", err)
+	}
+
+	token, err := oidc.RandomString(32)
+	if err != nil {
+		return models.User{}, "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.SessionTTL)
+	if err := s.Users.CreateSession(ctx, HashToken(token), user.ID, expiresAt); err != nil {
+		return models.User{}, "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	audit.SetActor(ctx, models.ActorUser, user.ID, user.DisplayName())
+	audit.Describe(ctx, "auth.login", "user", user.ID, user.DisplayName(), nil, map[string]string{"role": user.Role, "email": user.Email})
+	log.Printf("Auth: %s logged in via SSO as %s", user.DisplayName(), user.Role)
+	return user, token, expiresAt, nil
+}
+
+// MapRole picks the highest role granted by the configured role claim.
+// Only configured values grant a role, and SigMap role names only when
+// opted into.
+func (s *AuthService) MapRole(claims oidc.Claims) string {
+	cfg := s.OIDC.Config
+	values := claims.Strings(cfg.RoleClaim)
+
+	role := cfg.DefaultRole
+	for _, v := range values {
+		granted := ""
+		switch {
+		case containsValue(cfg.AdminValues, v):
+			granted = models.RoleAdmin
+		case containsValue(cfg.AnalystValues, v):
+			granted = models.RoleAnalyst
+		case cfg.RoleNames && (v == models.RoleAdmin || v == models.RoleAnalyst || v == models.RoleViewer):
+			granted = v
+		}
+		if models.RoleRank(granted) > models.RoleRank(role) {
+			role = granted
+		}
+	}
+	if models.RoleRank(role) == 0 {
+		role = models.RoleViewer
+	}
+	return role
+}
+
+// ResolveSession returns the user for a raw session token.
+func (s *AuthService) ResolveSession(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.Users.GetUserBySession(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Logout invalidates a session.
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if err := s.Users.DeleteSession(ctx, HashToken(token)); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "auth.logout", "", 0, "", nil, nil)
+	return nil
+}
+
+// HashToken returns the hex SHA-256 of a secret token for storage.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func containsValue(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
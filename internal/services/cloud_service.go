@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Abhaythakor/SigMap/internal/cloudinv"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+type CloudService struct {
+	Repo *repositories.CloudRepository
+}
+
+func NewCloudService(repo *repositories.CloudRepository) *CloudService {
+	return &CloudService{Repo: repo}
+}
+
+// CloudImport summarizes a cloud inventory import.
+type CloudImport struct {
+	Format    string `json:"format"`
+	Resources int    `json:"resources"`
+	Skipped   int    `json:"skipped"` // DNS records of unsupported types
+	Linked    int    `json:"linked_domains"`
+}
+
+// Import reads a cloud inventory export into the current workspace,
+// replacing what an earlier import of the same source stored, and relinks
+// the workspace's hosts to their resources. account names the AWS account
+// or GCP project for DNS exports, which do not carry it.
+func (s *CloudService) Import(ctx context.Context, r io.Reader, account, source string) (CloudImport, error) {
+	inv, err := cloudinv.Parse(r, account)
+	res := CloudImport{Format: inv.Format, Skipped: inv.Skipped}
+	if err != nil {
+		return res, err
+	}
+	for i := range inv.Resources {
+		inv.Resources[i].Source = source
+	}
+
+	if res.Resources, err = s.Repo.ReplaceSource(ctx, source, inv.Resources); err != nil {
+		return res, fmt.Errorf("storing %s resources: %w", inv.Format, err)
+	}
+	if res.Linked, err = s.Repo.Reconcile(ctx); err != nil {
+		return res, fmt.Errorf("linking hosts: %w", err)
+	}
+	return res, nil
+}
+
+// ReconcileAll relinks hosts to cloud resources in every workspace with an
+// inventory, picking up hosts and resolutions found since the import.
+func (s *CloudService) ReconcileAll(ctx context.Context) error {
+	ids, err := s.Repo.WorkspacesWithInventory(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := s.Repo.Reconcile(workspace.WithID(ctx, id)); err != nil {
+			log.Printf("Cloud: failed to reconcile workspace %d: %v", id, err)
+		}
+	}
+	return nil
+}
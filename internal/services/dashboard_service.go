@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+// Refresh triggers recorded with each dashboard view refresh.
+const (
+	RefreshSchedule = "schedule"
+	RefreshIngest   = "ingest"
+	RefreshManual   = "manual"
+)
+
+// DashboardService keeps the dashboard's materialized views current. They
+// are refreshed on a schedule, and sooner once database triggers mark them
+// dirty after an ingest changed what they are built from.
+type DashboardService struct {
+	Repo *repositories.DashboardRepository
+	mu   sync.Mutex // one refresh at a time
+}
+
+func NewDashboardService(repo *repositories.DashboardRepository) *DashboardService {
+	return &DashboardService{Repo: repo}
+}
+
+// RefreshAll refreshes every dashboard view.
+func (s *DashboardService) RefreshAll(ctx context.Context) error {
+	return s.Refresh(ctx, RefreshSchedule)
+}
+
+// Refresh refreshes every dashboard view, recording trigger as the reason.
+// A view failing does not stop the others; the first error is returned.
+func (s *DashboardService) Refresh(ctx context.Context, trigger string) error {
+	return s.refresh(ctx, repositories.DashboardViews, trigger)
+}
+
+// RefreshDirty refreshes the views whose sources changed since their last
+// refresh.
+func (s *DashboardService) RefreshDirty(ctx context.Context) error {
+	views, err := s.Repo.ViewRefreshes(ctx)
+	if err != nil {
+		return err
+	}
+	var dirty []string
+	for _, v := range views {
+		if v.DirtySince != nil {
+			dirty = append(dirty, v.Name)
+		}
+	}
+	return s.refresh(ctx, dirty, RefreshIngest)
+}
+
+func (s *DashboardService) refresh(ctx context.Context, views []string, trigger string) error {
+	if len(views) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	var first error
+	for _, name := range views {
+		if err := s.Repo.RefreshView(ctx, name, trigger); err != nil {
+			log.Printf("Dashboard: refreshing %s failed: %v", name, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	if first == nil && trigger != RefreshSchedule {
+		log.Printf("Dashboard: refreshed %d views (%s) in %s", len(views), trigger, time.Since(start).Round(time.Millisecond))
+	}
+	return first
+}
+
+// Refreshes returns the refresh state of the dashboard views.
+func (s *DashboardService) Refreshes(ctx context.Context) ([]repositories.ViewRefresh, error) {
+	return s.Repo.ViewRefreshes(ctx)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Abhaythakor/SigMap/internal/integrations/ctlog"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/publicsuffix"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+// SubdomainSource is a provider of subdomains for a root domain, such as
+// Chaos or a Certificate Transparency search.
+type SubdomainSource interface {
+	// Name is recorded against every domain the source reports.
+	Name() string
+	FetchSubdomains(ctx context.Context, domain string) ([]string, error)
+}
+
+type DiscoveryService struct {
+	Repo    *repositories.DomainRepository
+	Sources []SubdomainSource
+}
+
+func NewDiscoveryService(repo *repositories.DomainRepository, sources ...SubdomainSource) *DiscoveryService {
+	return &DiscoveryService{Repo: repo, Sources: sources}
+}
+
+// DiscoverSubdomains queries every source concurrently, merges their
+// results, and saves each subdomain with the sources that reported it. It
+// fails only when every source fails.
+func (s *DiscoveryService) DiscoverSubdomains(ctx context.Context, rootDomain string) ([]string, error) {
+	rootDomain = strings.ToLower(strings.TrimSuffix(rootDomain, "."))
+
+	var (
+		mu    sync.Mutex
+		found = map[string][]string{}
+		errs  []error
+		wg    sync.WaitGroup
+	)
+	for _, src := range s.Sources {
+		wg.Add(1)
+		go func(src SubdomainSource) {
+			defer wg.Done()
+			subs, err := src.FetchSubdomains(ctx, rootDomain)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("Discovery: %s failed for %s: %v", src.Name(), rootDomain, err)
+				errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+				return
+			}
+			for _, sub := range subs {
+				if sub = ctlog.Normalize(sub); sub != "" && ctlog.Under(sub, rootDomain) {
+					found[sub] = append(found[sub], src.Name())
+				}
+			}
+		}(src)
+	}
+	wg.Wait()
+	if len(s.Sources) > 0 && len(errs) == len(s.Sources) {
+		return nil, errors.Join(errs...)
+	}
+
+	names := make([]string, 0, len(found))
+	for name, sources := range found {
+		names = append(names, name)
+		for _, source := range dedupe(sources) {
+			if _, err := s.Repo.EnsureDomainFrom(ctx, name, source); err != nil {
+				log.Printf("Discovery: Failed to save subdomain %s: %v", name, err)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DumpImport summarizes an offline CT dump import.
+type DumpImport struct {
+	Imported int
+	Skipped  int
+}
+
+// ImportCTDump adds the names in an offline Certificate Transparency dump
+// (crt.sh JSON, JSON lines, or one name per line) to the current workspace.
+// Only names under a root domain the workspace already tracks are kept, so
+// full log dumps can be imported as is: memory grows with the names kept,
+// not with the dump.
+func (s *DiscoveryService) ImportCTDump(ctx context.Context, r io.Reader) (DumpImport, error) {
+	var res DumpImport
+	tracked, err := s.Repo.RootDomainNames(ctx)
+	if err != nil {
+		return res, err
+	}
+	seen := map[string]bool{}
+	err = ctlog.Decode(r, func(name string) error {
+		root, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil || !tracked[root] {
+			res.Skipped++
+			return nil
+		}
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		if _, err := s.Repo.EnsureDomainFrom(ctx, name, models.SourceCTDump); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		res.Imported++
+		return nil
+	})
+	return res, err
+}
+
+func dedupe(items []string) []string {
+	seen := map[string]bool{}
+	out := items[:0]
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/dns"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+const (
+	// dnsRefreshAge is how old a host's last resolution may get before the
+	// background refresh resolves it again.
+	dnsRefreshAge = 24 * time.Hour
+	// dnsRefreshBatch bounds the hosts resolved per refresh run.
+	dnsRefreshBatch = 500
+	dnsWorkers      = 8
+)
+
+type DNSService struct {
+	Repo   *repositories.DomainRepository
+	Assets *repositories.AssetRepository
+	Client *dns.Client
+}
+
+func NewDNSService(repo *repositories.DomainRepository, assets *repositories.AssetRepository, client *dns.Client) *DNSService {
+	return &DNSService{Repo: repo, Assets: assets, Client: client}
+}
+
+// Enrich resolves every record type of a host, stores the records with their
+// history, links the A/AAAA answers into the asset graph, and flags dangling
+// CNAMEs.
+func (s *DNSService) Enrich(ctx context.Context, domainID int, name string) (*dns.Result, error) {
+	res, err := s.Client.Resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.DNSRecord, 0, len(res.Records))
+	for _, rr := range res.Records {
+		records = append(records, models.DNSRecord{Type: dns.TypeName(rr.Type), Value: rr.Data, TTL: int(rr.TTL)})
+	}
+	status := models.DNSStatus{
+		Resolver:   res.Resolver,
+		Rcode:      dns.RcodeName(res.Rcode),
+		CNAMEChain: res.Chain,
+		Dangling:   res.Dangling(),
+	}
+	if res.Takeover != nil {
+		status.TakeoverTarget = res.Takeover.Target
+		status.TakeoverService = res.Takeover.Service
+		if res.Takeover.Vulnerable() {
+			log.Printf("DNS: possible subdomain takeover of %s via %s (%s)", name, res.Takeover.Target, res.Takeover.Service)
+		} else {
+			log.Printf("DNS: dangling CNAME %s -> %s", name, res.Takeover.Target)
+		}
+	}
+
+	if err := s.Repo.SaveDNS(ctx, domainID, records, status); err != nil {
+		return res, err
+	}
+	if _, err := s.Assets.RecordResolution(ctx, domainID, res.Addresses()); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// RefreshStale re-resolves hosts in every workspace whose records are older
+// than a day, building up record history between scans.
+func (s *DNSService) RefreshStale(ctx context.Context) error {
+	refs, err := s.Repo.ListStaleDNS(ctx, time.Now().Add(-dnsRefreshAge), dnsRefreshBatch)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	log.Printf("DNS: refreshing %d hosts", len(refs))
+
+	jobs := make(chan repositories.DomainRef)
+	var wg sync.WaitGroup
+	for i := 0; i < dnsWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				wctx := workspace.WithID(ctx, ref.WorkspaceID)
+				if _, err := s.Enrich(wctx, ref.ID, ref.Name); err != nil {
+					log.Printf("DNS: failed to resolve %s: %v", ref.Name, err)
+				}
+			}
+		}()
+	}
+	for _, ref := range refs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- ref
+	}
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/eol"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/version"
+)
+
+// DefaultEOLSource is the endoflife.date API. EOL_SOURCE or -eol-from may
+// name a mirror URL or a local directory holding {product}.json instead,
+// or a single file: one product named after the file, or an object of
+// product name to cycles.
+const DefaultEOLSource = "https://endoflife.date/api"
+
+// maxEOLDoc caps a downloaded product document.
+const maxEOLDoc = 8 << 20
+
+// errNoProduct marks a product the source does not know.
+var errNoProduct = errors.New("product not found")
+
+type EOLService struct {
+	Repo   *repositories.EOLRepository
+	Source string
+	Client *http.Client
+}
+
+func NewEOLService(repo *repositories.EOLRepository, source string) *EOLService {
+	if source == "" {
+		source = DefaultEOLSource
+	}
+	return &EOLService{Repo: repo, Source: source, Client: &http.Client{Timeout: time.Minute}}
+}
+
+// EOLImport reports what an import stored.
+type EOLImport struct {
+	Source   string   `json:"source"`
+	Products int      `json:"products"`
+	Cycles   int      `json:"cycles"`
+	Missing  []string `json:"missing,omitempty"` // mapped products the source does not have
+	Judged   int      `json:"judged"`            // distinct detected versions judged afterwards
+}
+
+// Import fetches release cycles from a URL, directory or file, stores them
+// and judges every detection again. From a URL or directory, only the
+// products technologies are mapped to are fetched.
+func (s *EOLService) Import(ctx context.Context, source string) (*EOLImport, error) {
+	if source == "" {
+		source = s.Source
+	}
+	log.Printf("Importing end-of-life data from %s...", source)
+	res := &EOLImport{Source: source}
+
+	if path, ok := localPath(source); ok {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			products, err := readEOLFile(path, data)
+			if err != nil {
+				return nil, err
+			}
+			for product, cycles := range products {
+				if err := s.Repo.ReplaceProduct(ctx, product, source, cycles); err != nil {
+					return nil, err
+				}
+				res.Products++
+				res.Cycles += len(cycles)
+			}
+			return s.judge(ctx, res)
+		}
+	}
+
+	products, err := s.Repo.MappedProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		data, err := s.fetch(ctx, source, product)
+		if errors.Is(err, errNoProduct) {
+			res.Missing = append(res.Missing, product)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", product, err)
+		}
+		cycles, err := eol.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", product, err)
+		}
+		if err := s.Repo.ReplaceProduct(ctx, product, source, cycles); err != nil {
+			return nil, err
+		}
+		res.Products++
+		res.Cycles += len(cycles)
+	}
+	return s.judge(ctx, res)
+}
+
+func (s *EOLService) judge(ctx context.Context, res *EOLImport) (*EOLImport, error) {
+	n, err := s.evaluate(ctx)
+	res.Judged = n
+	if err != nil {
+		return res, err
+	}
+	log.Printf("Imported %d end-of-life products (%d cycles), judged %d detected versions", res.Products, res.Cycles, n)
+	return res, nil
+}
+
+// readEOLFile reads a single product, named after the file, or a bundle.
+func readEOLFile(path string, data []byte) (map[string][]eol.Cycle, error) {
+	if cycles, err := eol.Parse(data); err == nil {
+		product := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		return map[string][]eol.Cycle{product: cycles}, nil
+	}
+	return eol.ParseBundle(data)
+}
+
+// localPath returns the file system path of a source that is not a URL.
+func localPath(source string) (string, bool) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return "", false
+	}
+	return strings.TrimPrefix(source, "file://"), true
+}
+
+func (s *EOLService) fetch(ctx context.Context, source, product string) ([]byte, error) {
+	name := product + ".json"
+	if path, ok := localPath(source); ok {
+		data, err := os.ReadFile(filepath.Join(path, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errNoProduct
+		}
+		return data, err
+	}
+
+	url := strings.TrimSuffix(source, "/") + "/" + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNoProduct
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxEOLDoc))
+}
+
+// Evaluate judges every detected version of a mapped technology again, so
+// new detections get a verdict and end-of-life dates that pass take effect.
+func (s *EOLService) Evaluate(ctx context.Context) error {
+	_, err := s.evaluate(ctx)
+	return err
+}
+
+func (s *EOLService) evaluate(ctx context.Context) (int, error) {
+	targets, err := s.Repo.Targets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	cycles := map[string][]eol.Cycle{}
+	for _, t := range targets {
+		if _, ok := cycles[t.Product]; !ok {
+			if cycles[t.Product], err = s.Repo.Cycles(ctx, t.Product); err != nil {
+				return 0, err
+			}
+		}
+		var res *eol.Result
+		if v, err := version.Parse(t.Version); err == nil {
+			if r, ok := eol.Check(cycles[t.Product], v, now); ok {
+				res = &r
+			}
+		}
+		if err := s.Repo.SetStatus(ctx, t.TechnologyID, t.Version, res); err != nil {
+			return 0, err
+		}
+	}
+	return len(targets), s.Repo.ClearStale(ctx)
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+// fingerprintRecheck is how often the cached engine checks whether a sync
+// has changed the stored fingerprints.
+const fingerprintRecheck = time.Minute
+
+// ErrNoFingerprints is returned when no signatures have been synced yet.
+var ErrNoFingerprints = errors.New("no fingerprints synced; run with -sync first")
+
+// ErrInvalidFingerprint wraps the reason a custom fingerprint was rejected.
+var ErrInvalidFingerprint = errors.New("invalid fingerprint")
+
+// FingerprintService detects technologies in-process with the synced
+// Wappalyzer matchers, without the httpx binary.
+type FingerprintService struct {
+	Repo    *repositories.FingerprintRepository
+	Domains *repositories.DomainRepository
+	Client  *http.Client
+
+	mu        sync.Mutex
+	engine    *fingerprint.Engine
+	stamp     string
+	checkedAt time.Time
+}
+
+func NewFingerprintService(repo *repositories.FingerprintRepository, domains *repositories.DomainRepository) *FingerprintService {
+	return &FingerprintService{Repo: repo, Domains: domains, Client: fingerprint.NewClient()}
+}
+
+// Engine returns the compiled engine, rebuilding it when the stored
+// fingerprints have changed since it was built.
+func (s *FingerprintService) Engine(ctx context.Context) (*fingerprint.Engine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.engine != nil && time.Since(s.checkedAt) < fingerprintRecheck {
+		return s.engine, nil
+	}
+	stamp, err := s.Repo.Stamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.checkedAt = time.Now()
+	if s.engine != nil && stamp == s.stamp {
+		return s.engine, nil
+	}
+
+	fps, err := s.Repo.LoadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	engine := fingerprint.New(fps)
+	if engine.Len() == 0 {
+		return nil, ErrNoFingerprints
+	}
+	log.Printf("Fingerprints: compiled %d technologies (%d patterns unsupported by RE2 skipped)", engine.Len(), engine.Skipped)
+	s.engine, s.stamp = engine, stamp
+	return engine, nil
+}
+
+// Detect fetches a URL and analyzes the response.
+func (s *FingerprintService) Detect(ctx context.Context, target string) (*fingerprint.Response, []fingerprint.Detection, error) {
+	engine, err := s.Engine(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := fingerprint.Fetch(ctx, s.Client, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, engine.Analyze(resp), nil
+}
+
+// DetectSaved analyzes a saved response (raw HTTP or bare HTML) served from
+// target, which may be empty.
+func (s *FingerprintService) DetectSaved(ctx context.Context, r io.Reader, target string) ([]fingerprint.Detection, error) {
+	engine, err := s.Engine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fingerprint.ReadResponse(r, target)
+	if err != nil {
+		return nil, fmt.Errorf("reading saved response: %w", err)
+	}
+	return engine.Analyze(resp), nil
+}
+
+// ScanDomain fingerprints a domain over HTTPS, falling back to HTTP, and
+// records what it finds.
+func (s *FingerprintService) ScanDomain(ctx context.Context, domain string) error {
+	var resp *fingerprint.Response
+	var detections []fingerprint.Detection
+	var err error
+	for _, scheme := range []string{"https://", "http://"} {
+		resp, detections, err = s.Detect(ctx, scheme+domain)
+		if err == nil || errors.Is(err, ErrNoFingerprints) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	domainID, err := s.Domains.EnsureDomain(ctx, domain)
+	if err != nil {
+		return err
+	}
+	for _, d := range detections {
+		if err := s.Domains.AddDetection(ctx, domainID, d.Name, resp.URL, d.Version, d.Confidence, "fingerprint"); err != nil {
+			log.Printf("Fingerprints: failed to record %s on %s: %v", d.Name, domain, err)
+		}
+	}
+	if err := s.Domains.ApplyRelations(ctx, domainID); err != nil {
+		log.Printf("Fingerprints: failed to apply technology relations on %s: %v", domain, err)
+	}
+	log.Printf("Fingerprints: %d technologies on %s", len(detections), resp.URL)
+	return nil
+}
+
+// SaveCustom validates a custom fingerprint and stores it as a new revision;
+// id 0 creates one. It returns the technology ID and the revision.
+func (s *FingerprintService) SaveCustom(ctx context.Context, id int, name string, fp fingerprint.Fingerprint, createdBy string) (int, int, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return 0, 0, fmt.Errorf("%w: name is required", ErrInvalidFingerprint)
+	case strings.Contains(name, ":"):
+		// Detections are reported as "Name:version".
+		return 0, 0, fmt.Errorf("%w: name may not contain ':'", ErrInvalidFingerprint)
+	}
+	if err := fp.Validate(); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidFingerprint, err)
+	}
+	return s.Repo.SaveCustom(ctx, id, name, fp.Description, fp.Website, fp, createdBy)
+}
@@ -3,20 +3,29 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/Abhaythakor/SigMap/internal/integrations/runner"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
 type HTTPXService struct {
 	Repo   *repositories.DomainRepository
+	Assets *repositories.AssetRepository
 	Runner *runner.Runner
+	// Local detects in-process when httpx is unavailable, or always when
+	// PreferLocal is set (TECH_DETECT=local).
+	Local       *FingerprintService
+	PreferLocal bool
 }
 
-func NewHTTPXService(repo *repositories.DomainRepository, r *runner.Runner) *HTTPXService {
-	return &HTTPXService{Repo: repo, Runner: r}
+func NewHTTPXService(repo *repositories.DomainRepository, assets *repositories.AssetRepository, r *runner.Runner, local *FingerprintService) *HTTPXService {
+	return &HTTPXService{Repo: repo, Assets: assets, Runner: r, Local: local}
 }
 
 type HTTPXResult struct {
@@ -26,19 +35,33 @@ type HTTPXResult struct {
 	StatusCode   int      `json:"status_code"`
 	Title        string   `json:"title"`
 	WebServer    string   `json:"webserver"`
+	Host         string   `json:"host"` // resolved IP
+	Port         string   `json:"port"`
+	Scheme       string   `json:"scheme"`
 }
 
 // ScanDomain runs httpx on a domain and ingests results.
 func (s *HTTPXService) ScanDomain(ctx context.Context, domain string) error {
+	if s.PreferLocal && s.Local != nil {
+		return s.Local.ScanDomain(ctx, domain)
+	}
 	log.Printf("HTTPX: Scanning %s", domain)
 
 	// Execute httpx -json
 	output, err := s.Runner.Execute(ctx, "httpx", "-u", domain, "-json", "-silent", "-tech-detect")
 	if err != nil {
-		log.Printf("HTTPX: execution failed (maybe not installed?), simulating results: %v", err)
+		if s.Local != nil {
+			log.Printf("HTTPX: execution failed (maybe not installed?), using local fingerprints: %v", err)
+			err = s.Local.ScanDomain(ctx, domain)
+			if !errors.Is(err, ErrNoFingerprints) {
+				return err
+			}
+		}
+		log.Printf("HTTPX: no detector available, simulating results: %v", err)
 		return s.simulateScan(ctx, domain)
 	}
 
+	domainID := 0
 	lines := strings.Split(string(output), "\n")
 	for _, line := range lines {
 		if line == "" {
@@ -50,7 +73,7 @@ func (s *HTTPXService) ScanDomain(ctx context.Context, domain string) error {
 			continue
 		}
 
-		domainID, err := s.Repo.EnsureDomain(ctx, domain)
+		domainID, err = s.Repo.EnsureDomain(ctx, domain)
 		if err != nil {
 			continue
 		}
@@ -59,14 +82,37 @@ func (s *HTTPXService) ScanDomain(ctx context.Context, domain string) error {
 			s.Repo.AddDetection(ctx, domainID, res.WebServer, res.URL, "", 100, "httpx")
 		}
 
+		s.recordService(ctx, domainID, res)
+
 		for _, tech := range res.Technologies {
 			s.Repo.AddDetection(ctx, domainID, tech, res.URL, "", 90, "httpx")
 		}
 	}
 
+	if domainID > 0 {
+		if err := s.Repo.ApplyRelations(ctx, domainID); err != nil {
+			log.Printf("HTTPX: failed to apply technology relations on %s: %v", domain, err)
+		}
+	}
 	return nil
 }
 
+// recordService stores the web port httpx answered on against the host's IP.
+func (s *HTTPXService) recordService(ctx context.Context, domainID int, res HTTPXResult) {
+	ip := net.ParseIP(res.Host)
+	port, _ := strconv.Atoi(res.Port)
+	if ip == nil || port <= 0 {
+		return
+	}
+
+	ipID, err := s.Assets.RecordService(ctx, ip.String(), models.Service{Port: port, Protocol: "tcp", Service: res.Scheme, Product: res.WebServer, Source: "httpx"})
+	if err != nil {
+		log.Printf("HTTPX: failed to record %s:%d: %v", ip, port, err)
+		return
+	}
+	s.Assets.LinkHost(ctx, domainID, ipID, repositories.RecordType(ip))
+}
+
 func (s *HTTPXService) simulateScan(ctx context.Context, domain string) error {
 	domainID, err := s.Repo.EnsureDomain(ctx, domain)
 	if err != nil {
@@ -77,5 +123,5 @@ func (s *HTTPXService) simulateScan(ctx context.Context, domain string) error {
 	for _, t := range techs {
 		s.Repo.AddDetection(ctx, domainID, t, "https://"+domain, "", 95, "Simulated HTTPX")
 	}
-	return nil
+	return s.Repo.ApplyRelations(ctx, domainID)
 }
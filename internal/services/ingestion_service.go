@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -13,25 +14,35 @@ import (
 	"strings"
 
 	"github.com/Abhaythakor/SigMap/internal/integrations/ipinfo"
+	"github.com/Abhaythakor/SigMap/internal/models"
 	"github.com/Abhaythakor/SigMap/internal/repositories"
 )
 
 type IngestionService struct {
 	Repo         *repositories.DomainRepository
+	Assets       *repositories.AssetRepository
+	DNS          *DNSService
 	IPInfoClient *ipinfo.Client
 }
 
-func NewIngestionService(repo *repositories.DomainRepository, ipInfoClient *ipinfo.Client) *IngestionService {
-	return &IngestionService{Repo: repo, IPInfoClient: ipInfoClient}
+func NewIngestionService(repo *repositories.DomainRepository, assets *repositories.AssetRepository, dnsSvc *DNSService, ipInfoClient *ipinfo.Client) *IngestionService {
+	return &IngestionService{Repo: repo, Assets: assets, DNS: dnsSvc, IPInfoClient: ipInfoClient}
 }
 
+// ScanResult is one ingested line: a technology detection, or an open port
+// when ip and port are set (naabu JSON output uses host/ip/port/protocol).
 type ScanResult struct {
 	Domain     string `json:"domain"`
+	Host       string `json:"host,omitempty"`
 	URL        string `json:"url"`
 	Technology string `json:"technology"`
 	Confidence string `json:"confidence"`
 	Source     string `json:"source"`
 	Version    string `json:"version,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	Service    string `json:"service,omitempty"`
 }
 
 func (s *IngestionService) IngestFromDirectory(ctx context.Context, dirPath string) error {
@@ -59,7 +70,17 @@ func (s *IngestionService) ingestFile(ctx context.Context, filePath string) erro
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	_, err = s.IngestReader(ctx, file, filePath)
+	return err
+}
+
+// IngestReader ingests JSON lines of ScanResult from r and returns the number
+// of detections and ports stored. source names the input in log messages.
+func (s *IngestionService) IngestReader(ctx context.Context, r io.Reader, source string) (int, error) {
+	stored := 0
+	touched := make(map[int]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 	for scanner.Scan() {
 		var res ScanResult
 		line := scanner.Text()
@@ -68,17 +89,31 @@ func (s *IngestionService) ingestFile(ctx context.Context, filePath string) erro
 		}
 
 		if err := json.Unmarshal([]byte(line), &res); err != nil {
-			log.Printf("Skip invalid JSON line in %s: %v", filePath, err)
+			log.Printf("Skip invalid JSON line in %s: %v", source, err)
+			continue
+		}
+		if res.Domain == "" && net.ParseIP(res.Host) == nil {
+			res.Domain = res.Host
+		}
+		if res.Technology == "" && res.Port > 0 && net.ParseIP(res.IP) != nil {
+			if err := s.ingestPort(ctx, res, source); err != nil {
+				log.Printf("Error adding port %s:%d from %s: %v", res.IP, res.Port, source, err)
+				continue
+			}
+			stored++
+			continue
+		}
+		if res.Domain == "" || res.Technology == "" {
 			continue
 		}
 
 		domainID, err := s.Repo.EnsureDomain(ctx, res.Domain)
 		if err != nil {
-			return err
+			return stored, err
 		}
 
 		// Infrastructure Enrichment on new/updated domain
-		go s.LookupInfrastructure(ctx, domainID, res.Domain)
+		go s.LookupInfrastructure(context.WithoutCancel(ctx), domainID, res.Domain)
 
 		confInt := 50
 		switch strings.ToLower(res.Confidence) {
@@ -93,20 +128,50 @@ func (s *IngestionService) ingestFile(ctx context.Context, filePath string) erro
 		err = s.Repo.AddDetection(ctx, domainID, res.Technology, res.URL, res.Version, confInt, res.Source)
 		if err != nil {
 			log.Printf("Error adding detection %s for %s: %v", res.Technology, res.Domain, err)
+			continue
+		}
+		touched[domainID] = true
+		stored++
+	}
+
+	// Relations are applied once the domain's whole stack is in
+	for domainID := range touched {
+		if err := s.Repo.ApplyRelations(ctx, domainID); err != nil {
+			log.Printf("Error applying technology relations to domain %d from %s: %v", domainID, source, err)
 		}
 	}
+	return stored, scanner.Err()
+}
+
+// ingestPort stores an open port and links the host it was found on.
+func (s *IngestionService) ingestPort(ctx context.Context, res ScanResult, source string) error {
+	service := models.Service{Port: res.Port, Protocol: strings.ToLower(res.Protocol), Service: res.Service, Source: res.Source}
+	if service.Source == "" {
+		service.Source = "ingest"
+	}
+	ipID, err := s.Assets.RecordService(ctx, res.IP, service)
+	if err != nil || res.Domain == "" {
+		return err
+	}
 
-	return scanner.Err()
+	domainID, err := s.Repo.EnsureDomain(ctx, res.Domain)
+	if err != nil {
+		return err
+	}
+	return s.Assets.LinkHost(ctx, domainID, ipID, repositories.RecordType(net.ParseIP(res.IP)))
 }
 
 func (s *IngestionService) LookupInfrastructure(ctx context.Context, domainID int, domainName string) {
-	// 1. Resolve IP
-	ips, err := net.LookupIP(domainName)
-	if err != nil || len(ips) == 0 {
+	// 1. Resolve DNS records
+	res, err := s.DNS.Enrich(ctx, domainID, domainName)
+	if err != nil {
+		log.Printf("Infra: DNS enrichment failed for %s: %v", domainName, err)
+	}
+	if res == nil || len(res.Addresses()) == 0 {
 		log.Printf("Infra: Could not resolve IP for %s", domainName)
 		return
 	}
-	ip := ips[0].String()
+	ip := res.Addresses()[0].String()
 
 	// 2. Fetch IP Details
 	details, err := s.IPInfoClient.GetIPDetails(ctx, ip)
@@ -128,10 +193,20 @@ func (s *IngestionService) LookupInfrastructure(ctx context.Context, domainID in
 	}
 
 	// 4. Update DB
+	if ipID, err := s.Assets.GetIPIDByAddress(ctx, ip); err == nil {
+		if err := s.Assets.UpdateIPDetails(ctx, ipID, asn, asnOrg, details.CloudProvider, details.Country); err != nil {
+			log.Printf("Infra: Failed to update IP %s: %v", ip, err)
+		}
+	}
+	// A provider known from an imported cloud inventory beats the IP guess.
 	_, err = s.Repo.Pool.Exec(ctx, `
 		UPDATE domains SET 
 			ip_address = $1, 
-			cloud_provider = $2, 
+			cloud_provider = CASE WHEN EXISTS (
+				SELECT 1 FROM domain_cloud_resources l
+				JOIN cloud_resources c ON c.id = l.cloud_resource_id
+				WHERE l.domain_id = domains.id AND c.resource_type <> 'dns-record'
+			) THEN cloud_provider ELSE $2 END, 
 			asn = $3, 
 			asn_org = $4 
 		WHERE id = $5
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/pdns"
+	"github.com/Abhaythakor/SigMap/internal/publicsuffix"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+type PassiveDNSService struct {
+	Repo   *repositories.DomainRepository
+	Assets *repositories.AssetRepository
+}
+
+func NewPassiveDNSService(repo *repositories.DomainRepository, assets *repositories.AssetRepository) *PassiveDNSService {
+	return &PassiveDNSService{Repo: repo, Assets: assets}
+}
+
+// PassiveDNSImport summarizes a passive DNS import.
+type PassiveDNSImport struct {
+	Stored      int // resolutions stored
+	Unsupported int // records of types other than A, AAAA and CNAME
+	OutOfScope  int // records for names outside tracked root domains
+	Invalid     int // malformed lines or values
+}
+
+// Import stores the A, AAAA and CNAME records of a passive DNS export in the
+// current workspace. source labels the records (usually the file name).
+// Only names under root domains the workspace already tracks are kept.
+func (s *PassiveDNSService) Import(ctx context.Context, r io.Reader, source string) (PassiveDNSImport, error) {
+	var res PassiveDNSImport
+	reader, err := pdns.NewReader(r)
+	if err != nil {
+		return res, err
+	}
+
+	tracked := map[string]bool{}
+	domainIDs := map[string]int{}
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		var lineErr *pdns.LineError
+		if errors.As(err, &lineErr) {
+			log.Printf("Passive DNS: skipping %s %v", source, lineErr)
+			res.Invalid++
+			continue
+		} else if err != nil {
+			return res, err
+		}
+
+		if rec.Type != "A" && rec.Type != "AAAA" && rec.Type != "CNAME" {
+			res.Unsupported++
+			continue
+		}
+
+		domainID, ok := domainIDs[rec.Name]
+		if !ok {
+			root, err := publicsuffix.EffectiveTLDPlusOne(rec.Name)
+			if err != nil {
+				res.OutOfScope++
+				continue
+			}
+			inScope, cached := tracked[root]
+			if !cached {
+				if inScope, err = s.Repo.HasRootDomain(ctx, root); err != nil {
+					return res, err
+				}
+				tracked[root] = inScope
+			}
+			if !inScope {
+				res.OutOfScope++
+				continue
+			}
+			if domainID, err = s.Repo.EnsureDomainFrom(ctx, rec.Name, models.SourcePassiveDNS); err != nil {
+				return res, fmt.Errorf("%s: %w", rec.Name, err)
+			}
+			domainIDs[rec.Name] = domainID
+		}
+
+		for _, value := range rec.Data {
+			value, ok := passiveValue(rec.Type, value)
+			if !ok {
+				res.Invalid++
+				continue
+			}
+			err := s.Assets.RecordPassiveDNS(ctx, models.PassiveDNSRecord{
+				DomainID:  domainID,
+				Type:      rec.Type,
+				Value:     value,
+				FirstSeen: rec.FirstSeen,
+				LastSeen:  rec.LastSeen,
+				Count:     rec.Count,
+				Source:    source,
+			})
+			if err != nil {
+				return res, fmt.Errorf("%s %s %s: %w", rec.Name, rec.Type, value, err)
+			}
+			res.Stored++
+		}
+	}
+}
+
+// passiveValue validates and normalizes the rdata of a record.
+func passiveValue(rrtype, value string) (string, bool) {
+	if rrtype == "CNAME" {
+		value = strings.ToLower(strings.TrimSuffix(value, "."))
+		return value, value != "" && !strings.ContainsAny(value, " /@")
+	}
+	ip := net.ParseIP(value)
+	if ip == nil || (rrtype == "A") != (ip.To4() != nil) {
+		return "", false
+	}
+	return ip.String(), true
+}
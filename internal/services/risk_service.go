@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/risk"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	riskBatch      = 200
+	riskRefreshAge = 24 * time.Hour
+)
+
+// RiskService keeps domain risk scores current. Database triggers queue a
+// domain whenever one of its inputs changes; Recompute scores the queue.
+type RiskService struct {
+	Repo *repositories.RiskRepository
+}
+
+func NewRiskService(repo *repositories.RiskRepository) *RiskService {
+	return &RiskService{Repo: repo}
+}
+
+// Recompute scores every queued domain.
+func (s *RiskService) Recompute(ctx context.Context) error {
+	total := 0
+	defer func() {
+		if total > 0 {
+			log.Printf("Risk: scored %d domains", total)
+		}
+	}()
+	for ctx.Err() == nil {
+		queued, err := s.Repo.Queued(ctx, riskBatch)
+		if err != nil {
+			return err
+		}
+		for _, q := range queued {
+			if err := s.score(ctx, q); err != nil {
+				return err
+			}
+		}
+		total += len(queued)
+		if len(queued) < riskBatch {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *RiskService) score(ctx context.Context, q repositories.QueuedDomain) error {
+	in, err := s.Repo.Inputs(ctx, q.ID)
+	if err == pgx.ErrNoRows {
+		return s.Repo.Drop(ctx, q.ID, q.QueuedAt)
+	}
+	if err != nil {
+		return err
+	}
+	return s.Repo.Save(ctx, q.ID, risk.Compute(in), q.QueuedAt)
+}
+
+// RefreshStale queues domains not scored for a day; the next Recompute
+// scores them.
+func (s *RiskService) RefreshStale(ctx context.Context) error {
+	n, err := s.Repo.EnqueueStale(ctx, time.Now().Add(-riskRefreshAge))
+	if n > 0 {
+		log.Printf("Risk: queued %d domains not scored for a day", n)
+	}
+	return err
+}
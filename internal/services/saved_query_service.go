@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+// Limits of a saved view evaluation: views matching more domains are
+// skipped, and a change lists at most this many names each way.
+const (
+	maxViewMembers = 50000
+	maxChangeNames = 100
+)
+
+// SavedQueryService evaluates subscribed saved views and notifies their
+// channels when domains enter or leave the result set.
+type SavedQueryService struct {
+	Repo    *repositories.SavedQueryRepository
+	Domains *repositories.DomainRepository
+	Alerts  *AlertService
+}
+
+func NewSavedQueryService(repo *repositories.SavedQueryRepository, domains *repositories.DomainRepository, alerts *AlertService) *SavedQueryService {
+	return &SavedQueryService{Repo: repo, Domains: domains, Alerts: alerts}
+}
+
+// EvaluateAll evaluates the subscribed views of every workspace.
+func (s *SavedQueryService) EvaluateAll(ctx context.Context) error {
+	ids, err := s.Repo.WorkspacesWithSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		wsCtx := workspace.WithID(ctx, id)
+		queries, err := s.Repo.ListSubscribed(wsCtx)
+		if err != nil {
+			log.Printf("Saved views: failed to list workspace %d: %v", id, err)
+			continue
+		}
+		for _, q := range queries {
+			if _, err := s.Evaluate(wsCtx, q); err != nil {
+				log.Printf("Saved views: failed to evaluate %q in workspace %d: %v", q.Name, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate runs a saved view, stores its new membership and notifies the
+// subscribed channels of the difference. The first evaluation only records
+// a baseline and returns nil.
+func (s *SavedQueryService) Evaluate(ctx context.Context, q models.SavedQuery) (*models.SavedQueryChange, error) {
+	filters, err := repositories.ParseDomainFilters(q.Values())
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	current, err := s.Domains.MatchingDomains(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) > maxViewMembers {
+		return nil, fmt.Errorf("matches %d domains, more than the %d a subscribed view may", len(current), maxViewMembers)
+	}
+	previous, err := s.Repo.Members(ctx, q.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	entered, left := make(map[int]string), make(map[int]string)
+	for id, name := range current {
+		if _, ok := previous[id]; !ok {
+			entered[id] = name
+		}
+	}
+	for id, name := range previous {
+		if _, ok := current[id]; !ok {
+			left[id] = name
+		}
+	}
+
+	now := time.Now()
+	var change *models.SavedQueryChange
+	if q.EvaluatedAt != nil && (len(entered) > 0 || len(left) > 0) {
+		change = &models.SavedQueryChange{
+			SavedQueryID: q.ID,
+			Entered:      changeNames(entered),
+			Left:         changeNames(left),
+			EnteredCount: len(entered),
+			LeftCount:    len(left),
+			ChangedAt:    now,
+		}
+	}
+	if err := s.Repo.RecordEvaluation(ctx, q.ID, entered, left, change, now); err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if err := s.Alerts.DispatchQueryChange(ctx, q, *change); err != nil {
+			return change, fmt.Errorf("notifying subscribers: %w", err)
+		}
+	}
+	return change, nil
+}
+
+// changeNames returns the first maxChangeNames names in order.
+func changeNames(domains map[int]string) []string {
+	names := make([]string, 0, len(domains))
+	for _, name := range domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > maxChangeNames {
+		names = names[:maxChangeNames]
+	}
+	return names
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+// ScanService runs the scan pipeline stage by stage for recurring scans.
+type ScanService struct {
+	Repo      *repositories.DomainRepository
+	Ingest    *IngestionService
+	Discovery *DiscoveryService
+	TLS       *TLSService
+	HTTPX     *HTTPXService
+	Nuclei    *NucleiService
+}
+
+func NewScanService(repo *repositories.DomainRepository, ingest *IngestionService, discoverySvc *DiscoveryService, tlsSvc *TLSService, httpxSvc *HTTPXService, nucleiSvc *NucleiService) *ScanService {
+	return &ScanService{Repo: repo, Ingest: ingest, Discovery: discoverySvc, TLS: tlsSvc, HTTPX: httpxSvc, Nuclei: nucleiSvc}
+}
+
+// ScanTarget scans a watchlist target. Root targets are expanded to every
+// known subdomain after discovery; domain targets only scan the host itself.
+func (s *ScanService) ScanTarget(ctx context.Context, target, kind string, stages []string) error {
+	var errs []error
+	if hasStage(stages, models.StageDiscovery) {
+		if subs, err := s.Discovery.DiscoverSubdomains(ctx, target); err != nil {
+			errs = append(errs, fmt.Errorf("discovery: %w", err))
+		} else {
+			log.Printf("Scan: Discovery found %d subdomains for %s", len(subs), target)
+		}
+	}
+
+	hosts := []string{target}
+	if kind == models.TargetRoot {
+		names, err := s.Repo.ListDomainsUnder(ctx, target)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if len(names) > 0 {
+			hosts = names
+		}
+	}
+
+	for _, host := range hosts {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := s.ScanHost(ctx, host, stages); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", host, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ScanHost runs the infra, tls, httpx and nuclei stages against a single host.
+func (s *ScanService) ScanHost(ctx context.Context, host string, stages []string) error {
+	domainID, err := s.Repo.EnsureDomain(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if hasStage(stages, models.StageInfra) {
+		s.Ingest.LookupInfrastructure(ctx, domainID, host)
+	}
+	if hasStage(stages, models.StageTLS) {
+		if err := s.TLS.ScanHost(ctx, domainID, host); err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+		}
+	}
+	if hasStage(stages, models.StageHTTPX) {
+		if err := s.HTTPX.ScanDomain(ctx, host); err != nil {
+			errs = append(errs, fmt.Errorf("httpx: %w", err))
+		}
+	}
+	if hasStage(stages, models.StageNuclei) {
+		if err := s.Nuclei.ScanAndStore(ctx, domainID, host); err != nil {
+			errs = append(errs, fmt.Errorf("nuclei: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func hasStage(stages []string, stage string) bool {
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
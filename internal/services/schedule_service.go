@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/schedule"
+)
+
+// maxScheduleConcurrency bounds the per-schedule concurrency cap.
+const maxScheduleConcurrency = 16
+
+type ScheduleService struct {
+	Repo *repositories.ScheduleRepository
+}
+
+func NewScheduleService(repo *repositories.ScheduleRepository) *ScheduleService {
+	return &ScheduleService{Repo: repo}
+}
+
+// NextRun returns the next activation of a schedule after t, in the
+// schedule's timezone, pushed back by a random jitter.
+func (s *ScheduleService) NextRun(sch models.ScanSchedule, after time.Time) (time.Time, error) {
+	cron, err := schedule.Parse(sch.CronExpr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown timezone %q", sch.Timezone)
+	}
+
+	next := cron.Next(after.In(loc))
+	if next.IsZero() {
+		return next, fmt.Errorf("schedule %q never fires", sch.CronExpr)
+	}
+	if sch.JitterSeconds > 0 {
+		next = next.Add(time.Duration(rand.IntN(sch.JitterSeconds)) * time.Second)
+	}
+	return next, nil
+}
+
+// Create validates and stores a schedule with its initial targets.
+func (s *ScheduleService) Create(ctx context.Context, sch models.ScanSchedule, targets string) (int, error) {
+	sch.Name = strings.TrimSpace(sch.Name)
+	sch.CronExpr = strings.TrimSpace(sch.CronExpr)
+	if sch.Name == "" {
+		return 0, fmt.Errorf("schedule name is required")
+	}
+	if sch.Timezone == "" {
+		sch.Timezone = "UTC"
+	}
+	for _, st := range sch.Stages {
+		if !hasStage(models.AllStages, st) {
+			return 0, fmt.Errorf("unknown stage %q", st)
+		}
+	}
+	if len(sch.Stages) == 0 {
+		return 0, fmt.Errorf("select at least one stage")
+	}
+	if sch.JitterSeconds < 0 {
+		sch.JitterSeconds = 0
+	}
+	if sch.MaxConcurrency < 1 || sch.MaxConcurrency > maxScheduleConcurrency {
+		return 0, fmt.Errorf("concurrency must be between 1 and %d", maxScheduleConcurrency)
+	}
+	if _, err := s.NextRun(sch, time.Now()); err != nil {
+		return 0, err
+	}
+
+	id, err := s.Repo.Create(ctx, sch)
+	if err != nil {
+		return 0, err
+	}
+	sch.ID = id
+	audit.Describe(ctx, "schedule.create", "schedule", id, sch.Name, nil, map[string]interface{}{
+		"cron": sch.CronExpr, "timezone": sch.Timezone, "stages": sch.Stages,
+		"jitter_seconds": sch.JitterSeconds, "max_concurrency": sch.MaxConcurrency,
+	})
+
+	if _, err := s.addTargets(ctx, sch, targets); err != nil {
+		return id, err
+	}
+	return id, nil
+}
+
+// AddTargets adds newline or comma separated targets to a schedule.
+// "*.example.com" watches example.com and all of its subdomains.
+func (s *ScheduleService) AddTargets(ctx context.Context, scheduleID int, targets string) (int, error) {
+	sch, err := s.Repo.Get(ctx, scheduleID)
+	if err != nil {
+		return 0, fmt.Errorf("schedule %d not found", scheduleID)
+	}
+	n, err := s.addTargets(ctx, sch, targets)
+	if err == nil {
+		audit.Describe(ctx, "schedule.targets_add", "schedule", sch.ID, sch.Name, nil, map[string]interface{}{"targets": ParseTargets(targets)})
+	}
+	return n, err
+}
+
+func (s *ScheduleService) addTargets(ctx context.Context, sch models.ScanSchedule, raw string) (int, error) {
+	added := 0
+	for target, kind := range ParseTargets(raw) {
+		next, err := s.NextRun(sch, time.Now())
+		if err != nil {
+			return added, err
+		}
+		if err := s.Repo.AddTarget(ctx, sch.ID, target, kind, next); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// ParseTargets splits user input into targets and their kinds.
+func ParseTargets(raw string) map[string]string {
+	targets := map[string]string{}
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ',' || r == ' ' || r == '\r' || r == '\t' }) {
+		item = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(item), "."))
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "*.") {
+			targets[strings.TrimPrefix(item, "*.")] = models.TargetRoot
+		} else if _, ok := targets[item]; !ok {
+			targets[item] = models.TargetDomain
+		}
+	}
+	return targets
+}
+
+// SetActive pauses or resumes a schedule. Resuming recomputes next runs so
+// runs missed while paused are not fired all at once.
+func (s *ScheduleService) SetActive(ctx context.Context, id int, active bool) error {
+	sch, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("schedule %d not found", id)
+	}
+	if err := s.Repo.SetActive(ctx, id, active); err != nil {
+		return err
+	}
+	if active && !sch.IsActive {
+		for _, t := range sch.Targets {
+			next, err := s.NextRun(sch, time.Now())
+			if err != nil {
+				return err
+			}
+			if err := s.Repo.SetNextRun(ctx, id, t.ID, next); err != nil {
+				return err
+			}
+		}
+	}
+	audit.Describe(ctx, "schedule.update", "schedule", id, sch.Name, map[string]bool{"is_active": sch.IsActive}, map[string]bool{"is_active": active})
+	return nil
+}
+
+// RunNow makes a target due on the scheduler's next tick.
+func (s *ScheduleService) RunNow(ctx context.Context, scheduleID, targetID int) error {
+	if err := s.Repo.SetNextRun(ctx, scheduleID, targetID, time.Now()); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "schedule.run_now", "schedule_target", targetID, "", nil, nil)
+	return nil
+}
+
+// RemoveTarget stops watching a target.
+func (s *ScheduleService) RemoveTarget(ctx context.Context, scheduleID, targetID int) error {
+	if err := s.Repo.RemoveTarget(ctx, scheduleID, targetID); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "schedule.target_remove", "schedule_target", targetID, "", nil, nil)
+	return nil
+}
+
+// Delete removes a schedule.
+func (s *ScheduleService) Delete(ctx context.Context, id int) error {
+	sch, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("schedule %d not found", id)
+	}
+	if err := s.Repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "schedule.delete", "schedule", id, sch.Name, map[string]interface{}{"cron": sch.CronExpr, "targets": len(sch.Targets)}, nil)
+	return nil
+}
@@ -2,161 +2,495 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/Abhaythakor/SigMap/internal/fingerprint"
 	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DefaultSignatureSource is the wappalyzergo repository. SIGNATURE_SOURCE
+// or -sync-from may name a mirror URL or a local directory instead; either
+// must hold the two files below.
+const DefaultSignatureSource = "https://raw.githubusercontent.com/projectdiscovery/wappalyzergo/refs/heads/main"
+
 const (
-	fingerprintsURL = "https://raw.githubusercontent.com/projectdiscovery/wappalyzergo/refs/heads/main/fingerprints_data.json"
-	categoriesURL   = "https://raw.githubusercontent.com/projectdiscovery/wappalyzergo/refs/heads/main/categories_data.json"
+	fingerprintsFile = "fingerprints_data.json"
+	categoriesFile   = "categories_data.json"
 )
 
+// maxSignatureDoc caps a downloaded signature document.
+const maxSignatureDoc = 64 << 20
+
+type signatureCategory struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// signatureDoc is one fetched document; data is nil when the source
+// answered 304 Not Modified.
+type signatureDoc struct {
+	data []byte
+	etag string
+}
+
 type SyncService struct {
-	Pool *pgxpool.Pool
+	Pool     *pgxpool.Pool
+	Versions *repositories.SignatureRepository
+	Source   string
+	Client   *http.Client
 }
 
-func NewSyncService(pool *pgxpool.Pool) *SyncService {
-	return &SyncService{Pool: pool}
+func NewSyncService(pool *pgxpool.Pool, versions *repositories.SignatureRepository, source string) *SyncService {
+	if source == "" {
+		source = DefaultSignatureSource
+	}
+	return &SyncService{Pool: pool, Versions: versions, Source: source, Client: &http.Client{Timeout: 2 * time.Minute}}
 }
 
-// Sync downloads and updates the technology metadata.
+// SyncResult reports what a sync found and did.
+type SyncResult struct {
+	Version *models.SignatureVersion `json:"version"`
+	// NotModified is set when the source answered 304 for both documents.
+	NotModified bool `json:"not_modified"`
+	// New is set when the documents had not been seen before.
+	New     bool `json:"new"`
+	Applied bool `json:"applied"`
+	// PinnedTo is the pinned version that kept this one from being applied.
+	PinnedTo *models.SignatureVersion `json:"pinned_to,omitempty"`
+}
+
+// Sync downloads and updates the technology metadata from the configured source.
 func (s *SyncService) Sync(ctx context.Context) error {
-	log.Println("Starting Wappalyzer metadata sync...")
+	_, err := s.SyncFrom(ctx, s.Source)
+	return err
+}
 
-	// 1. Sync Categories
-	categories, err := s.fetchCategories()
+// SyncFrom fetches signatures from a URL or directory, records them as a
+// version and applies that version unless another one is pinned.
+func (s *SyncService) SyncFrom(ctx context.Context, source string) (*SyncResult, error) {
+	log.Printf("Starting Wappalyzer metadata sync from %s...", source)
+
+	latest, err := s.Versions.Latest(ctx, source)
 	if err != nil {
-		return fmt.Errorf("failed to fetch categories: %w", err)
+		return nil, err
 	}
-	if err := s.saveCategories(ctx, categories); err != nil {
-		return fmt.Errorf("failed to save categories: %w", err)
+	var fpETag, catETag string
+	if latest != nil {
+		fpETag, catETag = latest.FingerprintsETag, latest.CategoriesETag
 	}
 
-	// 2. Sync Fingerprints
-	fingerprints, err := s.fetchFingerprints()
+	fps, err := s.fetch(ctx, source, fingerprintsFile, fpETag)
 	if err != nil {
-		return fmt.Errorf("failed to fetch fingerprints: %w", err)
+		return nil, fmt.Errorf("failed to fetch fingerprints: %w", err)
 	}
-	if err := s.saveFingerprints(ctx, fingerprints); err != nil {
-		return fmt.Errorf("failed to save fingerprints: %w", err)
+	cats, err := s.fetch(ctx, source, categoriesFile, catETag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
 	}
 
-	log.Println("Wappalyzer metadata sync completed successfully")
-	return nil
-}
+	res := &SyncResult{}
+	var version *models.SignatureVersion
+	if fps.data == nil && cats.data == nil {
+		// Conditional requests are only sent once a version from this source exists.
+		if err := s.Versions.Touch(ctx, latest.ID, source, fpETag, catETag); err != nil {
+			return nil, err
+		}
+		res.NotModified = true
+		version = latest
+		log.Printf("Signatures not modified since version %d", latest.ID)
+	} else {
+		if fps.data == nil || cats.data == nil {
+			oldFps, oldCats, err := s.Versions.Data(ctx, latest.ID)
+			if err != nil {
+				return nil, err
+			}
+			if fps.data == nil {
+				fps.data = oldFps
+			}
+			if cats.data == nil {
+				cats.data = oldCats
+			}
+		}
+		version, res.New, err = s.record(ctx, source, fps, cats)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Version = version
 
-func (s *SyncService) fetchCategories() (map[string]struct {
-	Name     string `json:"name"`
-	Priority int    `json:"priority"`
-}, error) {
-	resp, err := http.Get(categoriesURL)
+	pinned, err := s.Versions.Pinned(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var data map[string]struct {
-		Name     string `json:"name"`
-		Priority int    `json:"priority"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
+	switch {
+	case pinned != nil && pinned.ID != version.ID:
+		res.PinnedTo = pinned
+		log.Printf("Signatures pinned to version %d; version %d recorded but not applied", pinned.ID, version.ID)
+	case version.Active:
+		log.Printf("Signature version %d is already active", version.ID)
+	default:
+		if err := s.Apply(ctx, version.ID); err != nil {
+			return nil, fmt.Errorf("failed to apply version %d: %w", version.ID, err)
+		}
+		res.Applied = true
+		log.Printf("Wappalyzer metadata sync completed successfully: version %d (%d technologies, +%d -%d ~%d)",
+			version.ID, version.TechnologyCount, version.Added, version.Removed, version.Changed)
 	}
-	return data, nil
+	return res, nil
 }
 
-func (s *SyncService) fetchFingerprints() (map[string]models.Technology, error) {
-	resp, err := http.Get(fingerprintsURL)
+// fetch reads a document from a local directory, or GETs it from a URL
+// conditionally on the ETag seen last time.
+func (s *SyncService) fetch(ctx context.Context, source, name, etag string) (signatureDoc, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(filepath.Join(strings.TrimPrefix(source, "file://"), name))
+		return signatureDoc{data: data}, err
+	}
+
+	url := strings.TrimSuffix(source, "/") + "/" + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return signatureDoc{}, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return signatureDoc{}, err
 	}
 	defer resp.Body.Close()
 
-	// Wappalyzer JSON is structured as { "apps": { "name": { ... } } }
-	var wrapper struct {
-		Apps map[string]models.Technology `json:"apps"`
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return signatureDoc{etag: etag}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return signatureDoc{}, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureDoc))
+	if err != nil {
+		return signatureDoc{}, err
 	}
+	return signatureDoc{data: data, etag: resp.Header.Get("ETag")}, nil
+}
+
+// record returns the version for a pair of documents, creating it when the
+// pair has not been seen before.
+func (s *SyncService) record(ctx context.Context, source string, fps, cats signatureDoc) (*models.SignatureVersion, bool, error) {
+	h := sha256.New()
+	h.Write(fps.data)
+	h.Write([]byte{0})
+	h.Write(cats.data)
+	sum := hex.EncodeToString(h.Sum(nil))
 
-	body, err := io.ReadAll(resp.Body)
+	existing, err := s.Versions.FindByHash(ctx, sum)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if existing != nil {
+		if err := s.Versions.Touch(ctx, existing.ID, source, fps.etag, cats.etag); err != nil {
+			return nil, false, err
+		}
+		existing.Source = source
+		log.Printf("Fetched signatures match version %d", existing.ID)
+		return existing, false, nil
 	}
 
-	if err := json.Unmarshal(body, &wrapper); err != nil {
-		return nil, err
+	apps, err := fingerprint.Decode(fps.data)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", fingerprintsFile, err)
+	}
+	categories, err := decodeCategories(cats.data)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", categoriesFile, err)
+	}
+	if len(apps) == 0 {
+		return nil, false, fmt.Errorf("%s lists no technologies", fingerprintsFile)
 	}
 
-	return wrapper.Apps, nil
+	v := &models.SignatureVersion{
+		Source:           source,
+		SHA256:           sum,
+		FingerprintsETag: fps.etag,
+		CategoriesETag:   cats.etag,
+		TechnologyCount:  len(apps),
+		CategoryCount:    len(categories),
+	}
+	if active, err := s.Versions.Active(ctx); err != nil {
+		return nil, false, err
+	} else if active != nil {
+		old, err := s.fingerprints(ctx, active.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		added, removed, changed := fingerprint.Diff(old, apps)
+		v.Added, v.Removed, v.Changed = len(added), len(removed), len(changed)
+	} else {
+		v.Added = len(apps)
+	}
+	if err := s.Versions.Create(ctx, v, fps.data, cats.data); err != nil {
+		return nil, false, err
+	}
+	log.Printf("Recorded signature version %d (%s)", v.ID, v.ShortHash())
+	return v, true, nil
 }
 
-func (s *SyncService) saveCategories(ctx context.Context, categories map[string]struct {
-	Name     string `json:"name"`
-	Priority int    `json:"priority"`
-}) error {
+// Apply writes a recorded version into technologies, categories and the
+// matchers of the local engine, and marks it active. Technologies the
+// version lacks keep their rows (detections refer to them) but lose their
+// matchers and relations. Custom fingerprints are left alone.
+func (s *SyncService) Apply(ctx context.Context, id int) error {
+	fpsData, catsData, err := s.Versions.Data(ctx, id)
+	if err != nil {
+		return err
+	}
+	apps, err := fingerprint.Decode(fpsData)
+	if err != nil {
+		return err
+	}
+	categories, err := decodeCategories(catsData)
+	if err != nil {
+		return err
+	}
+
 	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
+	if err := s.saveCategories(ctx, tx, categories); err != nil {
+		return fmt.Errorf("failed to save categories: %w", err)
+	}
+	if err := s.saveFingerprints(ctx, tx, apps); err != nil {
+		return fmt.Errorf("failed to save fingerprints: %w", err)
+	}
+	if err := s.Versions.SetActive(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Pin applies a version and keeps later syncs from replacing it.
+func (s *SyncService) Pin(ctx context.Context, id int) error {
+	v, err := s.Versions.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !v.Active {
+		if err := s.Apply(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.Versions.SetPinned(ctx, id, true)
+}
+
+// Unpin lets the next sync apply the newest version again.
+func (s *SyncService) Unpin(ctx context.Context, id int) error {
+	return s.Versions.SetPinned(ctx, id, false)
+}
+
+// Diff reports the technologies added, removed or changed between two
+// versions.
+func (s *SyncService) Diff(ctx context.Context, fromID, toID int) (*models.SignatureDiff, error) {
+	from, err := s.Versions.Get(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.Versions.Get(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	old, err := s.fingerprints(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := s.fingerprints(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	added, removed, changed := fingerprint.Diff(old, cur)
+	diff := &models.SignatureDiff{From: *from, To: *to, Added: added, Removed: removed}
+	for _, c := range changed {
+		diff.Changed = append(diff.Changed, models.SignatureChange{Name: c.Name, Fields: c.Fields})
+	}
+	return diff, nil
+}
+
+func (s *SyncService) fingerprints(ctx context.Context, id int) (map[string]fingerprint.Fingerprint, error) {
+	data, _, err := s.Versions.Data(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint.Decode(data)
+}
+
+func decodeCategories(data []byte) (map[string]signatureCategory, error) {
+	var categories map[string]signatureCategory
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		return nil, errors.New("no categories")
+	}
+	return categories, nil
+}
+
+// saveCategories upserts categories by ID. Upstream renames categories now
+// and then, sometimes onto a name another ID held; names are unique, so
+// those holders are first moved aside to "Name (#id)". One that the version
+// still has gets its new name below; one it dropped keeps the marked name.
+func (s *SyncService) saveCategories(ctx context.Context, tx pgx.Tx, categories map[string]signatureCategory) error {
+	ids := make([]int, 0, len(categories))
+	names := make([]string, 0, len(categories))
+	priorities := make([]int, 0, len(categories))
 	for idStr, cat := range categories {
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
 			log.Printf("Warning: category ID %s is not an integer", idStr)
 			continue
 		}
+		ids = append(ids, id)
+		names = append(names, cat.Name)
+		priorities = append(priorities, cat.Priority)
+	}
+
+	previous := make(map[int]string)
+	rows, err := tx.Query(ctx, `SELECT id, name FROM categories`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		previous[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE categories c SET name = c.name || ' (#' || c.id || ')'
+		FROM unnest($1::int[], $2::text[]) AS n(id, name)
+		WHERE c.name = n.name AND c.id <> n.id
+	`, ids, names)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if old, ok := previous[id]; ok && old != names[i] {
+			log.Printf("Category %d renamed from %q to %q", id, old, names[i])
+		}
 		_, err = tx.Exec(ctx, `
 			INSERT INTO categories (id, name, priority)
 			VALUES ($1, $2, $3)
 			ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, priority = EXCLUDED.priority
-		`, id, cat.Name, cat.Priority)
+		`, id, names[i], priorities[i])
 		if err != nil {
 			return err
 		}
 	}
-
-	return tx.Commit(ctx)
+	return nil
 }
 
-func (s *SyncService) saveFingerprints(ctx context.Context, apps map[string]models.Technology) error {
-	tx, err := s.Pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
+func (s *SyncService) saveFingerprints(ctx context.Context, tx pgx.Tx, apps map[string]fingerprint.Fingerprint) error {
+	names := make([]string, 0, len(apps))
+	ids := make(map[string]int, len(apps))
 	for name, app := range apps {
+		names = append(names, name)
+
+		// A custom fingerprint of the same name wins: the upsert skips it
 		var techID int
 		err := tx.QueryRow(ctx, `
 			INSERT INTO technologies (name, website, icon, description)
 			VALUES ($1, $2, $3, $4)
 			ON CONFLICT (name) DO UPDATE SET website = EXCLUDED.website, icon = EXCLUDED.icon, description = EXCLUDED.description
+			WHERE technologies.source <> 'custom'
 			RETURNING id
 		`, name, app.Website, app.Icon, app.Description).Scan(&techID)
+		if err == pgx.ErrNoRows {
+			log.Printf("Keeping custom fingerprint %s over the synced one", name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ids[name] = techID
+
+		// Keep the matchers for the local detection engine
+		matchers, err := json.Marshal(app.Matchers())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO technology_fingerprints (technology_id, matchers)
+			VALUES ($1, $2)
+			ON CONFLICT (technology_id) DO UPDATE SET matchers = EXCLUDED.matchers, updated_at = NOW()
+		`, techID, matchers)
 		if err != nil {
 			return err
 		}
 
-		// Handle category mapping
+		// Handle category mapping; a version may move a technology between categories
+		if _, err := tx.Exec(ctx, `DELETE FROM technology_categories WHERE technology_id = $1`, techID); err != nil {
+			return err
+		}
 		for _, catID := range app.Cats {
-			_, err := tx.Exec(ctx, `
+			tag, err := tx.Exec(ctx, `
 				INSERT INTO technology_categories (technology_id, category_id)
-				VALUES ($1, $2)
+				SELECT $1, id FROM categories WHERE id = $2
 				ON CONFLICT DO NOTHING
 			`, techID, catID)
 			if err != nil {
-				// If category ID doesn't exist (it should, as we synced them first), log and continue
+				return err
+			}
+			if tag.RowsAffected() == 0 {
 				log.Printf("Warning: category ID %d for tech %s does not exist", catID, name)
 			}
 		}
 	}
 
-	return tx.Commit(ctx)
+	// Relations name other technologies, so they go in once all exist
+	for name, techID := range ids {
+		if err := repositories.ReplaceRelations(ctx, tx, techID, apps[name]); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		DELETE FROM technology_relations r
+		USING technologies t
+		WHERE t.id = r.technology_id AND t.source <> 'custom' AND NOT (t.name = ANY($1))
+	`, names)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		DELETE FROM technology_fingerprints f
+		USING technologies t
+		WHERE t.id = f.technology_id AND t.source <> 'custom' AND NOT (t.name = ANY($1))
+	`, names)
+	return err
 }
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/publicsuffix"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/tlsgrab"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+const (
+	tlsSweepBatch = 500
+	tlsWorkers    = 16
+)
+
+type TLSService struct {
+	Repo    *repositories.DomainRepository
+	Certs   *repositories.CertificateRepository
+	Grabber *tlsgrab.Grabber
+	Ports   []int
+}
+
+func NewTLSService(repo *repositories.DomainRepository, certs *repositories.CertificateRepository, grabber *tlsgrab.Grabber, ports []int) *TLSService {
+	return &TLSService{Repo: repo, Certs: certs, Grabber: grabber, Ports: ports}
+}
+
+// ScanHost grabs the certificate chain on every configured port of a host,
+// stores it, and adds the leaf's SAN names as new hosts. Closed ports are
+// skipped silently.
+func (s *TLSService) ScanHost(ctx context.Context, domainID int, host string) error {
+	var errs []error
+	for _, port := range s.Ports {
+		res, err := s.Grabber.Grab(ctx, host, port)
+		if err != nil {
+			continue
+		}
+
+		ep := models.TLSEndpoint{
+			DomainID:    domainID,
+			Port:        port,
+			Address:     res.Address,
+			Version:     res.Version,
+			CipherSuite: res.Cipher,
+			VerifyError: res.VerifyError,
+			Error:       res.HandshakeError,
+		}
+		for _, c := range res.Chain {
+			ep.Chain = append(ep.Chain, certificateModel(c))
+		}
+		if err := s.Certs.SaveEndpoint(ctx, ep); err != nil {
+			errs = append(errs, fmt.Errorf("port %d: %w", port, err))
+			continue
+		}
+		if leaf := ep.Leaf(); leaf != nil {
+			logFindings(host, port, *leaf)
+			s.discoverSANs(ctx, host, leaf.SANs)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ScanAll grabs certificates from every host in every workspace.
+func (s *TLSService) ScanAll(ctx context.Context) (int, error) {
+	scanned, afterID := 0, 0
+	for {
+		refs, err := s.Repo.ListDomainRefs(ctx, afterID, tlsSweepBatch)
+		if err != nil || len(refs) == 0 {
+			return scanned, err
+		}
+		afterID = refs[len(refs)-1].ID
+
+		jobs := make(chan repositories.DomainRef)
+		var wg sync.WaitGroup
+		for i := 0; i < tlsWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ref := range jobs {
+					if err := s.ScanHost(workspace.WithID(ctx, ref.WorkspaceID), ref.ID, ref.Name); err != nil {
+						log.Printf("TLS: failed to store %s: %v", ref.Name, err)
+					}
+				}
+			}()
+		}
+		for _, ref := range refs {
+			if ctx.Err() != nil {
+				break
+			}
+			jobs <- ref
+		}
+		close(jobs)
+		wg.Wait()
+		if ctx.Err() != nil {
+			return scanned, ctx.Err()
+		}
+		scanned += len(refs)
+	}
+}
+
+// discoverSANs adds SAN names as hosts when they belong to the scanned
+// host's root domain or to another root domain already tracked, so shared
+// CDN certificates don't pull in unrelated customers. Wildcards and IP SANs
+// are skipped.
+func (s *TLSService) discoverSANs(ctx context.Context, host string, sans []string) {
+	hostRoot, _ := publicsuffix.EffectiveTLDPlusOne(host)
+	for _, san := range sans {
+		name := strings.ToLower(strings.TrimSuffix(san, "."))
+		if name == host || strings.Contains(name, "*") || net.ParseIP(name) != nil {
+			continue
+		}
+		root, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			continue
+		}
+		if root != hostRoot {
+			tracked, err := s.Repo.HasRootDomain(ctx, root)
+			if err != nil || !tracked {
+				continue
+			}
+		}
+		if _, err := s.Repo.EnsureDomainFrom(ctx, name, models.SourceTLSSAN); err != nil {
+			log.Printf("TLS: Failed to save SAN %s: %v", name, err)
+		}
+	}
+}
+
+func logFindings(host string, port int, leaf models.Certificate) {
+	switch {
+	case leaf.Expired():
+		log.Printf("TLS: %s:%d serves an expired certificate (%s)", host, port, leaf.NotAfter.Format("2006-01-02"))
+	case leaf.ExpiringSoon():
+		log.Printf("TLS: %s:%d certificate expires in %d days", host, port, leaf.DaysLeft())
+	}
+	if leaf.SelfSigned {
+		log.Printf("TLS: %s:%d serves a self-signed certificate", host, port)
+	}
+	if leaf.Weak() {
+		log.Printf("TLS: %s:%d weak certificate: %s", host, port, strings.Join(leaf.WeakReasons, ", "))
+	}
+}
+
+func certificateModel(c *x509.Certificate) models.Certificate {
+	keyType, bits := tlsgrab.KeyInfo(c)
+	subjectCN, issuerCN := tlsgrab.CommonNames(c)
+	sans := append([]string{}, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return models.Certificate{
+		Fingerprint:        tlsgrab.Fingerprint(c),
+		Subject:            c.Subject.String(),
+		SubjectCN:          subjectCN,
+		Issuer:             c.Issuer.String(),
+		IssuerCN:           issuerCN,
+		Serial:             c.SerialNumber.Text(16),
+		SANs:               sans,
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		KeyType:            keyType,
+		KeyBits:            bits,
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		IsCA:               c.IsCA,
+		SelfSigned:         tlsgrab.SelfSigned(c),
+		WeakReasons:        tlsgrab.WeakReasons(c),
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/integrations/oidc"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+)
+
+// tokenPrefix marks SigMap API tokens so they are easy to spot in secret scanners.
+const tokenPrefix = "sm_"
+
+type TokenService struct {
+	Repo  *repositories.TokenRepository
+	Users *repositories.UserRepository
+}
+
+func NewTokenService(repo *repositories.TokenRepository, users *repositories.UserRepository) *TokenService {
+	return &TokenService{Repo: repo, Users: users}
+}
+
+// Create issues a new token and returns the raw secret, which is shown only once.
+// Personal tokens belong to owner and cannot carry scopes above the owner's role.
+func (s *TokenService) Create(ctx context.Context, name, kind string, scopes []string, ttl time.Duration, owner *models.User) (string, models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", models.APIToken{}, fmt.Errorf("token name is required")
+	}
+	if kind != models.TokenPersonal && kind != models.TokenService {
+		return "", models.APIToken{}, fmt.Errorf("invalid token kind %q", kind)
+	}
+	if kind == models.TokenService && owner != nil && !owner.HasRole(models.RoleAdmin) {
+		return "", models.APIToken{}, fmt.Errorf("only admins can create service tokens")
+	}
+
+	valid := make([]string, 0, len(scopes))
+	for _, sc := range scopes {
+		if !isKnownScope(sc) {
+			return "", models.APIToken{}, fmt.Errorf("unknown scope %q", sc)
+		}
+		if owner != nil && !owner.HasRole(models.ScopeRole(sc)) {
+			return "", models.APIToken{}, fmt.Errorf("scope %q requires the %s role", sc, models.ScopeRole(sc))
+		}
+		valid = append(valid, sc)
+	}
+	if len(valid) == 0 {
+		valid = []string{models.ScopeRead}
+	}
+
+	secret, err := oidc.RandomString(32)
+	if err != nil {
+		return "", models.APIToken{}, err
+	}
+	raw := tokenPrefix + secret
+
+	t := models.APIToken{
+		Name:      name,
+		Kind:      kind,
+		Prefix:    raw[:len(tokenPrefix)+6],
+		Scopes:    valid,
+		CreatedBy: "local",
+	}
+	if owner != nil {
+		t.CreatedBy = owner.DisplayName()
+		if kind == models.TokenPersonal {
+			t.UserID = &owner.ID
+		}
+	}
+	if ttl > 0 {
+		exp := time.Now().Add(ttl)
+		t.ExpiresAt = &exp
+	}
+
+	t.ID, err = s.Repo.Create(ctx, t, HashToken(raw))
+	if err != nil {
+		return "", models.APIToken{}, err
+	}
+	audit.Describe(ctx, "token.create", "api_token", t.ID, name, nil,
+		map[string]interface{}{"kind": kind, "prefix": t.Prefix, "scopes": valid, "expires_at": t.ExpiresAt})
+	log.Printf("Tokens: %s created %s token %q (%s)", t.CreatedBy, kind, name, strings.Join(valid, ","))
+	return raw, t, nil
+}
+
+// Resolve validates a raw bearer token and records its use. For personal
+// tokens it also returns the owner, and drops scopes the owner no longer holds.
+func (s *TokenService) Resolve(ctx context.Context, raw, ip string) (*models.APIToken, *models.User, error) {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, nil, fmt.Errorf("malformed token")
+	}
+
+	t, err := s.Repo.GetByHash(ctx, HashToken(raw))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown token")
+	}
+	if !t.IsActive() {
+		return nil, nil, fmt.Errorf("token revoked or expired")
+	}
+
+	var owner *models.User
+	if t.UserID != nil {
+		u, err := s.Users.GetUserByID(ctx, *t.UserID)
+		if err != nil || !u.IsActive {
+			return nil, nil, fmt.Errorf("token owner is disabled")
+		}
+		owner = &u
+
+		var allowed []string
+		for _, sc := range t.Scopes {
+			if owner.HasRole(models.ScopeRole(sc)) {
+				allowed = append(allowed, sc)
+			}
+		}
+		t.Scopes = allowed
+	}
+
+	if err := s.Repo.TouchLastUsed(ctx, t.ID, ip); err != nil {
+		log.Printf("Tokens: failed to record usage of token %d: %v", t.ID, err)
+	}
+	return &t, owner, nil
+}
+
+// List returns all tokens for admins and only their own for other users.
+func (s *TokenService) List(ctx context.Context, viewer *models.User) ([]models.APIToken, error) {
+	if viewer == nil || viewer.HasRole(models.RoleAdmin) {
+		return s.Repo.List(ctx, nil)
+	}
+	return s.Repo.List(ctx, &viewer.ID)
+}
+
+// Revoke revokes a token; non-admins may only revoke their own.
+func (s *TokenService) Revoke(ctx context.Context, id int, viewer *models.User) error {
+	var userID *int
+	if viewer != nil && !viewer.HasRole(models.RoleAdmin) {
+		userID = &viewer.ID
+	}
+	ok, err := s.Repo.Revoke(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("token %d not found", id)
+	}
+	audit.Describe(ctx, "token.revoke", "api_token", id, "", map[string]bool{"revoked": false}, map[string]bool{"revoked": true})
+	return nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range models.AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/audit"
+	"github.com/Abhaythakor/SigMap/internal/models"
+	"github.com/Abhaythakor/SigMap/internal/repositories"
+	"github.com/Abhaythakor/SigMap/internal/workspace"
+)
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+type WorkspaceService struct {
+	Repo *repositories.WorkspaceRepository
+}
+
+func NewWorkspaceService(repo *repositories.WorkspaceRepository) *WorkspaceService {
+	return &WorkspaceService{Repo: repo}
+}
+
+// Resolve picks the workspace for a request. Without a user (auth disabled or
+// service tokens) any existing workspace is allowed. Global admins may enter
+// every workspace as admin; other users need a membership, and fall back to
+// their first workspace when the requested one is not theirs.
+func (s *WorkspaceService) Resolve(ctx context.Context, user *models.User, requested int) (int, string, error) {
+	if requested == 0 {
+		requested = workspace.DefaultID
+	}
+
+	if user == nil || user.IsGlobalAdmin() {
+		if _, err := s.Repo.Get(ctx, requested); err != nil {
+			if requested == workspace.DefaultID {
+				return 0, "", fmt.Errorf("workspace %d not found: %w", requested, err)
+			}
+			requested = workspace.DefaultID
+		}
+		if user == nil {
+			return requested, "", nil
+		}
+		return requested, models.RoleAdmin, nil
+	}
+
+	role, err := s.Repo.MemberRole(ctx, requested, user.ID)
+	if err != nil {
+		return 0, "", err
+	}
+	if role != "" {
+		return requested, role, nil
+	}
+
+	list, err := s.Repo.ListForUser(ctx, user.ID)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(list) == 0 {
+		return 0, "", fmt.Errorf("%s is not a member of any workspace", user.DisplayName())
+	}
+	role, err = s.Repo.MemberRole(ctx, list[0].ID, user.ID)
+	return list[0].ID, role, err
+}
+
+// Available lists the workspaces a user can switch to.
+func (s *WorkspaceService) Available(ctx context.Context, user *models.User) ([]models.Workspace, error) {
+	if user == nil || user.IsGlobalAdmin() {
+		return s.Repo.List(ctx)
+	}
+	return s.Repo.ListForUser(ctx, user.ID)
+}
+
+// Lookup finds a workspace by numeric ID or slug.
+func (s *WorkspaceService) Lookup(ctx context.Context, ref string) (models.Workspace, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return s.Repo.Get(ctx, id)
+	}
+	return s.Repo.GetBySlug(ctx, ref)
+}
+
+// Create adds a workspace and makes the creator its admin.
+func (s *WorkspaceService) Create(ctx context.Context, name string, creator *models.User) (models.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Workspace{}, fmt.Errorf("workspace name is required")
+	}
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return models.Workspace{}, fmt.Errorf("workspace name must contain letters or digits")
+	}
+
+	ws, err := s.Repo.Create(ctx, name, slug)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	if creator != nil && creator.Email != "" {
+		if _, err := s.Repo.SetMemberByEmail(ctx, ws.ID, creator.Email, models.RoleAdmin); err != nil {
+			log.Printf("Workspaces: failed to add creator to %s: %v", ws.Slug, err)
+		}
+	}
+	audit.Describe(ctx, "workspace.create", "workspace", ws.ID, ws.Name, nil, map[string]string{"name": ws.Name, "slug": ws.Slug})
+	log.Printf("Workspaces: created %q (%s)", ws.Name, ws.Slug)
+	return ws, nil
+}
+
+// SetMember adds or updates a member of the current workspace.
+func (s *WorkspaceService) SetMember(ctx context.Context, email, role string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if models.RoleRank(role) == 0 {
+		return fmt.Errorf("invalid role %q", role)
+	}
+	wsID := workspace.FromContext(ctx)
+	before := map[string]string{}
+	if members, err := s.Repo.ListMembers(ctx, wsID); err == nil {
+		for _, m := range members {
+			if strings.EqualFold(m.Email, email) {
+				before["role"] = m.Role
+			}
+		}
+	}
+
+	ok, err := s.Repo.SetMemberByEmail(ctx, wsID, email, role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no user with email %s has signed in yet", email)
+	}
+	audit.Describe(ctx, "workspace.member_set", "user", 0, email, before, map[string]string{"role": role})
+	return nil
+}
+
+// RemoveMember removes a user from the current workspace.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID int) error {
+	wsID := workspace.FromContext(ctx)
+	role, err := s.Repo.MemberRole(ctx, wsID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.RemoveMember(ctx, wsID, userID); err != nil {
+		return err
+	}
+	audit.Describe(ctx, "workspace.member_remove", "user", userID, "", map[string]string{"role": role}, nil)
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package timewindow parses the time range and bucket size of trend charts:
+// a preset range ending now (24h, 7d, 30d, 90d, 1y) or a custom one, split
+// into hourly, daily, weekly or monthly buckets in a timezone.
+package timewindow
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Granularities, named as PostgreSQL's DATE_TRUNC units.
+const (
+	Hour  = "hour"
+	Day   = "day"
+	Week  = "week" // starting Monday
+	Month = "month"
+)
+
+// Granularities lists them finest first.
+var Granularities = []string{Hour, Day, Week, Month}
+
+// Custom is the range between explicit from and to times.
+const Custom = "custom"
+
+// Ranges lists the preset ranges, then Custom.
+var Ranges = []string{"24h", "7d", "30d", "90d", "1y", Custom}
+
+var presets = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+	"1y":  365 * 24 * time.Hour,
+}
+
+var presetNames = map[string]string{"24h": "24 hours", "7d": "7 days", "30d": "30 days", "90d": "90 days", "1y": "1 year"}
+
+// MaxBuckets bounds how finely a window can be split.
+const MaxBuckets = 1000
+
+// Window is the half-open interval [Start, End) split into buckets of
+// Granularity, aligned in Location.
+type Window struct {
+	Range       string
+	Start       time.Time
+	End         time.Time
+	Granularity string
+	Location    *time.Location
+}
+
+// Parse reads a window from URL parameters:
+//
+//	range        one of Ranges, def when empty
+//	from, to     the bounds of a custom range, as 2006-01-02 (to is
+//	             inclusive), 2006-01-02T15:04 or RFC 3339; to defaults to now
+//	granularity  one of Granularities, chosen from the range's length when empty
+//	tz           an IANA timezone bucket boundaries are aligned in, UTC when empty
+//
+// A from without a range makes the range custom.
+func Parse(v url.Values, def string, now time.Time) (Window, error) {
+	w := Window{Range: strings.ToLower(strings.TrimSpace(v.Get("range"))), Location: time.UTC}
+	if tz := strings.TrimSpace(v.Get("tz")); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return w, fmt.Errorf("unknown timezone %q", tz)
+		}
+		w.Location = loc
+	}
+	if w.Range == "" {
+		w.Range = def
+		if v.Get("from") != "" {
+			w.Range = Custom
+		}
+	}
+
+	if w.Range == Custom {
+		from, err := parseTime(v.Get("from"), w.Location, false)
+		if err != nil {
+			return w, fmt.Errorf("from: %v", err)
+		}
+		to := now
+		if raw := v.Get("to"); raw != "" {
+			if to, err = parseTime(raw, w.Location, true); err != nil {
+				return w, fmt.Errorf("to: %v", err)
+			}
+		}
+		if !from.Before(to) {
+			return w, fmt.Errorf("from must be before to")
+		}
+		w.Start, w.End = from, to
+	} else {
+		d, ok := presets[w.Range]
+		if !ok {
+			return w, fmt.Errorf("unknown range %q, expected one of %s", w.Range, strings.Join(Ranges, ", "))
+		}
+		w.Start, w.End = now.Add(-d), now
+	}
+
+	w.Granularity = strings.ToLower(strings.TrimSpace(v.Get("granularity")))
+	switch w.Granularity {
+	case "":
+		w.Granularity = defaultGranularity(w.End.Sub(w.Start))
+	case Hour, Day, Week, Month:
+	default:
+		return w, fmt.Errorf("unknown granularity %q, expected one of %s", w.Granularity, strings.Join(Granularities, ", "))
+	}
+	if n := w.Buckets(); n > MaxBuckets {
+		return w, fmt.Errorf("%s buckets over %s make %d points, more than %d; choose a coarser granularity", w.Granularity, w.Name(), n, MaxBuckets)
+	}
+	return w, nil
+}
+
+func parseTime(raw string, loc *time.Location, inclusive bool) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("required for a custom range")
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", raw, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, loc)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q", raw)
+	}
+	if inclusive {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// defaultGranularity keeps a chart between roughly 24 and 100 points.
+func defaultGranularity(d time.Duration) string {
+	switch {
+	case d <= 2*24*time.Hour:
+		return Hour
+	case d <= 100*24*time.Hour:
+		return Day
+	case d <= 2*365*24*time.Hour:
+		return Week
+	}
+	return Month
+}
+
+// Buckets estimates how many buckets the window is split into.
+func (w Window) Buckets() int {
+	var unit time.Duration
+	switch w.Granularity {
+	case Hour:
+		unit = time.Hour
+	case Day:
+		unit = 24 * time.Hour
+	case Week:
+		unit = 7 * 24 * time.Hour
+	default:
+		unit = 30 * 24 * time.Hour
+	}
+	return int(w.End.Sub(w.Start)/unit) + 1
+}
+
+// Previous is the window of the same length right before w, to compare
+// against.
+func (w Window) Previous() Window {
+	p := w
+	p.Start, p.End = w.Start.Add(-w.End.Sub(w.Start)), w.Start
+	return p
+}
+
+// Name describes the range, e.g. "30 days".
+func (w Window) Name() string {
+	if name, ok := presetNames[w.Range]; ok {
+		return name
+	}
+	start, end := w.Start.In(w.Location), w.End.In(w.Location)
+	return start.Format("Jan 02, 2006") + " – " + end.Add(-time.Nanosecond).Format("Jan 02, 2006")
+}
+
+// Label formats the start of a bucket, given as a wall clock time in the
+// window's timezone. Days carry the year when the window spans years.
+func (w Window) Label(bucket time.Time) string {
+	switch w.Granularity {
+	case Hour:
+		return bucket.Format("02 Jan 15:04")
+	case Month:
+		return bucket.Format("Jan 2006")
+	}
+	if w.Start.In(w.Location).Year() != w.End.In(w.Location).Year() {
+		return bucket.Format("02 Jan 2006")
+	}
+	return bucket.Format("02 Jan")
+}
+
+// Values encodes the window as URL parameters Parse reads back.
+func (w Window) Values() url.Values {
+	v := url.Values{}
+	v.Set("range", w.Range)
+	if w.Range == Custom {
+		v.Set("from", w.Start.In(w.Location).Format(time.RFC3339))
+		v.Set("to", w.End.In(w.Location).Format(time.RFC3339))
+	}
+	v.Set("granularity", w.Granularity)
+	if w.Location != time.UTC {
+		v.Set("tz", w.Location.String())
+	}
+	return v
+}
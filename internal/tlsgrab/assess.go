@@ -0,0 +1,95 @@
+package tlsgrab
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Minimum key sizes below which a certificate is flagged as weak.
+const (
+	MinRSABits   = 2048
+	MinECDSABits = 256
+)
+
+// Fingerprint returns the hex SHA-256 of the DER certificate.
+func Fingerprint(c *x509.Certificate) string {
+	sum := sha256.Sum256(c.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyInfo returns the public key algorithm and size in bits (0 if fixed).
+func KeyInfo(c *x509.Certificate) (string, int) {
+	switch k := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 0
+	}
+	return c.PublicKeyAlgorithm.String(), 0
+}
+
+// SelfSigned reports whether a certificate is signed by its own key. A
+// signature Go refuses to check (SHA-1, MD5) is taken at its word, so old
+// self-signed certificates are still caught.
+func SelfSigned(c *x509.Certificate) bool {
+	if !bytes.Equal(c.RawSubject, c.RawIssuer) {
+		return false
+	}
+	err := c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature)
+	var insecure x509.InsecureAlgorithmError
+	return err == nil || errors.As(err, &insecure)
+}
+
+// WeakReasons lists the weak cryptography a certificate uses. The signature
+// of a self-signed CA is not checked: it is a trust anchor, and clients do
+// not rely on it.
+func WeakReasons(c *x509.Certificate) []string {
+	var reasons []string
+	keyType, bits := KeyInfo(c)
+	switch keyType {
+	case "RSA":
+		if bits < MinRSABits {
+			reasons = append(reasons, fmt.Sprintf("RSA key of %d bits", bits))
+		}
+	case "ECDSA":
+		if bits < MinECDSABits {
+			reasons = append(reasons, fmt.Sprintf("ECDSA key of %d bits", bits))
+		}
+	case "DSA":
+		reasons = append(reasons, "DSA key")
+	}
+
+	if c.IsCA && SelfSigned(c) {
+		return reasons
+	}
+	switch c.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA:
+		reasons = append(reasons, "MD5 signature")
+	case x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		reasons = append(reasons, "SHA-1 signature")
+	}
+	return reasons
+}
+
+// CommonNames returns the subject and issuer common names, falling back to
+// the organization when a name has no CN.
+func CommonNames(c *x509.Certificate) (string, string) {
+	return commonName(c.Subject.CommonName, c.Subject.Organization), commonName(c.Issuer.CommonName, c.Issuer.Organization)
+}
+
+func commonName(cn string, org []string) string {
+	if cn != "" {
+		return cn
+	}
+	return strings.Join(org, ", ")
+}
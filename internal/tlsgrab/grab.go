@@ -0,0 +1,112 @@
+// Package tlsgrab collects the certificate chains TLS endpoints serve and
+// assesses them for expiry, self-signing and weak cryptography.
+package tlsgrab
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is always grabbed; TLS_PORTS adds more.
+const DefaultPort = 443
+
+// PortsFromEnv returns 443 plus the comma separated ports in TLS_PORTS.
+func PortsFromEnv() []int {
+	ports := []int{DefaultPort}
+	for _, p := range strings.Split(os.Getenv("TLS_PORTS"), ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n <= 0 || n > 65535 || n == DefaultPort {
+			continue
+		}
+		ports = append(ports, n)
+	}
+	return ports
+}
+
+type Grabber struct {
+	Timeout time.Duration
+	// Roots verifies chains; nil uses the system pool.
+	Roots *x509.CertPool
+}
+
+func NewGrabber() *Grabber {
+	return &Grabber{Timeout: 10 * time.Second}
+}
+
+// Result is what one endpoint presented during the handshake.
+type Result struct {
+	Host    string
+	Port    int
+	Address string
+	Version string
+	Cipher  string
+	// Chain is the certificates as sent by the server, leaf first.
+	Chain []*x509.Certificate
+	// VerifyError explains why the chain is not trusted for Host, if it isn't.
+	VerifyError string
+	// HandshakeError is set when the port accepted a connection but TLS failed.
+	HandshakeError string
+}
+
+// Grab connects to host:port and records the chain without trusting it. An
+// error means the port could not be reached at all.
+func (g *Grabber) Grab(ctx context.Context, host string, port int) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res := &Result{Host: host, Port: port}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		res.Address = addr.IP.String()
+	}
+
+	client := tls.Client(conn, &tls.Config{
+		ServerName: host,
+		// Verification is done separately so untrusted chains are still
+		// recorded.
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+	})
+	if err := client.HandshakeContext(ctx); err != nil {
+		res.HandshakeError = err.Error()
+		return res, nil
+	}
+
+	state := client.ConnectionState()
+	res.Version = tls.VersionName(state.Version)
+	res.Cipher = tls.CipherSuiteName(state.CipherSuite)
+	res.Chain = state.PeerCertificates
+	if err := g.verify(host, res.Chain); err != nil {
+		res.VerifyError = err.Error()
+	}
+	return res, nil
+}
+
+func (g *Grabber) verify(host string, chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("no certificate presented")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+		Roots:         g.Roots,
+	})
+	return err
+}
@@ -0,0 +1,190 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Range is a set of versions: alternatives separated by "||", each a list
+// of comparisons that must all hold. It reads the forms advisories and
+// package managers use:
+//
+//	>=1.0, <1.20.1 || >=1.21 <1.21.3
+//	1.2.x   ~1.2.3   ^1.2.3   1.0 - 1.4   *
+type Range struct {
+	alternatives [][]comparison
+}
+
+type comparison struct {
+	op  string // <, <=, >, >=, =, !=
+	key string
+}
+
+// ParseRange reads a range. A bare version matches itself only.
+func ParseRange(s string) (Range, error) {
+	var r Range
+	for _, alt := range strings.Split(s, "||") {
+		set, err := parseSet(alt)
+		if err != nil {
+			return Range{}, fmt.Errorf("range %q: %v", s, err)
+		}
+		r.alternatives = append(r.alternatives, set)
+	}
+	return r, nil
+}
+
+// Contains reports whether v falls in the range.
+func (r Range) Contains(v Version) bool {
+	key := v.Key()
+	for _, set := range r.alternatives {
+		if matchSet(key, set) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSet(key string, set []comparison) bool {
+	for _, c := range set {
+		var ok bool
+		switch c.op {
+		case "<":
+			ok = key < c.key
+		case "<=":
+			ok = key <= c.key
+		case ">":
+			ok = key > c.key
+		case ">=":
+			ok = key >= c.key
+		case "=":
+			ok = key == c.key
+		case "!=":
+			ok = key != c.key
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSet reads one alternative: comparisons separated by commas or
+// spaces, or a hyphen range.
+func parseSet(s string) ([]comparison, error) {
+	s = strings.TrimSpace(s)
+	if lo, hi, ok := strings.Cut(s, " - "); ok {
+		from, err := bound(">=", strings.TrimSpace(lo))
+		if err != nil {
+			return nil, err
+		}
+		to, err := bound("<=", strings.TrimSpace(hi))
+		if err != nil {
+			return nil, err
+		}
+		return append(from, to...), nil
+	}
+
+	var set []comparison
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		op := f[:len(f)-len(strings.TrimLeft(f, "<>=!~^"))]
+		v := f[len(op):]
+		// An operator may be separated from its version: ">= 1.2"
+		if v == "" && i+1 < len(fields) {
+			i++
+			v = fields[i]
+		}
+		c, err := bound(op, v)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, c...)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty range")
+	}
+	return set, nil
+}
+
+// bound turns one operator and version into comparisons. Wildcards (1.2.x),
+// tilde (~1.2.3: same minor) and caret (^1.2.3: same leftmost non-zero
+// number) become a lower and an exclusive upper bound.
+func bound(op, s string) ([]comparison, error) {
+	if op == "==" {
+		op = "="
+	}
+	if s == "*" || s == "x" || s == "X" {
+		if op != "" && op != "=" && op != ">=" {
+			return nil, fmt.Errorf("%s%s matches nothing", op, s)
+		}
+		return nil, nil
+	}
+
+	// Wildcards: cut at the first x or *
+	parts := strings.Split(s, ".")
+	wild := len(parts)
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			wild = i
+			break
+		}
+	}
+	v, err := Parse(strings.Join(parts[:wild], "."))
+	if err != nil {
+		return nil, err
+	}
+	if wild < len(parts) {
+		if op != "" && op != "=" {
+			return nil, fmt.Errorf("%s cannot take a wildcard", op)
+		}
+		return prefixBounds(v, wild), nil
+	}
+
+	switch op {
+	case "", "=":
+		return []comparison{{"=", v.Key()}}, nil
+	case "<", "<=", ">", ">=", "!=":
+		return []comparison{{op, v.Key()}}, nil
+	case "~", "~>":
+		// ~1 allows 1.x; ~1.2 and ~1.2.3 allow 1.2.x
+		n := 2
+		if len(strings.Split(strings.SplitN(s, "-", 2)[0], ".")) == 1 {
+			n = 1
+		}
+		return append([]comparison{{">=", v.Key()}}, upper(v, n)), nil
+	case "^":
+		n := 1
+		for n < len(v.Numbers) && v.Segment(n-1) == 0 {
+			n++
+		}
+		return append([]comparison{{">=", v.Key()}}, upper(v, n)), nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+// prefixBounds matches every version starting with v's first n numbers.
+func prefixBounds(v Version, n int) []comparison {
+	if n == 0 {
+		return nil
+	}
+	lo := Version{Epoch: v.Epoch, Numbers: prefix(v, n), Pre: []string{}}
+	return []comparison{{">=", lo.Key()}, upper(v, n)}
+}
+
+// upper is the exclusive bound above every version sharing v's first n
+// numbers: the earliest pre-release of the next one.
+func upper(v Version, n int) comparison {
+	nums := prefix(v, n)
+	nums[n-1]++
+	next := Version{Epoch: v.Epoch, Numbers: nums, Pre: []string{}}
+	return comparison{"<", next.Key()}
+}
+
+func prefix(v Version, n int) []int64 {
+	nums := make([]int64, n)
+	for i := range nums {
+		nums[i] = v.Segment(i)
+	}
+	return nums
+}
@@ -0,0 +1,249 @@
+// Package version reads the free-form versions that scanners report
+// (semver, dotted numbers, distro builds such as 1.18.0-ubuntu1 or
+// 1:2.4.41-4ubuntu3.14, OpenSSL's 1.1.1k) and orders them. Every version
+// has a Key whose byte order is version order, so the database can sort and
+// compare detections without understanding versions itself.
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed version. Only Epoch, Numbers and Pre take part in
+// ordering; a distro or build Revision is kept for display, so
+// 1.18.0-ubuntu1 equals 1.18.0.
+type Version struct {
+	Raw      string
+	Epoch    int64
+	Numbers  []int64  // most significant first
+	Pre      []string // pre-release identifiers (rc, 1); nil for a release
+	Revision string   // distro revision or build metadata
+}
+
+// Pre-release words by rank: dev < alpha < beta < rc. Other identifiers
+// after the first sort after these, as text.
+var preRank = map[string]byte{
+	"dev": '1', "snapshot": '1', "nightly": '1',
+	"alpha": '2', "a": '2',
+	"beta": '3', "b": '3',
+	"rc": '4', "c": '4', "pre": '4', "preview": '4', "cr": '4',
+}
+
+// Parse reads a version. It needs at least one leading number; a "v"
+// prefix and a Debian epoch ("1:") are accepted.
+func Parse(s string) (Version, error) {
+	v := Version{Raw: s}
+	t := strings.ToLower(strings.TrimSpace(s))
+	t = strings.TrimSpace(strings.TrimPrefix(t, "version"))
+	if len(t) > 1 && t[0] == 'v' && isDigit(t[1]) {
+		t = t[1:]
+	}
+	if i := strings.IndexByte(t, ':'); i > 0 && allDigits(t[:i]) {
+		epoch, err := strconv.ParseInt(t[:i], 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a version", s)
+		}
+		v.Epoch, t = epoch, t[i+1:]
+	}
+
+	// Dotted numbers
+	for {
+		end := 0
+		for end < len(t) && isDigit(t[end]) {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, err := strconv.ParseInt(t[:end], 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a version", s)
+		}
+		v.Numbers = append(v.Numbers, n)
+		t = t[end:]
+		if len(t) < 2 || t[0] != '.' || !isDigit(t[1]) {
+			break
+		}
+		t = t[1:]
+	}
+	if len(v.Numbers) == 0 {
+		return v, fmt.Errorf("%q is not a version", s)
+	}
+
+	// OpenSSL-style letter releases: 1.1.1k comes after 1.1.1j
+	if len(t) > 0 && isLetter(t[0]) && (len(t) == 1 || !isLetter(t[1]) && !isDigit(t[1])) {
+		v.Numbers = append(v.Numbers, int64(t[0]-'a'+1))
+		t = t[1:]
+	}
+
+	if t == "" {
+		return v, nil
+	}
+	if t[0] == '+' {
+		v.Revision = t[1:]
+		return v, nil
+	}
+	body := t
+	if strings.ContainsRune("-._~", rune(t[0])) {
+		body = t[1:]
+	}
+	pre, build, _ := strings.Cut(body, "+")
+	if ids := splitIdentifiers(pre); len(ids) > 0 && preRank[ids[0]] != 0 {
+		v.Pre, v.Revision = ids, build
+		return v, nil
+	}
+	v.Revision = body
+	return v, nil
+}
+
+// MustParse is Parse for versions known to be valid.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Key returns the sortable key of a version string, or "" when it cannot
+// be read.
+func Key(s string) string {
+	v, err := Parse(s)
+	if err != nil {
+		return ""
+	}
+	return v.Key()
+}
+
+// Key encodes the version so that byte order (COLLATE "C" in Postgres) is
+// version order. Numbers are length-prefixed and trailing zeros dropped, so
+// 1.2 equals 1.2.0; after them a pre-release sorts before the release,
+// which sorts before any further number.
+func (v Version) Key() string {
+	var b strings.Builder
+	writeNumber(&b, v.Epoch)
+	for _, n := range trimZeros(v.Numbers) {
+		b.WriteByte('3')
+		writeNumber(&b, n)
+	}
+	if v.Pre == nil {
+		b.WriteByte('2')
+		return b.String()
+	}
+	b.WriteByte('1')
+	for i, id := range v.Pre {
+		switch n, err := strconv.ParseInt(id, 10, 64); {
+		case err == nil:
+			b.WriteByte('1')
+			writeNumber(&b, n)
+		case i == 0 || preRank[id] != 0:
+			b.WriteByte('2')
+			b.WriteByte(preRank[id])
+		default:
+			b.WriteByte('3')
+			b.WriteString(id)
+			b.WriteByte('.')
+		}
+	}
+	b.WriteByte('0')
+	return b.String()
+}
+
+// String returns the normalized version: epoch, numbers and pre-release,
+// without the revision.
+func (v Version) String() string {
+	var b strings.Builder
+	if v.Epoch > 0 {
+		b.WriteString(strconv.FormatInt(v.Epoch, 10) + ":")
+	}
+	nums := v.Numbers
+	if len(nums) == 0 {
+		nums = []int64{0}
+	}
+	for i, n := range nums {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(strconv.FormatInt(n, 10))
+	}
+	if len(v.Pre) > 0 {
+		b.WriteString("-" + strings.Join(v.Pre, "."))
+	}
+	return b.String()
+}
+
+// Segment returns the i-th number, zero when the version has fewer.
+func (v Version) Segment(i int) int64 {
+	if i < len(v.Numbers) {
+		return v.Numbers[i]
+	}
+	return 0
+}
+
+// Compare returns -1, 0 or 1 as a is older than, equal to or newer than b.
+func Compare(a, b Version) int {
+	return strings.Compare(a.Key(), b.Key())
+}
+
+// Less orders version strings oldest first; strings that are not versions
+// sort after all versions, as text.
+func Less(a, b string) bool {
+	ka, kb := Key(a), Key(b)
+	if (ka == "") != (kb == "") {
+		return kb == ""
+	}
+	if ka != kb {
+		return ka < kb
+	}
+	return a < b
+}
+
+// writeNumber writes n as its digit count (two digits) then its digits, so
+// longer numbers sort after shorter ones. Zero has no digits.
+func writeNumber(b *strings.Builder, n int64) {
+	digits := ""
+	if n > 0 {
+		digits = strconv.FormatInt(n, 10)
+	}
+	fmt.Fprintf(b, "%02d%s", len(digits), digits)
+}
+
+// splitIdentifiers splits a pre-release on separators and between letters
+// and digits: "rc.1" and "rc1" both give [rc 1].
+func splitIdentifiers(s string) []string {
+	var ids []string
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		boundary := i == len(s) || !isLetter(s[i]) && !isDigit(s[i]) ||
+			start >= 0 && isDigit(s[i]) != isDigit(s[start])
+		if boundary && start >= 0 {
+			ids = append(ids, s[start:i])
+			start = -1
+		}
+		if i < len(s) && (isLetter(s[i]) || isDigit(s[i])) && start < 0 {
+			start = i
+		}
+	}
+	return ids
+}
+
+func trimZeros(nums []int64) []int64 {
+	for len(nums) > 1 && nums[len(nums)-1] == 0 {
+		nums = nums[:len(nums)-1]
+	}
+	return nums
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' }
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -46,6 +46,9 @@ func (c *Correlator) Correlate(technology string, findings []VulnFinding) VulnPr
 		if f.BugType != "" && existing.BugType == "" {
 			existing.BugType = f.BugType
 		}
+		if f.AffectedVersions != "" && existing.AffectedVersions == "" {
+			existing.AffectedVersions = f.AffectedVersions
+		}
 		if f.ExploitAvailable {
 			existing.ExploitAvailable = true
 		}
@@ -26,6 +26,7 @@ type VulnFinding struct {
 	POCAvailable      bool      `json:"poc_available"`
 	ExploitedInWild   bool      `json:"exploited_in_wild"`
 	PublishedAt       time.Time `json:"published_at"`
+	AffectedVersions  string    `json:"affected_versions,omitempty"` // e.g. ">=1.0,<1.20.1 || >=1.21,<1.21.3"; empty when unknown
 }
 
 // SourceConnector defines the interface for vulnerability data providers.
@@ -42,7 +42,7 @@ func (s *Service) GetTechVulnProfile(ctx context.Context, technology string) (Vu
 
 func (s *Service) getDetailedVulns(ctx context.Context, technology string) ([]VulnFinding, error) {
 	rows, err := s.Pool.Query(ctx, `
-		SELECT cve_id, description, severity_score, severity_label, bug_type, exploit_available, published_at
+		SELECT cve_id, description, severity_score, severity_label, bug_type, exploit_available, published_at, COALESCE(affected_versions, '')
 		FROM vulnerability_details
 		WHERE technology = $1
 		ORDER BY severity_score DESC NULLS LAST
@@ -55,7 +55,7 @@ func (s *Service) getDetailedVulns(ctx context.Context, technology string) ([]Vu
 	var findings []VulnFinding
 	for rows.Next() {
 		var f VulnFinding
-		err := rows.Scan(&f.CVE, &f.Description, &f.Severity, &f.SeverityLabel, &f.BugType, &f.ExploitAvailable, &f.PublishedAt)
+		err := rows.Scan(&f.CVE, &f.Description, &f.Severity, &f.SeverityLabel, &f.BugType, &f.ExploitAvailable, &f.PublishedAt, &f.AffectedVersions)
 		if err == nil {
 			findings = append(findings, f)
 		}
@@ -106,15 +106,16 @@ func (s *Service) RefreshTechProfile(ctx context.Context, technology string) (Vu
 	// Update Details
 	for _, v := range profile.DetailedVulns {
 		_, _ = s.Pool.Exec(ctx, `
-			INSERT INTO vulnerability_details (cve_id, technology, description, severity_score, severity_label, bug_type, exploit_available, published_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			INSERT INTO vulnerability_details (cve_id, technology, description, severity_score, severity_label, bug_type, exploit_available, published_at, affected_versions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
 			ON CONFLICT (cve_id, technology) DO UPDATE SET
 				description = EXCLUDED.description,
 				severity_score = EXCLUDED.severity_score,
 				severity_label = EXCLUDED.severity_label,
 				bug_type = EXCLUDED.bug_type,
-				exploit_available = EXCLUDED.exploit_available
-		`, v.CVE, technology, v.Description, v.Severity, v.SeverityLabel, v.BugType, v.ExploitAvailable, v.PublishedAt)
+				exploit_available = EXCLUDED.exploit_available,
+				affected_versions = COALESCE(EXCLUDED.affected_versions, vulnerability_details.affected_versions)
+		`, v.CVE, technology, v.Description, v.Severity, v.SeverityLabel, v.BugType, v.ExploitAvailable, v.PublishedAt, v.AffectedVersions)
 	}
 
 	return profile, err
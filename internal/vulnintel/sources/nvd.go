@@ -40,6 +40,7 @@ func (c *NVDConnector) FetchFindings(technology string) ([]vulnintel.VulnFinding
 			ExploitAvailable: rng.Float32() > 0.7,
 			POCAvailable:     rng.Float32() > 0.5,
 			PublishedAt:      time.Now().AddDate(0, 0, -rng.Intn(100)),
+			AffectedVersions: fmt.Sprintf("<%d.%d", rng.Intn(3)+1, rng.Intn(30)),
 		},
 	}
 
@@ -0,0 +1,29 @@
+package vulnintel
+
+import (
+	"strings"
+
+	"github.com/Abhaythakor/SigMap/internal/version"
+)
+
+// Affects reports whether a finding applies to a detected version. known is
+// false when the finding has no affected range, or the range or version
+// cannot be read, in which case the caller decides how to count it.
+//
+// AffectedVersions is a version.Range: alternatives separated by "||", each
+// a list of comparisons that must all hold, e.g.
+// ">=1.0,<1.20.1 || >=1.21,<1.21.3".
+func (f VulnFinding) Affects(detected string) (affected, known bool) {
+	if strings.TrimSpace(f.AffectedVersions) == "" {
+		return false, false
+	}
+	v, err := version.Parse(detected)
+	if err != nil {
+		return false, false
+	}
+	r, err := version.ParseRange(f.AffectedVersions)
+	if err != nil {
+		return false, false
+	}
+	return r.Contains(v), true
+}
@@ -0,0 +1,24 @@
+// Package workspace carries the active workspace through request and job
+// contexts so repositories can scope their queries.
+package workspace
+
+import "context"
+
+// DefaultID is the workspace that pre-existing data and unscoped callers
+// (CLI flags, background jobs without a workspace) belong to.
+const DefaultID = 1
+
+type contextKey struct{}
+
+// WithID returns a context scoped to the given workspace.
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the workspace ID of ctx, or DefaultID if none is set.
+func FromContext(ctx context.Context) int {
+	if id, ok := ctx.Value(contextKey{}).(int); ok && id > 0 {
+		return id
+	}
+	return DefaultID
+}
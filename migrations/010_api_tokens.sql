@@ -0,0 +1,19 @@
+-- 010_api_tokens.sql
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'personal', -- 'personal', 'service'
+    token_prefix VARCHAR(16) NOT NULL,            -- shown in the UI to identify a token
+    token_hash VARCHAR(64) NOT NULL UNIQUE,       -- SHA-256 of the full token
+    user_id INT REFERENCES users(id) ON DELETE CASCADE, -- owner of a personal token
+    created_by VARCHAR(255),
+    scopes TEXT[] NOT NULL DEFAULT '{read}',      -- read, scan, ingest, admin
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    last_used_ip VARCHAR(45),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);
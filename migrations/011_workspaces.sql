@@ -0,0 +1,62 @@
+-- 011_workspaces.sql
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing data is moved into the default workspace.
+INSERT INTO workspaces (id, name, slug) VALUES (1, 'Default', 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('workspaces', 'id'), GREATEST((SELECT MAX(id) FROM workspaces), 1));
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL DEFAULT 'viewer', -- viewer, analyst, admin
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- Existing users keep their global role in the default workspace.
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT 1, id, role FROM users
+ON CONFLICT DO NOTHING;
+
+-- Core tables
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE bookmarks ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE alert_channels ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE;
+
+-- Domain names are unique per workspace, not globally.
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_workspace_name ON domains(workspace_id, name);
+
+ALTER TABLE bookmarks DROP CONSTRAINT IF EXISTS bookmarks_domain_id_technology_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmarks_workspace_target ON bookmarks(workspace_id, domain_id, technology_id);
+
+CREATE INDEX IF NOT EXISTS idx_detections_workspace ON detections(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_notes_workspace ON notes(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_alert_channels_workspace ON alert_channels(workspace_id);
+
+-- Dashboard stats become one row per workspace.
+DROP MATERIALIZED VIEW IF EXISTS view_dashboard_stats;
+
+CREATE MATERIALIZED VIEW view_dashboard_stats AS
+SELECT
+    w.id as workspace_id,
+    (SELECT COUNT(*) FROM detections det WHERE det.workspace_id = w.id) as total_detections,
+    (SELECT COALESCE(AVG(det.confidence), 0) FROM detections det WHERE det.workspace_id = w.id) as avg_confidence,
+    (SELECT COUNT(DISTINCT t.id) FROM detections det
+        JOIN technologies t ON det.technology_id = t.id
+        LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
+        WHERE det.workspace_id = w.id AND COALESCE(vp.risk_level, t.risk_level) IN ('High', 'Critical')) as risky_technologies,
+    (SELECT COUNT(*) FROM domains d WHERE d.workspace_id = w.id AND d.is_bookmarked = TRUE) as bookmarked_domains,
+    CURRENT_TIMESTAMP as last_refreshed
+FROM workspaces w;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_stats_workspace ON view_dashboard_stats(workspace_id);
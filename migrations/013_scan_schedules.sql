@@ -0,0 +1,34 @@
+-- 013_scan_schedules.sql
+
+-- Watchlists: recurring scans of domains or root domains on a cron schedule.
+CREATE TABLE IF NOT EXISTS scan_schedules (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    cron_expr VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    stages TEXT[] NOT NULL DEFAULT '{chaos,infra,httpx,nuclei}',
+    jitter_seconds INT NOT NULL DEFAULT 0,
+    max_concurrency INT NOT NULL DEFAULT 2,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS scan_schedule_targets (
+    id SERIAL PRIMARY KEY,
+    schedule_id INT NOT NULL REFERENCES scan_schedules(id) ON DELETE CASCADE,
+    target VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'domain', -- domain, root
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_finished_at TIMESTAMP WITH TIME ZONE,
+    last_status VARCHAR(20), -- running, ok, failed
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (schedule_id, target)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scan_schedules_workspace ON scan_schedules(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_scan_schedule_targets_due ON scan_schedule_targets(next_run_at);
@@ -0,0 +1,77 @@
+-- 014_asset_graph.sql
+
+-- Registrable domains (public suffix + one label) that hosts roll up to.
+CREATE TABLE IF NOT EXISTS root_domains (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    public_suffix VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, name)
+);
+
+-- Hosts: every domain row belongs to a root domain and points at its nearest
+-- known ancestor. Existing rows are linked by `-assets`.
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS root_domain_id INT REFERENCES root_domains(id) ON DELETE SET NULL;
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES domains(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_domains_root ON domains(root_domain_id);
+CREATE INDEX IF NOT EXISTS idx_domains_parent ON domains(parent_id);
+
+-- IP addresses seen in a workspace, with their network metadata.
+CREATE TABLE IF NOT EXISTS ip_addresses (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    address INET NOT NULL,
+    version SMALLINT NOT NULL, -- 4 or 6
+    asn INT,
+    asn_org VARCHAR(255),
+    cloud_provider VARCHAR(100),
+    country VARCHAR(2),
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, address)
+);
+
+-- A/AAAA resolutions (many-to-many between hosts and IPs).
+CREATE TABLE IF NOT EXISTS domain_ips (
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    ip_id INT NOT NULL REFERENCES ip_addresses(id) ON DELETE CASCADE,
+    record_type VARCHAR(4) NOT NULL, -- A, AAAA
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (domain_id, ip_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_ips_ip ON domain_ips(ip_id);
+
+-- Open ports and the services behind them.
+CREATE TABLE IF NOT EXISTS ip_services (
+    id SERIAL PRIMARY KEY,
+    ip_id INT NOT NULL REFERENCES ip_addresses(id) ON DELETE CASCADE,
+    port INT NOT NULL CHECK (port BETWEEN 1 AND 65535),
+    protocol VARCHAR(10) NOT NULL DEFAULT 'tcp',
+    service VARCHAR(100),
+    product VARCHAR(255),
+    version VARCHAR(100),
+    source VARCHAR(100),
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (ip_id, port, protocol)
+);
+
+-- Carry over the single IP previously stored on each domain.
+INSERT INTO ip_addresses (workspace_id, address, version, asn, asn_org, cloud_provider, first_seen, last_seen)
+SELECT DISTINCT ON (workspace_id, ip_address::inet)
+    workspace_id, ip_address::inet, family(ip_address::inet), NULLIF(asn, 0), asn_org, cloud_provider, created_at, updated_at
+FROM domains
+WHERE ip_address IS NOT NULL AND ip_address <> ''
+ORDER BY workspace_id, ip_address::inet, updated_at DESC
+ON CONFLICT (workspace_id, address) DO NOTHING;
+
+INSERT INTO domain_ips (domain_id, ip_id, record_type, first_seen, last_seen)
+SELECT d.id, i.id, CASE WHEN i.version = 6 THEN 'AAAA' ELSE 'A' END, d.created_at, d.updated_at
+FROM domains d
+JOIN ip_addresses i ON i.workspace_id = d.workspace_id AND i.address = d.ip_address::inet
+WHERE d.ip_address IS NOT NULL AND d.ip_address <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,44 @@
+-- 015_dns_records.sql
+
+-- Current and past DNS records of each host. removed_at is set when a record
+-- stops being returned and cleared if it comes back.
+CREATE TABLE IF NOT EXISTS dns_records (
+    id SERIAL PRIMARY KEY,
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    record_type VARCHAR(10) NOT NULL, -- A, AAAA, CNAME, MX, NS, TXT
+    value TEXT NOT NULL,
+    ttl INT,
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    removed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (domain_id, record_type, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dns_records_value ON dns_records(record_type, value);
+
+-- Every appearance and disappearance of a record.
+CREATE TABLE IF NOT EXISTS dns_record_changes (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    record_type VARCHAR(10) NOT NULL,
+    value TEXT NOT NULL,
+    change VARCHAR(10) NOT NULL, -- added, removed
+    observed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dns_record_changes_domain ON dns_record_changes(domain_id, observed_at DESC);
+
+-- Outcome of the latest resolution of each host, including its CNAME chain
+-- and any dangling-CNAME (subdomain takeover) signal.
+CREATE TABLE IF NOT EXISTS dns_status (
+    domain_id INT PRIMARY KEY REFERENCES domains(id) ON DELETE CASCADE,
+    resolver VARCHAR(255),
+    rcode VARCHAR(20) NOT NULL,
+    cname_chain TEXT[] NOT NULL DEFAULT '{}',
+    dangling BOOLEAN NOT NULL DEFAULT FALSE,
+    takeover_target VARCHAR(255),
+    takeover_service VARCHAR(100),
+    checked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dns_status_dangling ON dns_status(dangling) WHERE dangling;
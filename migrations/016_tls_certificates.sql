@@ -0,0 +1,67 @@
+-- 016_tls_certificates.sql
+
+-- Certificates seen in a workspace, leaf or chain, keyed by their SHA-256
+-- fingerprint. Expiry is judged against not_after at read time; self-signed
+-- and weak-crypto findings are fixed properties of the certificate.
+CREATE TABLE IF NOT EXISTS certificates (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    fingerprint_sha256 CHAR(64) NOT NULL,
+    subject TEXT NOT NULL,
+    subject_cn VARCHAR(255),
+    issuer TEXT NOT NULL,
+    issuer_cn VARCHAR(255),
+    serial VARCHAR(128) NOT NULL,
+    sans TEXT[] NOT NULL DEFAULT '{}',
+    not_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    not_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    key_type VARCHAR(20) NOT NULL, -- RSA, ECDSA, Ed25519, DSA
+    key_bits INT,
+    signature_algorithm VARCHAR(50) NOT NULL,
+    is_ca BOOLEAN NOT NULL DEFAULT FALSE,
+    self_signed BOOLEAN NOT NULL DEFAULT FALSE,
+    weak_reasons TEXT[] NOT NULL DEFAULT '{}',
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, fingerprint_sha256)
+);
+
+CREATE INDEX IF NOT EXISTS idx_certificates_not_after ON certificates(workspace_id, not_after);
+CREATE INDEX IF NOT EXISTS idx_certificates_sans ON certificates USING GIN (sans);
+
+-- Latest TLS handshake with each host and port. error is set when the port
+-- accepted a connection but the handshake failed.
+CREATE TABLE IF NOT EXISTS tls_endpoints (
+    id SERIAL PRIMARY KEY,
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    port INT NOT NULL,
+    address INET,
+    tls_version VARCHAR(10),
+    cipher_suite VARCHAR(100),
+    verify_error TEXT,
+    error TEXT,
+    checked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (domain_id, port)
+);
+
+-- Chain currently served by an endpoint; position 0 is the leaf.
+CREATE TABLE IF NOT EXISTS tls_endpoint_certificates (
+    endpoint_id INT NOT NULL REFERENCES tls_endpoints(id) ON DELETE CASCADE,
+    certificate_id INT NOT NULL REFERENCES certificates(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (endpoint_id, certificate_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tls_endpoint_certificates_cert ON tls_endpoint_certificates(certificate_id);
+
+-- How each host was discovered (e.g. "tls-san" for names found in
+-- certificate SANs).
+CREATE TABLE IF NOT EXISTS domain_sources (
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (domain_id, source)
+);
@@ -0,0 +1,8 @@
+-- 017_subdomain_sources.sql
+
+-- Subdomain discovery now queries every configured source (Chaos, CT), so
+-- the "chaos" scan stage becomes "discovery".
+UPDATE scan_schedules SET stages = array_replace(stages, 'chaos', 'discovery') WHERE 'chaos' = ANY(stages);
+ALTER TABLE scan_schedules ALTER COLUMN stages SET DEFAULT '{discovery,infra,tls,httpx,nuclei}';
+
+CREATE INDEX IF NOT EXISTS idx_domain_sources_source ON domain_sources(source);
@@ -0,0 +1,21 @@
+-- 018_passive_dns.sql
+
+-- Historical A, AAAA and CNAME resolutions imported from passive DNS
+-- exports. A/AAAA values are linked into ip_addresses so an IP pivots to
+-- every name it served, live or historic.
+CREATE TABLE IF NOT EXISTS passive_dns (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    record_type VARCHAR(10) NOT NULL, -- A, AAAA, CNAME
+    value TEXT NOT NULL,
+    ip_id INT REFERENCES ip_addresses(id) ON DELETE SET NULL,
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    source VARCHAR(255) NOT NULL,
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (domain_id, record_type, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_passive_dns_ip ON passive_dns(ip_id);
+CREATE INDEX IF NOT EXISTS idx_passive_dns_value ON passive_dns(record_type, value);
@@ -0,0 +1,37 @@
+-- 019_cloud_inventory.sql
+
+-- Resources read from cloud inventory exports (DNS record sets, instances,
+-- load balancers). Each import replaces the rows of its source file.
+CREATE TABLE IF NOT EXISTS cloud_resources (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL, -- AWS, GCP, Azure
+    account VARCHAR(255) NOT NULL, -- AWS account ID, GCP project, Azure subscription
+    region VARCHAR(100),
+    resource_type VARCHAR(50) NOT NULL, -- dns-record, instance, load-balancer
+    resource_id TEXT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    record_type VARCHAR(10),
+    hostnames TEXT[] NOT NULL DEFAULT '{}',
+    addresses INET[] NOT NULL DEFAULT '{}',
+    targets TEXT[] NOT NULL DEFAULT '{}',
+    source VARCHAR(255) NOT NULL,
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, provider, account, resource_type, resource_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cloud_resources_source ON cloud_resources(workspace_id, source);
+CREATE INDEX IF NOT EXISTS idx_cloud_resources_hostnames ON cloud_resources USING GIN(hostnames);
+CREATE INDEX IF NOT EXISTS idx_cloud_resources_addresses ON cloud_resources USING GIN(addresses);
+
+-- Hosts tied to cloud resources, and how: by name, by an owned IP, by a
+-- CNAME to the resource, or through an imported DNS record's target.
+CREATE TABLE IF NOT EXISTS domain_cloud_resources (
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    cloud_resource_id INT NOT NULL REFERENCES cloud_resources(id) ON DELETE CASCADE,
+    matched_by VARCHAR(20) NOT NULL, -- name, ip, cname, record
+    PRIMARY KEY (domain_id, cloud_resource_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_cloud_resources_resource ON domain_cloud_resources(cloud_resource_id);
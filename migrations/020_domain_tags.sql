@@ -0,0 +1,38 @@
+-- 020_domain_tags.sql
+
+-- Ownership and criticality of each host.
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS owner VARCHAR(255);
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS criticality VARCHAR(20); -- Low, Medium, High, Critical
+
+CREATE INDEX IF NOT EXISTS idx_domains_owner ON domains(workspace_id, owner);
+CREATE INDEX IF NOT EXISTS idx_domains_criticality ON domains(workspace_id, criticality);
+
+-- Rules tagging hosts by name: "*.staging.example.com" matches
+-- staging.example.com and every host below it.
+CREATE TABLE IF NOT EXISTS tag_rules (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    pattern VARCHAR(255) NOT NULL,
+    key VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, pattern, key)
+);
+
+-- Key/value tags, one value per key. rule_id is set when a rule applied
+-- the tag; tags set by hand have none and are never overwritten by rules.
+CREATE TABLE IF NOT EXISTS domain_tags (
+    domain_id INT NOT NULL REFERENCES domains(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL DEFAULT '',
+    rule_id INT REFERENCES tag_rules(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (domain_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_tags_key_value ON domain_tags(key, value);
+
+-- Alert routing: a channel only receives alerts for hosts carrying all of
+-- its tags ("key" or "key=value") and at least its criticality.
+ALTER TABLE alert_channels ADD COLUMN IF NOT EXISTS route_tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE alert_channels ADD COLUMN IF NOT EXISTS min_criticality VARCHAR(20);
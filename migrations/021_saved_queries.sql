@@ -0,0 +1,14 @@
+-- 021_saved_queries.sql
+
+-- Named searches in the query language of the domain and technology lists.
+CREATE TABLE IF NOT EXISTS saved_queries (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    target VARCHAR(20) NOT NULL DEFAULT 'domains', -- domains, technologies
+    query TEXT NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, target, name)
+);
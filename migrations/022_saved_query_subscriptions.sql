@@ -0,0 +1,39 @@
+-- 022_saved_query_subscriptions.sql
+
+-- Saved views: a query plus the other /domains filters (URL-encoded), an
+-- optional pin to the sidebar, and when subscribers were last checked.
+ALTER TABLE saved_queries ADD COLUMN IF NOT EXISTS filters TEXT NOT NULL DEFAULT '';
+ALTER TABLE saved_queries ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE saved_queries ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMP WITH TIME ZONE;
+
+-- Alert channels notified when domains enter or leave a saved view.
+CREATE TABLE IF NOT EXISTS saved_query_subscriptions (
+    saved_query_id INT NOT NULL REFERENCES saved_queries(id) ON DELETE CASCADE,
+    channel_id INT NOT NULL REFERENCES alert_channels(id) ON DELETE CASCADE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (saved_query_id, channel_id)
+);
+
+-- The result set at the last evaluation. No foreign key on domain_id so a
+-- deleted domain is still reported as having left.
+CREATE TABLE IF NOT EXISTS saved_query_members (
+    saved_query_id INT NOT NULL REFERENCES saved_queries(id) ON DELETE CASCADE,
+    domain_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    since TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (saved_query_id, domain_id)
+);
+
+-- Membership changes found by the evaluator.
+CREATE TABLE IF NOT EXISTS saved_query_changes (
+    id SERIAL PRIMARY KEY,
+    saved_query_id INT NOT NULL REFERENCES saved_queries(id) ON DELETE CASCADE,
+    entered TEXT[] NOT NULL DEFAULT '{}',
+    left_names TEXT[] NOT NULL DEFAULT '{}',
+    entered_count INT NOT NULL DEFAULT 0,
+    left_count INT NOT NULL DEFAULT 0,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_query_changes_query ON saved_query_changes(saved_query_id, changed_at DESC);
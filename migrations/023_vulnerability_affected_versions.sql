@@ -0,0 +1,5 @@
+-- 023_vulnerability_affected_versions.sql
+
+-- Version ranges a CVE applies to, e.g. ">=1.0,<1.20.1 || >=1.21,<1.21.3".
+-- NULL when the source did not say.
+ALTER TABLE vulnerability_details ADD COLUMN IF NOT EXISTS affected_versions TEXT;
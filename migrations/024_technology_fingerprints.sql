@@ -0,0 +1,9 @@
+-- 024_technology_fingerprints.sql
+
+-- Wappalyzer matchers (headers, cookies, meta, html, scripts, ...) for the
+-- in-process detection engine, one document per technology.
+CREATE TABLE IF NOT EXISTS technology_fingerprints (
+    technology_id INTEGER PRIMARY KEY REFERENCES technologies(id) ON DELETE CASCADE,
+    matchers JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
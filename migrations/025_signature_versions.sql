@@ -0,0 +1,27 @@
+-- 025_signature_versions.sql
+
+-- Every distinct fingerprints/categories pair a sync has seen. The documents
+-- are kept so an older version can be re-applied (rollback) without network
+-- access; at most one version is active and at most one is pinned.
+CREATE TABLE IF NOT EXISTS signature_versions (
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL UNIQUE,
+    fingerprints_etag TEXT NOT NULL DEFAULT '',
+    categories_etag TEXT NOT NULL DEFAULT '',
+    fingerprints BYTEA NOT NULL,
+    categories BYTEA NOT NULL,
+    technology_count INTEGER NOT NULL DEFAULT 0,
+    category_count INTEGER NOT NULL DEFAULT 0,
+    added_count INTEGER NOT NULL DEFAULT 0,
+    removed_count INTEGER NOT NULL DEFAULT 0,
+    changed_count INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    checked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    applied_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signature_versions_active ON signature_versions (active) WHERE active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signature_versions_pinned ON signature_versions (pinned) WHERE pinned;
@@ -0,0 +1,18 @@
+-- 026_custom_fingerprints.sql
+
+-- Where a technology's signature comes from. Sync never overwrites a
+-- 'custom' technology, even when upstream has one of the same name.
+ALTER TABLE technologies ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'wappalyzer';
+
+-- Every saved revision of a custom fingerprint; the latest is the one in
+-- technology_fingerprints.
+CREATE TABLE IF NOT EXISTS technology_fingerprint_revisions (
+    technology_id INTEGER NOT NULL REFERENCES technologies(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    matchers JSONB NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    website TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (technology_id, revision)
+);
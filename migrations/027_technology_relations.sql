@@ -0,0 +1,17 @@
+-- 027_technology_relations.sql
+
+-- implies, requires and excludes from the Wappalyzer schema, resolved to
+-- technology IDs. Confidence scales detections inferred through an implies.
+CREATE TABLE IF NOT EXISTS technology_relations (
+    technology_id INT NOT NULL REFERENCES technologies(id) ON DELETE CASCADE,
+    related_id INT NOT NULL REFERENCES technologies(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('implies', 'requires', 'excludes')),
+    confidence INT NOT NULL DEFAULT 100 CHECK (confidence >= 0 AND confidence <= 100),
+    PRIMARY KEY (technology_id, kind, related_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_technology_relations_related ON technology_relations(related_id);
+
+-- Detections inferred through an implies point at the observed technology
+-- they were inferred from; observed detections leave it NULL.
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS inferred_from INT REFERENCES technologies(id) ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+-- 028_detection_version_key.sql
+
+-- The detected version encoded by internal/version so that byte order is
+-- version order: compare and sort it with COLLATE "C". NULL when there is
+-- no version or it cannot be read. Existing rows are filled in by the
+-- server at startup.
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS version_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_detections_version_key ON detections(technology_id, version_key COLLATE "C");
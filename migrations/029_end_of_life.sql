@@ -0,0 +1,87 @@
+-- 029_end_of_life.sql
+
+-- Release cycles imported from endoflife.date, or files in its format.
+CREATE TABLE IF NOT EXISTS eol_products (
+    product VARCHAR(100) PRIMARY KEY, -- endoflife.date product, e.g. nginx
+    source TEXT NOT NULL,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS eol_cycles (
+    product VARCHAR(100) NOT NULL REFERENCES eol_products(product) ON DELETE CASCADE,
+    cycle VARCHAR(50) NOT NULL,
+    release_date DATE,
+    eol_date DATE,
+    eol BOOLEAN NOT NULL DEFAULT FALSE, -- end of life, no date given
+    latest VARCHAR(100),
+    lts BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (product, cycle)
+);
+
+-- Which product a technology is, by technology name like
+-- technology_vuln_profile. Products need not be imported yet.
+CREATE TABLE IF NOT EXISTS eol_mappings (
+    technology VARCHAR(255) PRIMARY KEY,
+    product VARCHAR(100) NOT NULL
+);
+
+INSERT INTO eol_mappings (technology, product) VALUES
+    ('Nginx', 'nginx'),
+    ('Apache HTTP Server', 'apache'),
+    ('Apache Tomcat', 'tomcat'),
+    ('PHP', 'php'),
+    ('Python', 'python'),
+    ('Ruby', 'ruby'),
+    ('Perl', 'perl'),
+    ('Go', 'go'),
+    ('Node.js', 'nodejs'),
+    ('OpenSSL', 'openssl'),
+    ('WordPress', 'wordpress'),
+    ('Drupal', 'drupal'),
+    ('Magento', 'magento'),
+    ('TYPO3 CMS', 'typo3'),
+    ('Ghost', 'ghost'),
+    ('Umbraco', 'umbraco'),
+    ('Moodle', 'moodle'),
+    ('Nextcloud', 'nextcloud'),
+    ('Django', 'django'),
+    ('Laravel', 'laravel'),
+    ('Symfony', 'symfony'),
+    ('Ruby on Rails', 'rails'),
+    ('Next.js', 'nextjs'),
+    ('Nuxt.js', 'nuxt'),
+    ('Angular', 'angular'),
+    ('AngularJS', 'angularjs'),
+    ('React', 'react'),
+    ('Vue.js', 'vue'),
+    ('jQuery', 'jquery'),
+    ('Bootstrap', 'bootstrap'),
+    ('Varnish', 'varnish'),
+    ('HAProxy', 'haproxy'),
+    ('Traefik', 'traefik'),
+    ('Envoy', 'envoy'),
+    ('Elasticsearch', 'elasticsearch'),
+    ('Kibana', 'kibana'),
+    ('Grafana', 'grafana'),
+    ('Jenkins', 'jenkins'),
+    ('GitLab', 'gitlab'),
+    ('Confluence', 'confluence'),
+    ('MySQL', 'mysql'),
+    ('MariaDB', 'mariadb'),
+    ('PostgreSQL', 'postgresql'),
+    ('MongoDB', 'mongodb'),
+    ('Redis', 'redis'),
+    ('Ubuntu', 'ubuntu'),
+    ('Debian', 'debian'),
+    ('CentOS', 'centos')
+ON CONFLICT (technology) DO NOTHING;
+
+-- The verdict on each detection's version, refreshed by the server; NULL
+-- when the technology is not mapped or the version fits no cycle.
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS eol_status VARCHAR(10) CHECK (eol_status IN ('supported', 'outdated', 'eol'));
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS eol_cycle VARCHAR(50);
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS eol_date DATE;
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS majors_behind INT NOT NULL DEFAULT 0;
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS minors_behind INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_detections_eol_status ON detections(eol_status) WHERE eol_status IS NOT NULL;
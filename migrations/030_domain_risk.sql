@@ -0,0 +1,89 @@
+-- 030_domain_risk.sql
+
+-- Composite risk score of each domain (see internal/risk), with the
+-- factors it is made of, largest first.
+CREATE TABLE IF NOT EXISTS domain_risk (
+    domain_id INT PRIMARY KEY REFERENCES domains(id) ON DELETE CASCADE,
+    score INT NOT NULL DEFAULT 0 CHECK (score BETWEEN 0 AND 100),
+    level VARCHAR(20) NOT NULL DEFAULT 'Low',
+    factors JSONB NOT NULL DEFAULT '[]',
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_risk_score ON domain_risk(score DESC);
+CREATE INDEX IF NOT EXISTS idx_domain_risk_computed ON domain_risk(computed_at);
+
+-- Domains whose inputs changed since they were scored. Triggers below fill
+-- it and the risk worker drains it. No foreign key: rows are queued while
+-- their domain is being deleted, and the worker drops those.
+CREATE TABLE IF NOT EXISTS domain_risk_queue (
+    domain_id INT PRIMARY KEY,
+    queued_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Queues the domain a row belongs to, before and after the change;
+-- TG_ARGV[0] names the row's domain column.
+CREATE OR REPLACE FUNCTION domain_risk_enqueue() RETURNS trigger AS $$
+DECLARE
+    ids INT[] := '{}';
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        ids := ids || (to_jsonb(OLD) ->> TG_ARGV[0])::INT;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        ids := ids || (to_jsonb(NEW) ->> TG_ARGV[0])::INT;
+    END IF;
+    INSERT INTO domain_risk_queue (domain_id)
+    SELECT DISTINCT id FROM unnest(ids) AS id WHERE id IS NOT NULL
+    ON CONFLICT (domain_id) DO UPDATE SET queued_at = CURRENT_TIMESTAMP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Queues every domain running a technology whose vulnerability profile
+-- changed.
+CREATE OR REPLACE FUNCTION domain_risk_enqueue_technology() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO domain_risk_queue (domain_id)
+    SELECT DISTINCT det.domain_id FROM detections det
+    JOIN technologies t ON t.id = det.technology_id
+    WHERE t.name = NEW.technology
+    ON CONFLICT (domain_id) DO UPDATE SET queued_at = CURRENT_TIMESTAMP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS domain_risk_domains ON domains;
+CREATE TRIGGER domain_risk_domains
+    AFTER INSERT OR UPDATE OF criticality ON domains
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue('id');
+
+DROP TRIGGER IF EXISTS domain_risk_detections ON detections;
+CREATE TRIGGER domain_risk_detections
+    AFTER INSERT OR DELETE OR UPDATE OF technology_id, eol_status, majors_behind, minors_behind ON detections
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue('domain_id');
+
+DROP TRIGGER IF EXISTS domain_risk_active_vulnerabilities ON active_vulnerabilities;
+CREATE TRIGGER domain_risk_active_vulnerabilities
+    AFTER INSERT OR DELETE OR UPDATE OF severity ON active_vulnerabilities
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue('domain_id');
+
+DROP TRIGGER IF EXISTS domain_risk_tls_endpoints ON tls_endpoints;
+CREATE TRIGGER domain_risk_tls_endpoints
+    AFTER INSERT OR DELETE OR UPDATE ON tls_endpoints
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue('domain_id');
+
+DROP TRIGGER IF EXISTS domain_risk_dns_status ON dns_status;
+CREATE TRIGGER domain_risk_dns_status
+    AFTER INSERT OR DELETE OR UPDATE OF dangling, takeover_service ON dns_status
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue('domain_id');
+
+DROP TRIGGER IF EXISTS domain_risk_vuln_profile ON technology_vuln_profile;
+CREATE TRIGGER domain_risk_vuln_profile
+    AFTER INSERT OR UPDATE OF risk_level, cve_count, exploit_available ON technology_vuln_profile
+    FOR EACH ROW EXECUTE FUNCTION domain_risk_enqueue_technology();
+
+-- Score existing domains.
+INSERT INTO domain_risk_queue (domain_id)
+SELECT id FROM domains
+ON CONFLICT (domain_id) DO NOTHING;
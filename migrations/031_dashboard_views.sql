@@ -0,0 +1,90 @@
+-- 031_dashboard_views.sql
+
+-- Dashboard stats gain the critical technology count, read live before.
+DROP MATERIALIZED VIEW IF EXISTS view_dashboard_stats;
+
+CREATE MATERIALIZED VIEW view_dashboard_stats AS
+SELECT
+    w.id as workspace_id,
+    (SELECT COUNT(*) FROM detections det WHERE det.workspace_id = w.id) as total_detections,
+    (SELECT COALESCE(AVG(det.confidence), 0) FROM detections det WHERE det.workspace_id = w.id) as avg_confidence,
+    (SELECT COUNT(DISTINCT t.id) FROM detections det
+        JOIN technologies t ON det.technology_id = t.id
+        LEFT JOIN technology_vuln_profile vp ON t.name = vp.technology
+        WHERE det.workspace_id = w.id AND COALESCE(vp.risk_level, t.risk_level) IN ('High', 'Critical')) as risky_technologies,
+    (SELECT COUNT(*) FROM domains d WHERE d.workspace_id = w.id AND d.is_bookmarked = TRUE) as bookmarked_domains,
+    (SELECT COUNT(DISTINCT vp.technology) FROM technology_vuln_profile vp
+        JOIN technologies t ON t.name = vp.technology
+        JOIN detections det ON det.technology_id = t.id
+        WHERE vp.risk_level = 'Critical' AND det.workspace_id = w.id) as critical_technologies,
+    CURRENT_TIMESTAMP as last_refreshed
+FROM workspaces w;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_stats_workspace ON view_dashboard_stats(workspace_id);
+
+-- New detections per workspace and day, for the trends widget.
+CREATE MATERIALIZED VIEW IF NOT EXISTS view_dashboard_trends AS
+SELECT workspace_id, DATE_TRUNC('day', created_at) as day, COUNT(*) as detections
+FROM detections
+WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '90 days'
+GROUP BY workspace_id, DATE_TRUNC('day', created_at);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_trends_day ON view_dashboard_trends(workspace_id, day);
+
+-- Detections per workspace and technology, for the distribution widget.
+CREATE MATERIALIZED VIEW IF NOT EXISTS view_dashboard_distribution AS
+SELECT det.workspace_id, t.name as technology, COUNT(*) as detections
+FROM detections det
+JOIN technologies t ON det.technology_id = t.id
+GROUP BY det.workspace_id, t.name;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_distribution_tech ON view_dashboard_distribution(workspace_id, technology);
+CREATE INDEX IF NOT EXISTS idx_dashboard_distribution_count ON view_dashboard_distribution(workspace_id, detections DESC);
+
+-- When each dashboard view was last refreshed, and since when its sources
+-- have changed without a refresh. Statement triggers below set
+-- dirty_since; the refresher clears it.
+CREATE TABLE IF NOT EXISTS materialized_view_refreshes (
+    view_name VARCHAR(100) PRIMARY KEY,
+    refreshed_at TIMESTAMP WITH TIME ZONE,
+    duration_ms INT,
+    trigger VARCHAR(20), -- schedule, ingest, manual
+    error TEXT,
+    dirty_since TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO materialized_view_refreshes (view_name, refreshed_at, trigger) VALUES
+    ('view_dashboard_stats', CURRENT_TIMESTAMP, 'schedule'),
+    ('view_dashboard_trends', CURRENT_TIMESTAMP, 'schedule'),
+    ('view_dashboard_distribution', CURRENT_TIMESTAMP, 'schedule')
+ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at, dirty_since = NULL;
+
+-- Marks the dashboard views dirty; TG_ARGV lists the views the table feeds.
+-- Only the first change after a refresh writes.
+CREATE OR REPLACE FUNCTION dashboard_mark_dirty() RETURNS trigger AS $$
+BEGIN
+    UPDATE materialized_view_refreshes SET dirty_since = CURRENT_TIMESTAMP
+    WHERE view_name = ANY(TG_ARGV) AND dirty_since IS NULL;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS dashboard_dirty_detections ON detections;
+CREATE TRIGGER dashboard_dirty_detections
+    AFTER INSERT OR DELETE OR UPDATE OF technology_id, confidence ON detections
+    FOR EACH STATEMENT EXECUTE FUNCTION dashboard_mark_dirty('view_dashboard_stats', 'view_dashboard_trends', 'view_dashboard_distribution');
+
+DROP TRIGGER IF EXISTS dashboard_dirty_domains ON domains;
+CREATE TRIGGER dashboard_dirty_domains
+    AFTER INSERT OR DELETE OR UPDATE OF is_bookmarked ON domains
+    FOR EACH STATEMENT EXECUTE FUNCTION dashboard_mark_dirty('view_dashboard_stats');
+
+DROP TRIGGER IF EXISTS dashboard_dirty_vuln_profile ON technology_vuln_profile;
+CREATE TRIGGER dashboard_dirty_vuln_profile
+    AFTER INSERT OR DELETE OR UPDATE ON technology_vuln_profile
+    FOR EACH STATEMENT EXECUTE FUNCTION dashboard_mark_dirty('view_dashboard_stats');
+
+DROP TRIGGER IF EXISTS dashboard_dirty_workspaces ON workspaces;
+CREATE TRIGGER dashboard_dirty_workspaces
+    AFTER INSERT OR DELETE ON workspaces
+    FOR EACH STATEMENT EXECUTE FUNCTION dashboard_mark_dirty('view_dashboard_stats');
@@ -0,0 +1,19 @@
+-- 032_trend_windows.sql
+
+-- Trend windows count detections by creation time within a workspace.
+CREATE INDEX IF NOT EXISTS idx_detections_workspace_created ON detections(workspace_id, created_at);
+
+-- Days of the dashboard trends view are UTC, whatever the session timezone,
+-- so they line up with the UTC daily buckets they are served for.
+DROP MATERIALIZED VIEW IF EXISTS view_dashboard_trends;
+
+CREATE MATERIALIZED VIEW view_dashboard_trends AS
+SELECT workspace_id, DATE_TRUNC('day', created_at AT TIME ZONE 'UTC') as day, COUNT(*) as detections
+FROM detections
+WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '90 days'
+GROUP BY workspace_id, DATE_TRUNC('day', created_at AT TIME ZONE 'UTC');
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_trends_day ON view_dashboard_trends(workspace_id, day);
+
+UPDATE materialized_view_refreshes SET refreshed_at = CURRENT_TIMESTAMP, dirty_since = NULL, error = NULL
+WHERE view_name = 'view_dashboard_trends';